@@ -3,6 +3,7 @@ package fem
 import (
 	"errors"
 	"fmt"
+	"runtime"
 
 	"github.com/soypat/lap"
 	"gonum.org/v1/gonum/mat"
@@ -13,8 +14,16 @@ import (
 type GeneralAssembler struct {
 	// Stiffness matrix of modelled solid.
 	ksolid lap.Sparse
+	// Mass matrix of modelled solid.
+	msolid lap.Sparse
+	// Geometric stiffness matrix of modelled solid.
+	kgeom lap.Sparse
+	// Damping matrix of modelled solid.
+	csolid lap.Sparse
 	nodes  []r3.Vec
 	dofs   DofsFlag
+	// Number of goroutines used in assembly. Assembly is sequential if less than 2.
+	workers int
 }
 
 // NewSymAssembler initializes a GeneralAssembler ready for use.
@@ -22,14 +31,46 @@ func NewGeneralAssembler(nodes []r3.Vec, modelDofs DofsFlag) *GeneralAssembler {
 	totalDofs := len(nodes) * modelDofs.Count()
 	return &GeneralAssembler{
 		ksolid: *lap.NewSparse(totalDofs, totalDofs),
+		msolid: *lap.NewSparse(totalDofs, totalDofs),
+		kgeom:  *lap.NewSparse(totalDofs, totalDofs),
+		csolid: *lap.NewSparse(totalDofs, totalDofs),
 		dofs:   modelDofs,
 		nodes:  nodes,
 	}
 }
 
+// SetConcurrency sets the number of goroutines that the following methods partition the elements across:
+//   - AddIsoparametric and AddIsoparametricMaterials
+//   - AddIsoparametricMass, AddIsoparametricMassMaterials and AddIsoparametricCapacity
+//   - AddIsoparametricRayleighDamping
+//   - NewExplicitModel and ExplicitModel.InternalForces
+//
+// If workers is less than 1 then runtime.GOMAXPROCS(0) workers are used. Each worker integrates its
+// elements into its own scratch space and the element matrices are accumulated in element order, so
+// results do not depend on the number of workers. The getElement and constituter arguments of these
+// methods must then be safe for concurrent use. The assembler's other methods, such as load vector,
+// lumped mass, stress recovery and geometric stiffness assembly, are always sequential.
+// Assemblers are sequential by default.
+func (ga *GeneralAssembler) SetConcurrency(workers int) {
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	ga.workers = workers
+}
+
 // Ksolid returns the stiffness matrix of the solid.
 func (ga *GeneralAssembler) Ksolid() *lap.Sparse { return &ga.ksolid }
 
+// Msolid returns the mass matrix of the solid. For thermal models it
+// is the heat capacity matrix added by AddIsoparametricCapacity.
+func (ga *GeneralAssembler) Msolid() *lap.Sparse { return &ga.msolid }
+
+// Kgeometric returns the geometric stiffness matrix of the solid due to its stress state.
+func (ga *GeneralAssembler) Kgeometric() *lap.Sparse { return &ga.kgeom }
+
+// Csolid returns the damping matrix of the solid added by AddIsoparametricRayleighDamping.
+func (ga *GeneralAssembler) Csolid() *lap.Sparse { return &ga.csolid }
+
 // TotalDofs returns the total number of dofs in the model.
 func (ga *GeneralAssembler) TotalDofs() int {
 	r, _ := ga.ksolid.Dims()
@@ -95,24 +136,7 @@ func assembleElement(V []float64, I, J, elemDofs []int, Ke *mat.Dense) {
 // dofs then an error is returned.
 // The length of the slice returned is equal to the amount of dofs per element node.
 func (ga *GeneralAssembler) DofMapping(e Element) ([]int, error) {
-	modelDofs := ga.dofs
-	numModelDofs := modelDofs.Count()
-	elemDofs := e.Dofs()
-	if !modelDofs.Has(elemDofs) {
-		return nil, fmt.Errorf("model dofs %s does not contain all element dofs %s", ga.dofs.String(), e.Dofs().String())
-	}
-	dofMapping := make([]int, elemDofs.Count())
-	idm := 0
-	for i := 0; i < numModelDofs; i++ {
-		if modelDofs.Has(1<<i) && elemDofs.Has(1<<i) {
-			dofMapping[idm] = i
-			idm++
-		}
-	}
-	if idm == 0 || len(dofMapping) == 0 {
-		return nil, errors.New("element has empty dof mapping")
-	}
-	return dofMapping, nil
+	return mapdofs(ga.dofs, e.Dofs())
 }
 
 func storeElemNode(dst []float64, allNodes []r3.Vec, elem []int, dims int) {
@@ -157,10 +181,81 @@ func storeElemDofs(dst, elem, dofmapping []int, modelDofsPerNode int) {
 	}
 }
 
+// AddElement3 adds Nelem elements of type elemT to the model's solid stiffness matrix.
+// It calls getElement to get the element nodes and orientation vectors, each with
+// an incrementing element index i.
+//
+// The element stiffness matrix is given in the element's local axes, which are
+// oriented so that local X points along x and local Y lies in the plane of x and y.
+// Elements with x and y both zero are not rotated. The local X axis of an AxialElement3
+// is defined by its nodes instead, so x must be zero and y is the orientation vector of
+// its cross section, such as a beam's v-vector. If y is also zero the global Y axis is used,
+// or the global Z axis if the element is parallel to Y.
 func (ga *GeneralAssembler) AddElement3(elemT Element3, c Constituter, Nelem int, getElement func(i int) (e []int, x, y r3.Vec)) error {
 	if elemT == nil || c == nil || getElement == nil {
 		panic("nil argument to AddElement3") // This is very likely programmer error.
 	}
+	NdofPerElem := elemT.Dofs().Count() * elemT.LenNodes()
+	Ke := mat.NewDense(NdofPerElem, NdofPerElem, nil)
+	aux := mat.NewDense(NdofPerElem, NdofPerElem, nil)
+	return ga.forEachElement3(elemT, Nelem, getElement, func(iele int, elemNodes []r3.Vec, T *mat.Dense, elemDofs []int) error {
+		Ke.Zero()
+		err := elemT.CopyK(Ke, elemNodes)
+		if err != nil {
+			return err
+		}
+		if T != nil {
+			// Ke = Tᵀ*Ke*T
+			aux.Mul(T.T(), Ke)
+			Ke.Mul(aux, T)
+		}
+		addElementMatrix(&ga.ksolid, elemDofs, Ke)
+		return nil
+	})
+}
+
+// AddElement3Geometric adds the geometric stiffness matrix of Nelem elements of type elemT
+// to the model's geometric stiffness matrix given the displacements of a solved reference load case,
+// such as those returned by LinearStatic. getElement must return the same elements and orientation
+// vectors passed to AddElement3. See Buckling for its use.
+func (ga *GeneralAssembler) AddElement3Geometric(elemT GeometricElement3, displacements lap.Vector, Nelem int, getElement func(i int) (e []int, x, y r3.Vec)) error {
+	if elemT == nil || displacements == nil || getElement == nil {
+		panic("nil argument to AddElement3Geometric") // This is very likely programmer error.
+	} else if displacements.Len() != ga.TotalDofs() {
+		return fmt.Errorf("displacements vector length %d does not match total number of dofs %d", displacements.Len(), ga.TotalDofs())
+	}
+	NdofPerElem := elemT.Dofs().Count() * elemT.LenNodes()
+	Kg := mat.NewDense(NdofPerElem, NdofPerElem, nil)
+	aux := mat.NewDense(NdofPerElem, NdofPerElem, nil)
+	ue := mat.NewVecDense(NdofPerElem, nil)
+	ul := mat.NewVecDense(NdofPerElem, nil)
+	return ga.forEachElement3(elemT, Nelem, getElement, func(iele int, elemNodes []r3.Vec, T *mat.Dense, elemDofs []int) error {
+		for i, dof := range elemDofs {
+			ue.SetVec(i, displacements.AtVec(dof))
+		}
+		if T != nil {
+			ul.MulVec(T, ue)
+		} else {
+			ul.CopyVec(ue)
+		}
+		Kg.Zero()
+		err := elemT.CopyKGeometric(Kg, elemNodes, ul.RawVector().Data)
+		if err != nil {
+			return err
+		}
+		if T != nil {
+			aux.Mul(T.T(), Kg)
+			Kg.Mul(aux, T)
+		}
+		addElementMatrix(&ga.kgeom, elemDofs, Kg)
+		return nil
+	})
+}
+
+// forEachElement3 iterates over Nelem elements of type elemT, calling fn with the element's nodes,
+// its global dofs and the rotation matrix T from global to the element's local axes, which is nil
+// if the element is not rotated. See AddElement3 for how the local axes are defined.
+func (ga *GeneralAssembler) forEachElement3(elemT Element3, Nelem int, getElement func(i int) (e []int, x, y r3.Vec), fn func(iele int, elemNodes []r3.Vec, T *mat.Dense, elemDofs []int) error) error {
 	dofMapping, err := ga.DofMapping(elemT)
 	if err != nil {
 		return err
@@ -177,38 +272,58 @@ func (ga *GeneralAssembler) AddElement3(elemT Element3, c Constituter, Nelem int
 	if ga.dofs>>6 != 0 {
 		panic("AddElement3 currently only handles 6 rigid body motion degrees of freedom")
 	}
+	axial, isAxial := elemT.(AxialElement3)
+	// Element dofs are rotated in groups of 3 components.
+	elemDofsFlag := elemT.Dofs()
+	canRotate := elemDofsFlag&DofPos != 0 || elemDofsFlag&DofRot != 0
+	for _, group := range []DofsFlag{DofPos, DofRot} {
+		if d := elemDofsFlag & group; d != 0 && d != group {
+			canRotate = false
+		}
+	}
 	var T3 r3.Mat
-	rotator := lapmat{lap.Slice(blkDiag{
-		rep: 2 * NnodPerElem,
+	rotator := blkDiag{
+		rep: NdofPerElem / 3,
 		m:   &T3,
-	}, dofMapping, dofMapping)}
-
-	Ke := mat.NewDense(NdofPerElem, NdofPerElem, nil)
+	}
+	T := mat.NewDense(NdofPerElem, NdofPerElem, nil)
 	elemNodes := make([]r3.Vec, NnodPerElem)
 	elemDofs := make([]int, NdofPerElem)
 	for iele := 0; iele < Nelem; iele++ {
-		Ke.Zero()
 		element, x, y := getElement(iele)
-		if x != (r3.Vec{}) || y != (r3.Vec{}) {
-			return errors.New("element orientation not supported yet")
-		}
 		if len(element) != NnodPerElem {
 			return fmt.Errorf("element #%d of %d nodes expected to be of %d nodes", iele, len(element), NnodPerElem)
 		}
 		for i, elnod := range element {
 			elemNodes[i] = ga.nodes[elnod]
 		}
-		err := elemT.CopyK(Ke, elemNodes)
-		if err != nil {
-			return err
+		rotate := x != (r3.Vec{}) || y != (r3.Vec{})
+		if isAxial {
+			if x != (r3.Vec{}) {
+				return fmt.Errorf("element #%d: local X axis of axial element is defined by its nodes, x must be zero", iele)
+			}
+			x = axial.LocalX(elemNodes)
+			if y == (r3.Vec{}) {
+				y = r3.Vec{Y: 1}
+				if r3.Norm(r3.Cross(x, y)) <= 1e-12*r3.Norm(x) {
+					y = r3.Vec{Z: 1}
+				}
+			}
+			rotate = true
 		}
-
-		if x != (r3.Vec{}) && y != (r3.Vec{}) {
-			// Rotate element stiffness matrix to match user input orientation.
+		var Te *mat.Dense
+		if rotate {
+			if !canRotate {
+				return errors.New("element dofs can not be rotated")
+			}
+			// Rotate element matrices to match user input orientation.
+			orientZ := r3.Cross(x, y)
+			if !(r3.Norm(orientZ) > 1e-12*r3.Norm(x)*r3.Norm(y)) {
+				return fmt.Errorf("element #%d: orientation vectors must be non-zero and not parallel", iele)
+			}
 			orientX := r3.Unit(x)
-			orientY := r3.Unit(y)
-			orientZ := r3.Cross(orientX, orientY)
-			orientY = r3.Cross(orientZ, orientX) // Should be unit vector.
+			orientZ = r3.Unit(orientZ)
+			orientY := r3.Cross(orientZ, orientX) // Should be unit vector.
 			T3.Set(0, 0, orientX.X)
 			T3.Set(0, 1, orientX.Y)
 			T3.Set(0, 2, orientX.Z)
@@ -218,21 +333,27 @@ func (ga *GeneralAssembler) AddElement3(elemT Element3, c Constituter, Nelem int
 			T3.Set(2, 0, orientZ.X)
 			T3.Set(2, 1, orientZ.Y)
 			T3.Set(2, 2, orientZ.Z)
-			Ke.Mul(rotator.T(), Ke)
-			Ke.Mul(Ke, rotator)
+			T.Copy(rotator)
+			Te = T
 		}
 		storeElemDofs(elemDofs, element, dofMapping, NdofPerNodeModel)
-		for i := 0; i < NdofPerElem; i++ {
-			ei := elemDofs[i]
-			for j := 0; j < NdofPerElem; j++ {
-				ej := elemDofs[j]
-				ga.ksolid.Set(ei, ej, ga.ksolid.At(ei, ej)+Ke.At(i, j))
-			}
+		err := fn(iele, elemNodes, Te, elemDofs)
+		if err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
+// addElementMatrix adds element matrix Ke to dst at the element's global dofs.
+func addElementMatrix(dst *lap.Sparse, elemDofs []int, Ke *mat.Dense) {
+	for i, ei := range elemDofs {
+		for j, ej := range elemDofs {
+			dst.Set(ei, ej, dst.At(ei, ej)+Ke.At(i, j))
+		}
+	}
+}
+
 type blkDiag struct {
 	rep int
 	m   mat.Matrix
@@ -245,7 +366,7 @@ func (b blkDiag) Dims() (int, int) {
 
 func (b blkDiag) At(i, j int) float64 {
 	r, c := b.m.Dims()
-	if i > b.rep*r || j > b.rep*c {
+	if i >= b.rep*r || j >= b.rep*c {
 		panic("bad access")
 	}
 	iq := i / r
@@ -24,192 +24,1174 @@ func (ga *GeneralAssembler) ForEachElement(elemT Element, spatialDimsPerNode, Ne
 // It calls getElement to get the element nodes and coordinates Nelem times, each with
 // an incrementing element index i.
 //
-// Arbitrary orientation of solid properties for each isoparametric element is not yet implemented.
+// The material axes of each element are oriented with xC and yC as described by
+// OrientableIsoConstituter. Both zero leave the material axes aligned with the model's axes.
 func (ga *GeneralAssembler) AddIsoparametric(elemT Isoparametric, c IsoConstituter, Nelem int, getElement func(i int) (elem []int, xC, yC r3.Vec)) error {
-	C, err := c.Constitutive()
+	if c == nil || getElement == nil {
+		panic("nil argument to AddIsoparametric") // This is very likely programmer error.
+	}
+	return ga.AddIsoparametricMaterials(elemT, []IsoConstituter{c}, Nelem, func(i int) (elem []int, material int, xC, yC r3.Vec) {
+		elem, xC, yC = getElement(i)
+		return elem, 0, xC, yC
+	})
+}
+
+// AddIsoparametricMaterials adds isoparametric elements of different materials to the model's
+// solid stiffness matrix. It works like AddIsoparametric with getElement also returning the index
+// of each element's constituter in materials. The constitutive matrices of the materials are
+// calculated once and must all be of the same dimension.
+//
+// AddIsoparametricMassMaterials and AddIsoparametricThermalLoadMaterials take the same material
+// table. Stress recovery and AddIsoparametricGeometric take a single constituter and are called
+// once for the elements of each material.
+func (ga *GeneralAssembler) AddIsoparametricMaterials(elemT Isoparametric, materials []IsoConstituter, Nelem int, getElement func(i int) (elem []int, material int, xC, yC r3.Vec)) error {
+	if len(materials) == 0 {
+		return errors.New("no materials")
+	}
+	var dimC int
+	Cds := make([]*mat.Dense, len(materials))
+	for i, c := range materials {
+		Cd, err := denseConstitutive(c)
+		if err != nil {
+			return fmt.Errorf("material %d: %w", i, err)
+		}
+		r, _ := Cd.Dims()
+		if i == 0 {
+			dimC = r
+		} else if r != dimC {
+			return fmt.Errorf("material %d constitutive matrix dimension %d does not match %d of material 0", i, r, dimC)
+		}
+		Cds[i] = Cd
+	}
+	it, err := newIsoIntegrator(elemT)
 	if err != nil {
 		return err
 	}
-	dimC, _c := C.Dims()
-	if _c != dimC {
-		return fmt.Errorf("expected constitutive matrix to be square, got %dx%d", dimC, c)
-	}
-
 	var (
-		// Number of integration dimensions per node. Usually spatial, so 2 for 2D problems and 3 for 3D problems.
-		NdimsPerNode = len(elemT.BasisDiff(r3.Vec{})) / elemT.LenNodes()
 		// Number of dofs per node. These contain the field variables.
 		// For example, for a 2D displacement problem these are the x and y displacements, so equal to 2.
 		// For a thermal problem there is always only 1 dof per node for the temperature, regardless of the number of spatial dimensions.
 		NdofsPerNode = elemT.Dofs().Count() //
-		// Number of nodes per element.
-		NnodperElem = elemT.LenNodes()
 		// Number of dofs per element.
-		NdofperElem = NnodperElem * NdofsPerNode
-		// Element stiffness matrix. The results of integrating the element's stiffness matrix over the element's domain.
-		Ke = mat.NewDense(NdofperElem, NdofperElem, nil)
-		// number of columns in Compliance x NdofPerNode*nodesperelement
-		B = mat.NewDense(dimC, NdofperElem, nil)
-		// Differentiated form functions with respect to the integration coordinates.
-		dNxy = mat.NewDense(NdimsPerNode, NnodperElem, nil)
-		// Quadrature integration points.
-		upg, wpg = elemT.Quadrature()
-		// Compliance matrix as a Dense matrix.
-		Cd = mat.NewDense(dimC, dimC, nil)
+		NdofperElem = it.NnodperElem * NdofsPerNode
 	)
-	if len(upg) == 0 || len(upg) != len(wpg) {
-		return fmt.Errorf("bad quadrature result from isoparametric element")
+	NvalPerElem := NdofperElem * NdofperElem
+	spac := lap.NewSparseAccum(NvalPerElem * Nelem)
+	err = forEachElementConcurrent(ga.workers, ga.dofs, ga.nodes, elemT, it.NdimsPerNode, Nelem, func() (func(int) []int, elementDofCallback) {
+		it := it.clone()
+		var (
+			// Element stiffness matrix. The results of integrating the element's stiffness matrix over the element's domain.
+			Ke = mat.NewDense(NdofperElem, NdofperElem, nil)
+			// number of columns in Compliance x NdofPerNode*nodesperelement
+			B = mat.NewDense(dimC, NdofperElem, nil)
+			// Allocate memory for auxiliary matrices.
+			aux1 = mat.NewDense(NdofperElem, dimC, nil)
+			aux2 = mat.NewDense(NdofperElem, NdofperElem, nil)
+		)
+		var (
+			x, y     r3.Vec
+			material int
+		)
+		subGetElement := func(i int) (elem []int) {
+			elem, material, x, y = getElement(i)
+			return elem
+		}
+		return subGetElement, func(iele int, elemNodBacking []float64, elemDofs []int) error {
+			if material < 0 || material >= len(materials) {
+				return fmt.Errorf("element #%d material index %d out of range", iele, material)
+			}
+			c := materials[material]
+			Ce, err := orientedConstitutive(c, Cds[material], x, y)
+			if err != nil {
+				return err
+			}
+			elemNod := mat.NewDense(it.NnodperElem, it.NdimsPerNode, elemNodBacking)
+			err = it.stiffness(Ke, B, aux1, aux2, iele, elemNod, c, Ce)
+			if err != nil {
+				return err
+			}
+			offset := iele * NvalPerElem
+			assembleElement(spac.V[offset:], spac.I[offset:], spac.J[offset:], elemDofs, Ke)
+			return nil
+		}
+	})
+	if err != nil {
+		return err
 	}
-	Cd.Copy(C)
+	ga.ksolid.Accumulate(spac)
+	return nil
+}
 
-	// Calculate form functions evaluated at integration points.
-	Npg := make([]*mat.VecDense, len(upg))
-	dNpg := make([]*mat.Dense, len(upg))
-	for ipg, pg := range upg {
-		Npg[ipg] = mat.NewVecDense(NnodperElem, elemT.Basis(pg))
-		dNpg[ipg] = mat.NewDense(NdimsPerNode, NnodperElem, elemT.BasisDiff(pg))
+// AddIsoparametricMass adds the consistent mass matrix ∫ρ·Nᵀ·N dV of isoparametric
+// elements to the model's mass matrix. density is the mass per unit volume of the elements.
+// The domain is integrated with the scale factor returned by c in the same way
+// AddIsoparametric does, so the mass of an axisymmetric model is also per radian.
+func (ga *GeneralAssembler) AddIsoparametricMass(elemT Isoparametric, c IsoConstituter, density float64, Nelem int, getElement func(i int) (elem []int, xC, yC r3.Vec)) error {
+	if c == nil || getElement == nil {
+		panic("nil argument to AddIsoparametricMass")
 	}
-	// Allocate memory for auxiliary matrices.
-	jac := mat.NewDense(NdimsPerNode, NdimsPerNode, nil)
-
-	aux1 := mat.NewDense(NdofperElem, dimC, nil)
-	aux2 := mat.NewDense(NdofperElem, NdofperElem, nil)
+	return ga.AddIsoparametricMassMaterials(elemT, []IsoConstituter{c}, []float64{density}, Nelem, func(i int) (elem []int, material int, xC, yC r3.Vec) {
+		elem, xC, yC = getElement(i)
+		return elem, 0, xC, yC
+	})
+}
 
+// AddIsoparametricMassMaterials adds the consistent mass matrix of isoparametric elements of
+// different materials to the model's mass matrix. It works like AddIsoparametricMass with
+// getElement also returning the index of each element's constituter in materials and of its
+// density in densities, as in AddIsoparametricMaterials.
+func (ga *GeneralAssembler) AddIsoparametricMassMaterials(elemT Isoparametric, materials []IsoConstituter, densities []float64, Nelem int, getElement func(i int) (elem []int, material int, xC, yC r3.Vec)) error {
+	if len(materials) == 0 {
+		return errors.New("no materials")
+	} else if len(densities) != len(materials) {
+		return fmt.Errorf("number of densities %d does not match number of materials %d", len(densities), len(materials))
+	}
+	var dimC int
+	for i, c := range materials {
+		density := densities[i]
+		if density <= 0 || math.IsNaN(density) || math.IsInf(density, 0) {
+			return fmt.Errorf("density must be a positive finite number, got %g", density)
+		}
+		Cd, err := denseConstitutive(c)
+		if err != nil {
+			return fmt.Errorf("material %d: %w", i, err)
+		}
+		r, _ := Cd.Dims()
+		if i == 0 {
+			dimC = r
+		} else if r != dimC {
+			return fmt.Errorf("material %d constitutive matrix dimension %d does not match %d of material 0", i, r, dimC)
+		}
+	}
+	it, err := newMassIntegrator(elemT)
+	if err != nil {
+		return err
+	}
+	var (
+		NdofsPerNode = elemT.Dofs().Count()
+		NdofperElem  = it.NnodperElem * NdofsPerNode
+	)
 	NvalPerElem := NdofperElem * NdofperElem
 	spac := lap.NewSparseAccum(NvalPerElem * Nelem)
-	var x, y r3.Vec
+	err = forEachElementConcurrent(ga.workers, ga.dofs, ga.nodes, elemT, it.NdimsPerNode, Nelem, func() (func(int) []int, elementDofCallback) {
+		it := it.clone()
+		var (
+			// Element mass matrix.
+			Me = mat.NewDense(NdofperElem, NdofperElem, nil)
+			// Mass matrix of the element's scalar field. Is expanded to all dofs of a node.
+			Mn = mat.NewDense(it.NnodperElem, it.NnodperElem, nil)
+			B  = mat.NewDense(dimC, NdofperElem, nil)
+		)
+		var material int
+		subGetElement := func(i int) (elem []int) {
+			elem, material, _, _ = getElement(i)
+			return elem
+		}
+		return subGetElement, func(iele int, elemNodBacking []float64, elemDofs []int) error {
+			if material < 0 || material >= len(materials) {
+				return fmt.Errorf("element #%d material index %d out of range", iele, material)
+			}
+			elemNod := mat.NewDense(it.NnodperElem, it.NdimsPerNode, elemNodBacking)
+			err := it.scalarMass(Mn, B, iele, elemNod, materials[material], densities[material])
+			if err != nil {
+				return err
+			}
+			expandNodal(Me, Mn, NdofsPerNode)
+			offset := iele * NvalPerElem
+			assembleElement(spac.V[offset:], spac.I[offset:], spac.J[offset:], elemDofs, Me)
+			return nil
+		}
+	})
+	if err != nil {
+		return err
+	}
+	ga.msolid.Accumulate(spac)
+	return nil
+}
+
+// AddIsoparametricCapacity adds the heat capacity matrix ∫ρ·c·Nᵀ·N dV of isoparametric elements
+// of a thermal model to the model's mass matrix, which is returned by Msolid. density is the mass per
+// unit volume and specificHeat the heat capacity per unit mass of the elements. c is the conductivity
+// constituter used in AddIsoparametric, i.e. that of solids.IsotropicConductivity, so the capacity of
+// an axisymmetric model is per radian like its conductivity matrix.
+func (ga *GeneralAssembler) AddIsoparametricCapacity(elemT Isoparametric, c IsoConstituter, density, specificHeat float64, Nelem int, getElement func(i int) (elem []int, xC, yC r3.Vec)) error {
+	if specificHeat <= 0 || math.IsNaN(specificHeat) || math.IsInf(specificHeat, 0) {
+		return fmt.Errorf("specific heat must be a positive finite number, got %g", specificHeat)
+	}
+	return ga.AddIsoparametricMass(elemT, c, density*specificHeat, Nelem, getElement)
+}
+
+// MassLumping specifies how an element's consistent mass matrix is
+// reduced to a diagonal matrix.
+type MassLumping int
+
+const (
+	// LumpAuto uses row-sum lumping unless it yields a non-positive nodal
+	// mass, in which case HRZ lumping is used. This is the case for
+	// quadratic elements such as Quad8, Triangle6, Hexa20 and Tetra10.
+	// Row-sum masses that vanish to round-off, such as those of the
+	// corners of Triangle6, are deemed non-positive.
+	LumpAuto MassLumping = iota
+	// LumpRowSum sets each diagonal term to the sum of its row.
+	// Suited for linear elements such as Quad4, Hexa8 and Tetra4.
+	LumpRowSum
+	// LumpHRZ is the Hinton-Rock-Zienkiewicz lumping scheme which scales the
+	// diagonal of the consistent mass matrix so that the total mass of
+	// the element is preserved. Nodal masses are always positive.
+	LumpHRZ
+)
+
+// AddIsoparametricLumpedMass adds the diagonal lumped mass of isoparametric elements to dst,
+// which is indexed by the model's global dofs like Ksolid. The lumping scheme is chosen with lumping.
+// See AddIsoparametricMass for a description of the remaining arguments.
+func (ga *GeneralAssembler) AddIsoparametricLumpedMass(dst *lap.DenseV, elemT Isoparametric, c IsoConstituter, density float64, lumping MassLumping, Nelem int, getElement func(i int) (elem []int, xC, yC r3.Vec)) error {
+	if dst.Len() != ga.TotalDofs() {
+		return fmt.Errorf("lumped mass vector length %d does not match total number of dofs %d", dst.Len(), ga.TotalDofs())
+	} else if lumping < LumpAuto || lumping > LumpHRZ {
+		return fmt.Errorf("unknown mass lumping scheme %d", lumping)
+	}
+	if density <= 0 || math.IsNaN(density) || math.IsInf(density, 0) {
+		return fmt.Errorf("density must be a positive finite number, got %g", density)
+	}
+	Cd, err := denseConstitutive(c)
+	if err != nil {
+		return err
+	}
+	it, err := newMassIntegrator(elemT)
+	if err != nil {
+		return err
+	}
+	dimC, _ := Cd.Dims()
+	var (
+		NdofsPerNode = elemT.Dofs().Count()
+		Mn           = mat.NewDense(it.NnodperElem, it.NnodperElem, nil)
+		B            = mat.NewDense(dimC, it.NnodperElem*NdofsPerNode, nil)
+		lumped       = make([]float64, it.NnodperElem)
+	)
 	subGetElement := func(i int) (elem []int) {
-		elem, x, y = getElement(i)
+		elem, _, _ = getElement(i)
 		return elem
 	}
-	err = ga.ForEachElement(elemT, NdimsPerNode, Nelem, subGetElement, func(iele int, elemNodBacking []float64, elemDofs []int) error {
-		if x != (r3.Vec{}) || y != (r3.Vec{}) {
-			return errors.New("arbitrary constitutive orientation not implemented yet")
+	return ga.ForEachElement(elemT, it.NdimsPerNode, Nelem, subGetElement, func(iele int, elemNodBacking []float64, elemDofs []int) error {
+		elemNod := mat.NewDense(it.NnodperElem, it.NdimsPerNode, elemNodBacking)
+		err := it.scalarMass(Mn, B, iele, elemNod, c, density)
+		if err != nil {
+			return err
+		}
+		lumpMass(lumped, Mn, lumping)
+		for a, m := range lumped {
+			for i := 0; i < NdofsPerNode; i++ {
+				dof := elemDofs[a*NdofsPerNode+i]
+				dst.SetVec(dof, dst.AtVec(dof)+m)
+			}
+		}
+		return nil
+	})
+}
+
+// lumpMass stores the diagonal of the lumped mass matrix of scalar
+// consistent mass matrix Mn in dst.
+func lumpMass(dst []float64, Mn *mat.Dense, lumping MassLumping) {
+	var total, diagSum, minMass float64
+	for a := range dst {
+		row := Mn.RawRowView(a)
+		dst[a] = 0
+		for _, v := range row {
+			dst[a] += v
+		}
+		total += dst[a]
+		diagSum += row[a]
+		if a == 0 || dst[a] < minMass {
+			minMass = dst[a]
+		}
+	}
+	positive := minMass > 1e-10*total
+	if lumping == LumpRowSum || (lumping == LumpAuto && positive) {
+		return
+	}
+	// HRZ lumping.
+	scale := total / diagSum
+	for a := range dst {
+		dst[a] = scale * Mn.At(a, a)
+	}
+}
+
+// AddIsoparametricBodyForce adds the equivalent nodal forces ∫Nᵀ·b dV of body force
+// field b acting on isoparametric elements to dst, which is indexed by the model's global
+// dofs like Ksolid. b is evaluated at the position of each quadrature point and its
+// X, Y and Z components act on the DofPosX, DofPosY and DofPosZ dofs of the element respectively.
+// The domain is integrated with the scale factor returned by c the same way AddIsoparametric
+// does, so axisymmetric loads need not be multiplied by the radius.
+func (ga *GeneralAssembler) AddIsoparametricBodyForce(dst *lap.DenseV, elemT Isoparametric, c IsoConstituter, Nelem int, getElement func(i int) (elem []int, xC, yC r3.Vec), b func(pos r3.Vec) r3.Vec) error {
+	if b == nil {
+		panic("nil body force argument to AddIsoparametricBodyForce")
+	} else if dst.Len() != ga.TotalDofs() {
+		return fmt.Errorf("load vector length %d does not match total number of dofs %d", dst.Len(), ga.TotalDofs())
+	}
+	Cd, err := denseConstitutive(c)
+	if err != nil {
+		return err
+	}
+	it, err := newIsoIntegrator(elemT)
+	if err != nil {
+		return err
+	}
+	dimC, _ := Cd.Dims()
+	var (
+		components   = dofComponents(elemT.Dofs())
+		NdofsPerNode = len(components)
+		B            = mat.NewDense(dimC, it.NnodperElem*NdofsPerNode, nil)
+		fe           = make([]float64, it.NnodperElem*NdofsPerNode)
+	)
+	subGetElement := func(i int) (elem []int) {
+		elem, _, _ = getElement(i)
+		return elem
+	}
+	return ga.ForEachElement(elemT, it.NdimsPerNode, Nelem, subGetElement, func(iele int, elemNodBacking []float64, elemDofs []int) error {
+		for i := range fe {
+			fe[i] = 0
+		}
+		elemNod := mat.NewDense(it.NnodperElem, it.NdimsPerNode, elemNodBacking)
+		for ipg := range it.upg {
+			dJac, err := it.jacobian(iele, ipg, elemNod)
+			if err != nil {
+				return err
+			}
+			scale, err := it.strainDisplacement(B, iele, ipg, elemNod, c)
+			if err != nil {
+				return err
+			}
+			bpg := b(it.position(ipg, elemNod))
+			bcomp := [3]float64{bpg.X, bpg.Y, bpg.Z}
+			f := dJac * it.wpg[ipg] * scale
+			N := it.Npg[ipg].RawVector().Data
+			for a, Na := range N {
+				for i, comp := range components {
+					if comp >= 0 {
+						fe[a*NdofsPerNode+i] += f * Na * bcomp[comp]
+					}
+				}
+			}
+		}
+		for i, dof := range elemDofs {
+			dst.SetVec(dof, dst.AtVec(dof)+fe[i])
+		}
+		return nil
+	})
+}
+
+// UniformBodyForce returns a body force field of constant value b.
+// Gravity acting on a solid of density ρ is obtained with b = ρ·g.
+func UniformBodyForce(b r3.Vec) func(pos r3.Vec) r3.Vec {
+	return func(r3.Vec) r3.Vec { return b }
+}
+
+// AddIsoparametricTraction adds the equivalent nodal forces ∫Nᵀ·t dA of a surface traction t
+// acting on faces of isoparametric elements to dst, which is indexed by the model's global dofs like Ksolid.
+// getFace is called Nfaces times and returns the element's nodes and the index of the loaded face as
+// defined by elemT's Face method. traction is evaluated at the position of each quadrature point of
+// the face along with the outward unit normal of the face at that point. The X, Y and Z components
+// of the traction act on the DofPosX, DofPosY and DofPosZ dofs of the element respectively.
+// The face is integrated with the scale factor returned by c the same way AddIsoparametric does,
+// so axisymmetric loads need not be multiplied by the radius.
+func (ga *GeneralAssembler) AddIsoparametricTraction(dst *lap.DenseV, elemT Faceted, c IsoConstituter, Nfaces int, getFace func(i int) (elem []int, face int), traction func(pos, normal r3.Vec) r3.Vec) error {
+	if traction == nil {
+		panic("nil traction argument to AddIsoparametricTraction")
+	} else if dst.Len() != ga.TotalDofs() {
+		return fmt.Errorf("load vector length %d does not match total number of dofs %d", dst.Len(), ga.TotalDofs())
+	}
+	Cd, err := denseConstitutive(c)
+	if err != nil {
+		return err
+	}
+	dimC, _ := Cd.Dims()
+	var (
+		components   = dofComponents(elemT.Dofs())
+		NdofsPerNode = len(components)
+		NnodperElem  = elemT.LenNodes()
+		B            = mat.NewDense(dimC, NnodperElem*NdofsPerNode, nil)
+		fe           = make([]float64, NnodperElem*NdofsPerNode)
+		// Faces are integrated lazily as they are first requested.
+		faces = make([]*faceIntegrator, elemT.LenFaces())
+		face  int
+	)
+	subGetElement := func(i int) (elem []int) {
+		elem, face = getFace(i)
+		return elem
+	}
+	NdimsPerNode := len(elemT.BasisDiff(r3.Vec{})) / NnodperElem
+	return ga.ForEachElement(elemT, NdimsPerNode, Nfaces, subGetElement, func(iele int, elemNodBacking []float64, elemDofs []int) error {
+		if face < 0 || face >= len(faces) {
+			return fmt.Errorf("face index %d of element #%d out of range [0, %d)", face, iele, len(faces))
+		}
+		if faces[face] == nil {
+			fi, err := newFaceIntegrator(elemT, face)
+			if err != nil {
+				return err
+			}
+			faces[face] = fi
+		}
+		fi := faces[face]
+		for i := range fe {
+			fe[i] = 0
 		}
-		Ke.Zero()
 		elemNod := mat.NewDense(NnodperElem, NdimsPerNode, elemNodBacking)
-		for ipg := range upg {
-			dN := dNpg[ipg]
-			jac.Mul(dN, elemNod)
-			dJac := mat.Det(jac)
-			if dJac < 0 {
-				return fmt.Errorf("negative determinant of jacobian of element #%d, Check node ordering", iele)
-			} else if dJac < 1e-12 {
-				return fmt.Errorf("zero determinant of jacobian of element #%d, Check element shape for bad aspect ratio", iele)
-			}
-			err := dNxy.Solve(jac, dN)
-			if err != nil {
-				return fmt.Errorf("error calculating element #%d form factor: %s", iele, err)
-			}
-			scale := c.SetStrainDisplacementMatrix(B, elemNod, dNxy, Npg[ipg])
-			if math.IsNaN(scale) {
-				return fmt.Errorf("NaN scale value returned by SetStrainDisplacementMatrix at element #%d, quad %d", iele, ipg)
-			}
-			// Ke = Ke + Bᵀ*C*B * weight*det(J)
-			aux1.Mul(B.T(), Cd)
-			aux2.Mul(aux1, B)
-			aux2.Scale(dJac*wpg[ipg]*scale, aux2)
-			Ke.Add(Ke, aux2)
-		}
-		offset := iele * NvalPerElem
-		assembleElement(spac.V[offset:], spac.I[offset:], spac.J[offset:], elemDofs, Ke)
+		for ipg := range fi.it.upg {
+			// Element jacobian is required by the constituter to calculate the scale factor.
+			_, err := fi.it.jacobian(iele, ipg, elemNod)
+			if err != nil {
+				return err
+			}
+			scale, err := fi.it.strainDisplacement(B, iele, ipg, elemNod, c)
+			if err != nil {
+				return err
+			}
+			dA, normal := fi.surface(ipg, elemNod)
+			if dA < 1e-12 {
+				return fmt.Errorf("zero area at face %d of element #%d, check element shape", face, iele)
+			}
+			tpg := traction(fi.it.position(ipg, elemNod), normal)
+			tcomp := [3]float64{tpg.X, tpg.Y, tpg.Z}
+			f := dA * fi.it.wpg[ipg] * scale
+			N := fi.it.Npg[ipg].RawVector().Data
+			for a, Na := range N {
+				for i, comp := range components {
+					if comp >= 0 {
+						fe[a*NdofsPerNode+i] += f * Na * tcomp[comp]
+					}
+				}
+			}
+		}
+		for i, dof := range elemDofs {
+			dst.SetVec(dof, dst.AtVec(dof)+fe[i])
+		}
 		return nil
 	})
+}
+
+// Pressure returns a traction field of uniform pressure p acting on a face.
+// Positive pressure pushes against the face, opposite to its outward normal.
+func Pressure(p float64) func(pos, normal r3.Vec) r3.Vec {
+	return func(_, normal r3.Vec) r3.Vec { return r3.Scale(-p, normal) }
+}
+
+// AddIsoparametricThermalLoad adds the equivalent nodal forces ∫Bᵀ·C·ε₀ dV of the thermal
+// strain ε₀ = ΔT·c.ThermalStrain() of isoparametric elements to dst, which is indexed by the
+// model's global dofs like Ksolid. deltaT returns the temperature change ΔT at a quadrature
+// point of element iele, given the element's nodes and the form functions N evaluated at the point.
+// See NodalField and ElementField for common temperature fields.
+func (ga *GeneralAssembler) AddIsoparametricThermalLoad(dst *lap.DenseV, elemT Isoparametric, c ThermalIsoConstituter, Nelem int, getElement func(i int) (elem []int, xC, yC r3.Vec), deltaT func(iele int, elem []int, N []float64) float64) error {
+	if c == nil || getElement == nil {
+		panic("nil argument to AddIsoparametricThermalLoad")
+	}
+	return ga.AddIsoparametricThermalLoadMaterials(dst, elemT, []ThermalIsoConstituter{c}, Nelem, func(i int) (elem []int, material int, xC, yC r3.Vec) {
+		elem, xC, yC = getElement(i)
+		return elem, 0, xC, yC
+	}, deltaT)
+}
+
+// AddIsoparametricThermalLoadMaterials adds the equivalent nodal forces of the thermal strain of
+// isoparametric elements of different materials to dst. It works like AddIsoparametricThermalLoad
+// with getElement also returning the index of each element's constituter in materials, as in
+// AddIsoparametricMaterials.
+func (ga *GeneralAssembler) AddIsoparametricThermalLoadMaterials(dst *lap.DenseV, elemT Isoparametric, materials []ThermalIsoConstituter, Nelem int, getElement func(i int) (elem []int, material int, xC, yC r3.Vec), deltaT func(iele int, elem []int, N []float64) float64) error {
+	if deltaT == nil {
+		panic("nil temperature change argument to AddIsoparametricThermalLoad")
+	} else if dst.Len() != ga.TotalDofs() {
+		return fmt.Errorf("load vector length %d does not match total number of dofs %d", dst.Len(), ga.TotalDofs())
+	} else if len(materials) == 0 {
+		return errors.New("no materials")
+	}
+	var dimC int
+	// Stress per unit temperature change of restrained material.
+	sigma0s := make([]*mat.VecDense, len(materials))
+	for i, c := range materials {
+		Cd, err := denseConstitutive(c)
+		if err != nil {
+			return fmt.Errorf("material %d: %w", i, err)
+		}
+		r, _ := Cd.Dims()
+		if i == 0 {
+			dimC = r
+		} else if r != dimC {
+			return fmt.Errorf("material %d constitutive matrix dimension %d does not match %d of material 0", i, r, dimC)
+		}
+		eps0 := c.ThermalStrain()
+		if len(eps0) != dimC {
+			return fmt.Errorf("material %d: thermal strain length %d does not match constitutive matrix dimension %d", i, len(eps0), dimC)
+		}
+		sigma0s[i] = mat.NewVecDense(dimC, nil)
+		sigma0s[i].MulVec(Cd, mat.NewVecDense(dimC, eps0))
+	}
+	it, err := newIsoIntegrator(elemT)
 	if err != nil {
 		return err
 	}
-	ga.ksolid.Accumulate(spac)
-	return nil
+	var (
+		NdofperElem = it.NnodperElem * elemT.Dofs().Count()
+		B           = mat.NewDense(dimC, NdofperElem, nil)
+		fe          = mat.NewVecDense(NdofperElem, nil)
+		aux         = mat.NewVecDense(NdofperElem, nil)
+	)
+	var (
+		x, y     r3.Vec
+		elem     []int
+		material int
+	)
+	subGetElement := func(i int) []int {
+		elem, material, x, y = getElement(i)
+		return elem
+	}
+	var sigma0e mat.VecDense
+	return ga.ForEachElement(elemT, it.NdimsPerNode, Nelem, subGetElement, func(iele int, elemNodBacking []float64, elemDofs []int) error {
+		if material < 0 || material >= len(materials) {
+			return fmt.Errorf("element #%d material index %d out of range", iele, material)
+		}
+		c, sigma := materials[material], sigma0s[material]
+		if x != (r3.Vec{}) || y != (r3.Vec{}) {
+			Ce, eps0e, err := orientThermal(c, x, y)
+			if err != nil {
+				return err
+			}
+			sigma0e.MulVec(Ce, mat.NewVecDense(dimC, eps0e))
+			sigma = &sigma0e
+		}
+		fe.Zero()
+		elemNod := mat.NewDense(it.NnodperElem, it.NdimsPerNode, elemNodBacking)
+		for ipg := range it.upg {
+			dJac, err := it.jacobian(iele, ipg, elemNod)
+			if err != nil {
+				return err
+			}
+			scale, err := it.strainDisplacement(B, iele, ipg, elemNod, c)
+			if err != nil {
+				return err
+			}
+			dT := deltaT(iele, elem, it.Npg[ipg].RawVector().Data)
+			// fe = fe + Bᵀ*C*ε₀ * ΔT * weight*det(J)
+			aux.MulVec(B.T(), sigma)
+			fe.AddScaledVec(fe, dT*dJac*it.wpg[ipg]*scale, aux)
+		}
+		for i, dof := range elemDofs {
+			dst.SetVec(dof, dst.AtVec(dof)+fe.AtVec(i))
+		}
+		return nil
+	})
+}
+
+// NodalField returns a field interpolated from nodal values with the
+// form functions of the element, where values is indexed by node number.
+// It can be used as the temperature change argument of AddIsoparametricThermalLoad.
+func NodalField(values []float64) func(iele int, elem []int, N []float64) float64 {
+	return func(_ int, elem []int, N []float64) (v float64) {
+		for a, node := range elem {
+			v += N[a] * values[node]
+		}
+		return v
+	}
+}
+
+// ElementField returns a field that is constant over each element,
+// where values is indexed by element number.
+// It can be used as the temperature change argument of AddIsoparametricThermalLoad.
+func ElementField(values []float64) func(iele int, elem []int, N []float64) float64 {
+	return func(iele int, _ []int, _ []float64) float64 {
+		return values[iele]
+	}
+}
+
+// faceIntegrator integrates over a face of an isoparametric element.
+type faceIntegrator struct {
+	nodes []int
+	// Integrator of the element evaluated at the face's quadrature points.
+	it *isoIntegrator
+	// Differentiated face form functions evaluated at the face's quadrature points.
+	dNface []*mat.Dense
+	tang   *mat.Dense
+}
+
+func newFaceIntegrator(elemT Faceted, face int) (*faceIntegrator, error) {
+	nodes, faceT := elemT.Face(face)
+	if len(nodes) != faceT.LenNodes() {
+		return nil, fmt.Errorf("face %d of element has %d nodes, expected %d", face, len(nodes), faceT.LenNodes())
+	}
+	upg, wpg := faceT.Quadrature()
+	if len(upg) == 0 || len(upg) != len(wpg) {
+		return nil, fmt.Errorf("bad quadrature result from isoparametric face element")
+	}
+	NdimsPerNode := len(elemT.BasisDiff(r3.Vec{})) / elemT.LenNodes()
+	Ndims := len(faceT.BasisDiff(r3.Vec{})) / len(nodes)
+	if Ndims != NdimsPerNode-1 {
+		return nil, fmt.Errorf("face of %d dimensions not supported on element of %d dimensions", Ndims, NdimsPerNode)
+	}
+	isoNodes := elemT.IsoparametricNodes()
+	fi := &faceIntegrator{
+		nodes:  nodes,
+		dNface: make([]*mat.Dense, len(upg)),
+		tang:   mat.NewDense(Ndims, NdimsPerNode, nil),
+	}
+	// Map face quadrature points to the element's isoparametric coordinates.
+	elemPoints := make([]r3.Vec, len(upg))
+	for ipg, pg := range upg {
+		N := faceT.Basis(pg)
+		for k, node := range nodes {
+			elemPoints[ipg] = r3.Add(elemPoints[ipg], r3.Scale(N[k], isoNodes[node]))
+		}
+		fi.dNface[ipg] = mat.NewDense(Ndims, len(nodes), faceT.BasisDiff(pg))
+	}
+	it, err := newIsoIntegratorAt(elemT, elemPoints, wpg)
+	if err != nil {
+		return nil, err
+	}
+	fi.it = it
+	return fi, nil
+}
+
+// surface returns the area differential and outward unit normal of
+// the face at quadrature point ipg.
+func (fi *faceIntegrator) surface(ipg int, elemNod *mat.Dense) (dA float64, normal r3.Vec) {
+	dN := fi.dNface[ipg]
+	Ndims, _ := dN.Dims()
+	fi.tang.Zero()
+	for k, node := range fi.nodes {
+		for j := 0; j < Ndims; j++ {
+			for i := 0; i < fi.it.NdimsPerNode; i++ {
+				fi.tang.Set(j, i, fi.tang.At(j, i)+dN.At(j, k)*elemNod.At(node, i))
+			}
+		}
+	}
+	if fi.it.NdimsPerNode == 2 {
+		// Edge of a 2D element traversed counter-clockwise: normal lies to the right.
+		normal = r3.Vec{X: fi.tang.At(0, 1), Y: -fi.tang.At(0, 0)}
+	} else {
+		t1 := r3.Vec{X: fi.tang.At(0, 0), Y: fi.tang.At(0, 1), Z: fi.tang.At(0, 2)}
+		t2 := r3.Vec{X: fi.tang.At(1, 0), Y: fi.tang.At(1, 1), Z: fi.tang.At(1, 2)}
+		normal = r3.Cross(t1, t2)
+	}
+	dA = r3.Norm(normal)
+	if dA == 0 {
+		return 0, r3.Vec{}
+	}
+	return dA, r3.Scale(1/dA, normal)
+}
+
+// IsoparametricVolumes stores the volume of the ith isoparametric element in dst[i].
+// The domain is integrated with the scale factor returned by c in the same way
+// AddIsoparametric does, so the volume of an axisymmetric element is per radian.
+func (ga *GeneralAssembler) IsoparametricVolumes(dst []float64, elemT Isoparametric, c IsoConstituter, Nelem int, getElement func(i int) (elem []int, xC, yC r3.Vec)) error {
+	if len(dst) < Nelem {
+		return fmt.Errorf("volume destination length %d less than number of elements %d", len(dst), Nelem)
+	}
+	Cd, err := denseConstitutive(c)
+	if err != nil {
+		return err
+	}
+	it, err := newIsoIntegrator(elemT)
+	if err != nil {
+		return err
+	}
+	dimC, _ := Cd.Dims()
+	B := mat.NewDense(dimC, it.NnodperElem*elemT.Dofs().Count(), nil)
+	subGetElement := func(i int) (elem []int) {
+		elem, _, _ = getElement(i)
+		return elem
+	}
+	return ga.ForEachElement(elemT, it.NdimsPerNode, Nelem, subGetElement, func(iele int, elemNodBacking []float64, elemDofs []int) error {
+		elemNod := mat.NewDense(it.NnodperElem, it.NdimsPerNode, elemNodBacking)
+		dst[iele] = 0
+		for ipg := range it.upg {
+			dJac, err := it.jacobian(iele, ipg, elemNod)
+			if err != nil {
+				return err
+			}
+			scale, err := it.strainDisplacement(B, iele, ipg, elemNod, c)
+			if err != nil {
+				return err
+			}
+			dst[iele] += dJac * it.wpg[ipg] * scale
+		}
+		return nil
+	})
 }
 
 // IsoparametricStrains calculates the strains at the integration points of an isoparametric element.
+// Strains are given in the model's axes regardless of the orientation of the element's material axes.
 func (ga *GeneralAssembler) IsoparametricStrains(displacements lap.Vector, elemT Isoparametric, c IsoConstituter, Nelem int, getElement func(i int) (elem []int, xC, yC r3.Vec), strainCallback func(iele int, strains []float64)) error {
 	nDisp := displacements.Len()
 	if nDisp != ga.TotalDofs() {
 		return fmt.Errorf("displacements vector length %d does not match total number of dofs %d", nDisp, ga.TotalDofs())
 	}
-	C, err := c.Constitutive()
+	Cd, err := denseConstitutive(c)
 	if err != nil {
 		return err
 	}
-	dimC, _c := C.Dims()
-	if _c != dimC {
-		return fmt.Errorf("expected constitutive matrix to be square, got %dx%d", dimC, c)
+	it, err := newIsoIntegrator(elemT)
+	if err != nil {
+		return err
 	}
-
+	dimC, _ := Cd.Dims()
 	var (
-		// Number of integration dimensions per node. Usually spatial, so 2 for 2D problems and 3 for 3D problems.
-		NdimsPerNode = len(elemT.BasisDiff(r3.Vec{})) / elemT.LenNodes()
 		// Number of dofs per node. These contain the field variables.
 		// For example, for a 2D displacement problem these are the x and y displacements, so equal to 2.
 		// For a thermal problem there is always only 1 dof per node for the temperature, regardless of the number of spatial dimensions.
 		NdofsPerNode = elemT.Dofs().Count() //
-		// Number of nodes per element.
-		NnodperElem = elemT.LenNodes()
 		// Number of dofs per element.
-		NdofperElem = NnodperElem * NdofsPerNode
-		// Element stiffness matrix. The results of integrating the element's stiffness matrix over the element's domain.
-		Ke = mat.NewDense(NdofperElem, NdofperElem, nil)
+		NdofperElem = it.NnodperElem * NdofsPerNode
 		// number of columns in Compliance x NdofPerNode*nodesperelement
 		B = mat.NewDense(dimC, NdofperElem, nil)
-		// Differentiated form functions with respect to the integration coordinates.
-		dNxy = mat.NewDense(NdimsPerNode, NnodperElem, nil)
-		// Quadrature integration points.
-		upg, wpg = elemT.Quadrature()
-		// Compliance matrix as a Dense matrix.
-		Cd = mat.NewDense(dimC, dimC, nil)
 	)
-	if len(upg) == 0 || len(upg) != len(wpg) {
+	// Allocate memory for auxiliary matrices.
+	pgStrain := mat.NewDense(len(it.upg), dimC, nil)
+
+	subGetElement := func(i int) (elem []int) {
+		elem, _, _ = getElement(i)
+		return elem
+	}
+	err = ga.ForEachElement(elemT, it.NdimsPerNode, Nelem, subGetElement, func(iele int, elemNodBacking []float64, elemDofs []int) error {
+		elemNod := mat.NewDense(it.NnodperElem, it.NdimsPerNode, elemNodBacking)
+		for ipg := range it.upg {
+			_, err := it.jacobian(iele, ipg, elemNod)
+			if err != nil {
+				return err
+			}
+			_, err = it.strainDisplacement(B, iele, ipg, elemNod, c)
+			if err != nil {
+				return err
+			}
+			vd := pgStrain.RowView(ipg).(*mat.VecDense)
+			vd.MulVec(B, lapvec{lap.SliceVec(displacements, elemDofs)})
+		}
+		strainCallback(iele, pgStrain.RawMatrix().Data)
+		return nil
+	})
+	return err
+}
+
+// IsoparametricStresses calculates the stresses σ = C·ε at the integration points of isoparametric elements.
+// The stresses of all integration points of an element are passed to stressCallback one after the other,
+// in the same layout as the strains of IsoparametricStrains. See the solids package for
+// calculating derived quantities such as the von Mises stress.
+func (ga *GeneralAssembler) IsoparametricStresses(displacements lap.Vector, elemT Isoparametric, c IsoConstituter, Nelem int, getElement func(i int) (elem []int, xC, yC r3.Vec), stressCallback func(iele int, stresses []float64)) error {
+	Cd, err := denseConstitutive(c)
+	if err != nil {
+		return err
+	}
+	dimC, _ := Cd.Dims()
+	upg, _ := elemT.Quadrature()
+	if len(upg) == 0 {
 		return fmt.Errorf("bad quadrature result from isoparametric element")
 	}
-	Cd.Copy(C)
+	stresses := mat.NewDense(len(upg), dimC, nil)
+	var x, y r3.Vec
+	subGetElement := func(i int) (elem []int, xC, yC r3.Vec) {
+		elem, x, y = getElement(i)
+		return elem, x, y
+	}
+	var errOrient error
+	err = ga.IsoparametricStrains(displacements, elemT, c, Nelem, subGetElement, func(iele int, strains []float64) {
+		if errOrient != nil {
+			return
+		}
+		Ce, err := orientedConstitutive(c, Cd, x, y)
+		if err != nil {
+			errOrient = err
+			return
+		}
+		// σᵀ = εᵀ*Cᵀ, with strains of each integration point in a row.
+		stresses.Mul(mat.NewDense(len(upg), dimC, strains), Ce.T())
+		stressCallback(iele, stresses.RawMatrix().Data)
+	})
+	if err != nil {
+		return err
+	}
+	return errOrient
+}
 
-	// Calculate form functions evaluated at integration points.
-	Npg := make([]*mat.VecDense, len(upg))
-	dNpg := make([]*mat.Dense, len(upg))
-	for ipg, pg := range upg {
-		Npg[ipg] = mat.NewVecDense(NnodperElem, elemT.Basis(pg))
-		dNpg[ipg] = mat.NewDense(NdimsPerNode, NnodperElem, elemT.BasisDiff(pg))
+// IsoparametricThermalStresses calculates the stresses σ = C·(ε - ε₀) at the integration points
+// of isoparametric elements subject to the thermal strain ε₀ = ΔT·c.ThermalStrain().
+// deltaT is the temperature change as described in AddIsoparametricThermalLoad.
+// The stresses of all integration points of an element are passed to stressCallback
+// one after the other, in the same layout as the strains of IsoparametricStrains.
+func (ga *GeneralAssembler) IsoparametricThermalStresses(displacements lap.Vector, elemT Isoparametric, c ThermalIsoConstituter, Nelem int, getElement func(i int) (elem []int, xC, yC r3.Vec), deltaT func(iele int, elem []int, N []float64) float64, stressCallback func(iele int, stresses []float64)) error {
+	if deltaT == nil {
+		panic("nil temperature change argument to IsoparametricThermalStresses")
 	}
-	// Allocate memory for auxiliary matrices.
-	jac := mat.NewDense(NdimsPerNode, NdimsPerNode, nil)
-	pgStrain := mat.NewDense(len(upg), dimC, nil)
+	Cd, err := denseConstitutive(c)
+	if err != nil {
+		return err
+	}
+	dimC, _ := Cd.Dims()
+	eps0 := c.ThermalStrain()
+	if len(eps0) != dimC {
+		return fmt.Errorf("thermal strain length %d does not match constitutive matrix dimension %d", len(eps0), dimC)
+	}
+	it, err := newIsoIntegrator(elemT)
+	if err != nil {
+		return err
+	}
+	pgStress := mat.NewDense(len(it.upg), dimC, nil)
+	eps := mat.NewVecDense(dimC, nil)
+	var elem []int
+	var x, y r3.Vec
+	subGetElement := func(i int) (e []int, xC, yC r3.Vec) {
+		elem, x, y = getElement(i)
+		return elem, x, y
+	}
+	var errOrient error
+	err = ga.IsoparametricStrains(displacements, elemT, c, Nelem, subGetElement, func(iele int, strains []float64) {
+		Ce, eps0e := Cd, eps0
+		if errOrient != nil {
+			return
+		} else if x != (r3.Vec{}) || y != (r3.Vec{}) {
+			Ce, eps0e, errOrient = orientThermal(c, x, y)
+			if errOrient != nil {
+				return
+			}
+		}
+		for ipg := range it.upg {
+			dT := deltaT(iele, elem, it.Npg[ipg].RawVector().Data)
+			for i := 0; i < dimC; i++ {
+				eps.SetVec(i, strains[ipg*dimC+i]-dT*eps0e[i])
+			}
+			pgStress.RowView(ipg).(*mat.VecDense).MulVec(Ce, eps)
+		}
+		stressCallback(iele, pgStress.RawMatrix().Data)
+	})
+	if err != nil {
+		return err
+	}
+	return errOrient
+}
 
+// AddIsoparametricGeometric adds the geometric stiffness matrix ∫Gᵀ·σ·G dV of isoparametric
+// elements to the model's geometric stiffness matrix, where σ = C·ε is the stress state at the
+// integration points given the displacements of a solved reference load case and G holds the
+// derivatives of the form functions. getElement must return the same elements and orientation
+// vectors passed to AddIsoparametric. Only 3D solid and plane constituters are supported.
+// See Buckling for its use.
+//
+// The thermal strain ε₀ is not subtracted from ε, so reference load cases with thermal
+// loads such as those of AddIsoparametricThermalLoad give a wrong stress state.
+func (ga *GeneralAssembler) AddIsoparametricGeometric(elemT Isoparametric, displacements lap.Vector, c IsoConstituter, Nelem int, getElement func(i int) (elem []int, xC, yC r3.Vec)) error {
+	if displacements.Len() != ga.TotalDofs() {
+		return fmt.Errorf("displacements vector length %d does not match total number of dofs %d", displacements.Len(), ga.TotalDofs())
+	}
+	Cd, err := denseConstitutive(c)
+	if err != nil {
+		return err
+	}
+	it, err := newIsoIntegrator(elemT)
+	if err != nil {
+		return err
+	}
+	dimC, _ := Cd.Dims()
+	NdofsPerNode := elemT.Dofs().Count()
+	// Index of the stress components of the stress tensor.
+	var tensor [][]int
+	switch {
+	case dimC == 6 && it.NdimsPerNode == 3:
+		tensor = [][]int{{0, 3, 5}, {3, 1, 4}, {5, 4, 2}}
+	case dimC == 3 && it.NdimsPerNode == 2:
+		tensor = [][]int{{0, 2}, {2, 1}}
+	default:
+		return fmt.Errorf("geometric stiffness not supported for %d stress components in %d dimensions", dimC, it.NdimsPerNode)
+	}
+	if NdofsPerNode != it.NdimsPerNode {
+		return errors.New("geometric stiffness requires a displacement dof per spatial dimension")
+	}
+	var (
+		NdofperElem = it.NnodperElem * NdofsPerNode
+		Kg          = mat.NewDense(NdofperElem, NdofperElem, nil)
+		B           = mat.NewDense(dimC, NdofperElem, nil)
+		strain      = mat.NewVecDense(dimC, nil)
+		stress      = mat.NewVecDense(dimC, nil)
+	)
 	var x, y r3.Vec
 	subGetElement := func(i int) (elem []int) {
 		elem, x, y = getElement(i)
 		return elem
 	}
-	err = ga.ForEachElement(elemT, NdimsPerNode, Nelem, subGetElement, func(iele int, elemNodBacking []float64, elemDofs []int) error {
-		if x != (r3.Vec{}) || y != (r3.Vec{}) {
-			return errors.New("arbitrary constitutive orientation not implemented yet")
+	return ga.ForEachElement(elemT, it.NdimsPerNode, Nelem, subGetElement, func(iele int, elemNodBacking []float64, elemDofs []int) error {
+		Ce, err := orientedConstitutive(c, Cd, x, y)
+		if err != nil {
+			return err
 		}
-		Ke.Zero()
-		elemNod := mat.NewDense(NnodperElem, NdimsPerNode, elemNodBacking)
-		for ipg := range upg {
-			dN := dNpg[ipg]
-			jac.Mul(dN, elemNod)
-			dJac := mat.Det(jac)
-			if dJac < 0 {
-				return fmt.Errorf("negative determinant of jacobian of element #%d, Check node ordering", iele)
-			} else if dJac < 1e-12 {
-				return fmt.Errorf("zero determinant of jacobian of element #%d, Check element shape for bad aspect ratio", iele)
+		Kg.Zero()
+		elemNod := mat.NewDense(it.NnodperElem, it.NdimsPerNode, elemNodBacking)
+		ue := lapvec{lap.SliceVec(displacements, elemDofs)}
+		for ipg := range it.upg {
+			dJac, err := it.jacobian(iele, ipg, elemNod)
+			if err != nil {
+				return err
 			}
-			err := dNxy.Solve(jac, dN)
+			scale, err := it.strainDisplacement(B, iele, ipg, elemNod, c)
 			if err != nil {
-				return fmt.Errorf("error calculating element #%d form factor: %s", iele, err)
+				return err
 			}
-			scale := c.SetStrainDisplacementMatrix(B, elemNod, dNxy, Npg[ipg])
-			if math.IsNaN(scale) {
-				return fmt.Errorf("NaN scale value returned by SetStrainDisplacementMatrix at element #%d, quad %d", iele, ipg)
+			strain.MulVec(B, ue)
+			stress.MulVec(Ce, strain)
+			f := dJac * it.wpg[ipg] * scale
+			// Kg(a,b) = Kg(a,b) + ∇Naᵀ·σ·∇Nb * weight*det(J) for each displacement component.
+			for a := 0; a < it.NnodperElem; a++ {
+				for b := 0; b < it.NnodperElem; b++ {
+					var kab float64
+					for i, row := range tensor {
+						for j, comp := range row {
+							kab += it.dNxy.At(i, a) * stress.AtVec(comp) * it.dNxy.At(j, b)
+						}
+					}
+					kab *= f
+					for k := 0; k < NdofsPerNode; k++ {
+						ia, ib := a*NdofsPerNode+k, b*NdofsPerNode+k
+						Kg.Set(ia, ib, Kg.At(ia, ib)+kab)
+					}
+				}
 			}
-			vd := pgStrain.RowView(ipg).(*mat.VecDense)
-			vd.MulVec(B, lapvec{lap.SliceVec(displacements, elemDofs)})
 		}
-		strainCallback(iele, pgStrain.RawMatrix().Data)
+		addElementMatrix(&ga.kgeom, elemDofs, Kg)
 		return nil
 	})
-	return err
+}
+
+// isoIntegrator holds the form functions of an isoparametric element evaluated
+// at its quadrature points along with the auxiliary matrices needed to map them
+// to an element's physical coordinates. It is not safe for concurrent use.
+type isoIntegrator struct {
+	// Number of integration dimensions per node. Usually spatial, so 2 for 2D problems and 3 for 3D problems.
+	NdimsPerNode int
+	// Number of nodes per element.
+	NnodperElem int
+	// Quadrature integration points and weights.
+	upg []r3.Vec
+	wpg []float64
+	// Form functions and their derivatives evaluated at integration points.
+	Npg  []*mat.VecDense
+	dNpg []*mat.Dense
+	// Differentiated form functions with respect to the element's physical coordinates.
+	// Set by jacobian.
+	dNxy *mat.Dense
+	jac  *mat.Dense
+}
+
+func newIsoIntegrator(elemT Isoparametric) (*isoIntegrator, error) {
+	upg, wpg := elemT.Quadrature()
+	if len(upg) == 0 || len(upg) != len(wpg) {
+		return nil, fmt.Errorf("bad quadrature result from isoparametric element")
+	}
+	return newIsoIntegratorAt(elemT, upg, wpg)
+}
+
+// newMassIntegrator returns the integrator of the consistent mass matrix of elemT, which
+// uses the element's MassQuadrature if it is a MassIsoparametric element. Otherwise the
+// element's Quadrature is used, which may underintegrate the mass matrix.
+func newMassIntegrator(elemT Isoparametric) (*isoIntegrator, error) {
+	massT, ok := elemT.(MassIsoparametric)
+	if !ok {
+		return newIsoIntegrator(elemT)
+	}
+	upg, wpg := massT.MassQuadrature()
+	if len(upg) == 0 || len(upg) != len(wpg) {
+		return nil, fmt.Errorf("bad mass quadrature result from isoparametric element")
+	}
+	return newIsoIntegratorAt(elemT, upg, wpg)
+}
+
+// newIsoIntegratorAt returns an integrator of elemT over the points upg
+// in the element's isoparametric coordinates with weights wpg.
+func newIsoIntegratorAt(elemT Isoparametric, upg []r3.Vec, wpg []float64) (*isoIntegrator, error) {
+	NnodperElem := elemT.LenNodes()
+	if NnodperElem <= 0 {
+		return nil, errors.New("isoparametric element must have at least one node")
+	}
+	it := &isoIntegrator{
+		NdimsPerNode: len(elemT.BasisDiff(r3.Vec{})) / NnodperElem,
+		NnodperElem:  NnodperElem,
+		upg:          upg,
+		wpg:          wpg,
+	}
+	// Calculate form functions evaluated at integration points.
+	it.Npg = make([]*mat.VecDense, len(it.upg))
+	it.dNpg = make([]*mat.Dense, len(it.upg))
+	for ipg, pg := range it.upg {
+		it.Npg[ipg] = mat.NewVecDense(NnodperElem, elemT.Basis(pg))
+		it.dNpg[ipg] = mat.NewDense(it.NdimsPerNode, NnodperElem, elemT.BasisDiff(pg))
+	}
+	it.jac = mat.NewDense(it.NdimsPerNode, it.NdimsPerNode, nil)
+	it.dNxy = mat.NewDense(it.NdimsPerNode, NnodperElem, nil)
+	return it, nil
+}
+
+// clone returns a copy of it with its own scratch space
+// that can be used concurrently with it.
+func (it *isoIntegrator) clone() *isoIntegrator {
+	c := *it
+	c.jac = mat.NewDense(it.NdimsPerNode, it.NdimsPerNode, nil)
+	c.dNxy = mat.NewDense(it.NdimsPerNode, it.NnodperElem, nil)
+	return &c
+}
+
+// jacobian calculates the form function derivatives with respect to the physical
+// coordinates of the element at quadrature point ipg and stores them in it.dNxy.
+// It returns the determinant of the jacobian.
+func (it *isoIntegrator) jacobian(iele, ipg int, elemNod *mat.Dense) (dJac float64, err error) {
+	dN := it.dNpg[ipg]
+	it.jac.Mul(dN, elemNod)
+	dJac = mat.Det(it.jac)
+	if dJac < 0 {
+		return 0, fmt.Errorf("negative determinant of jacobian of element #%d, Check node ordering", iele)
+	} else if dJac < 1e-12 {
+		return 0, fmt.Errorf("zero determinant of jacobian of element #%d, Check element shape for bad aspect ratio", iele)
+	}
+	err = it.dNxy.Solve(it.jac, dN)
+	if err != nil {
+		return 0, fmt.Errorf("error calculating element #%d form factor: %s", iele, err)
+	}
+	return dJac, nil
+}
+
+// strainDisplacement sets the strain displacement matrix B at quadrature point ipg
+// and returns the integration scale factor. It must be called after jacobian.
+func (it *isoIntegrator) strainDisplacement(B *mat.Dense, iele, ipg int, elemNod *mat.Dense, c IsoConstituter) (scale float64, err error) {
+	scale = c.SetStrainDisplacementMatrix(B, elemNod, it.dNxy, it.Npg[ipg])
+	if math.IsNaN(scale) {
+		return 0, fmt.Errorf("NaN scale value returned by SetStrainDisplacementMatrix at element #%d, quad %d", iele, ipg)
+	}
+	return scale, nil
+}
+
+// position returns the physical position of quadrature point ipg of an element.
+func (it *isoIntegrator) position(ipg int, elemNod *mat.Dense) (pos r3.Vec) {
+	N := it.Npg[ipg]
+	var p [3]float64
+	for i := 0; i < it.NdimsPerNode; i++ {
+		p[i] = mat.Dot(N, elemNod.ColView(i))
+	}
+	return r3.Vec{X: p[0], Y: p[1], Z: p[2]}
+}
+
+// scalarMass stores ∫ρ·Nᵀ·N dV of an element in dst, a NnodperElem×NnodperElem matrix.
+// B is used as scratch space to obtain the integration scale factor from c. The
+// integrator should be that returned by newMassIntegrator.
+func (it *isoIntegrator) scalarMass(dst, B *mat.Dense, iele int, elemNod *mat.Dense, c IsoConstituter, density float64) error {
+	dst.Zero()
+	for ipg := range it.upg {
+		dJac, err := it.jacobian(iele, ipg, elemNod)
+		if err != nil {
+			return err
+		}
+		scale, err := it.strainDisplacement(B, iele, ipg, elemNod, c)
+		if err != nil {
+			return err
+		}
+		N := it.Npg[ipg].RawVector().Data
+		f := density * dJac * it.wpg[ipg] * scale
+		for a := range N {
+			fNa := f * N[a]
+			for b := a; b < len(N); b++ {
+				v := dst.At(a, b) + fNa*N[b]
+				dst.Set(a, b, v)
+				dst.Set(b, a, v)
+			}
+		}
+	}
+	return nil
+}
+
+// stiffness stores ∫Bᵀ·C·B dV of an element in dst, a square matrix of the element's dofs,
+// where Ce is the constitutive matrix of c. B, BtC and BtCB are used as scratch space.
+func (it *isoIntegrator) stiffness(dst, B, BtC, BtCB *mat.Dense, iele int, elemNod *mat.Dense, c IsoConstituter, Ce *mat.Dense) error {
+	dst.Zero()
+	for ipg := range it.upg {
+		dJac, err := it.jacobian(iele, ipg, elemNod)
+		if err != nil {
+			return err
+		}
+		scale, err := it.strainDisplacement(B, iele, ipg, elemNod, c)
+		if err != nil {
+			return err
+		}
+		// Ke = Ke + Bᵀ*C*B * weight*det(J)
+		BtC.Mul(B.T(), Ce)
+		BtCB.Mul(BtC, B)
+		BtCB.Scale(dJac*it.wpg[ipg]*scale, BtCB)
+		dst.Add(dst, BtCB)
+	}
+	return nil
+}
+
+// expandNodal sets dst to the matrix of a field with dofsPerNode dofs per node
+// whose components are uncoupled and equal to scalar matrix src.
+func expandNodal(dst, src *mat.Dense, dofsPerNode int) {
+	dst.Zero()
+	r, c := src.Dims()
+	for a := 0; a < r; a++ {
+		for b := 0; b < c; b++ {
+			v := src.At(a, b)
+			for i := 0; i < dofsPerNode; i++ {
+				dst.Set(a*dofsPerNode+i, b*dofsPerNode+i, v)
+			}
+		}
+	}
+}
+
+// denseConstitutive returns a copy of the constitutive matrix of c
+// as a square Dense matrix.
+func denseConstitutive(c Constituter) (*mat.Dense, error) {
+	C, err := c.Constitutive()
+	if err != nil {
+		return nil, err
+	}
+	dimC, _c := C.Dims()
+	if _c != dimC {
+		return nil, fmt.Errorf("expected constitutive matrix to be square, got %dx%d", dimC, _c)
+	}
+	Cd := mat.NewDense(dimC, dimC, nil)
+	Cd.Copy(C)
+	return Cd, nil
+}
+
+// orient returns c with its material axes oriented by x and y.
+func orient(c IsoConstituter, x, y r3.Vec) (IsoConstituter, error) {
+	oc, ok := c.(OrientableIsoConstituter)
+	if !ok {
+		return nil, errors.New("constituter does not support material orientation")
+	}
+	return oc.Oriented(x, y)
+}
+
+// orientedConstitutive returns the dense constitutive matrix of c with its material
+// axes oriented by x and y. Cd, the matrix of c, is returned if both are zero.
+func orientedConstitutive(c IsoConstituter, Cd *mat.Dense, x, y r3.Vec) (*mat.Dense, error) {
+	if x == (r3.Vec{}) && y == (r3.Vec{}) {
+		return Cd, nil
+	}
+	oc, err := orient(c, x, y)
+	if err != nil {
+		return nil, err
+	}
+	return denseConstitutive(oc)
+}
+
+// orientThermal returns the dense constitutive matrix and thermal
+// strain of c with its material axes oriented by x and y.
+func orientThermal(c ThermalIsoConstituter, x, y r3.Vec) (*mat.Dense, []float64, error) {
+	oc, err := orient(c, x, y)
+	if err != nil {
+		return nil, nil, err
+	}
+	tc, ok := oc.(ThermalIsoConstituter)
+	if !ok {
+		return nil, nil, errors.New("oriented constituter has no thermal strain")
+	}
+	Cd, err := denseConstitutive(tc)
+	if err != nil {
+		return nil, nil, err
+	}
+	eps0 := tc.ThermalStrain()
+	if r, _ := Cd.Dims(); len(eps0) != r {
+		return nil, nil, fmt.Errorf("thermal strain length %d does not match constitutive matrix dimension %d", len(eps0), r)
+	}
+	return Cd, eps0, nil
 }
 
 type lapvec struct {
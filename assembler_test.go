@@ -0,0 +1,721 @@
+package fem_test
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/soypat/go-fem"
+	"github.com/soypat/go-fem/constitution/solids"
+	"github.com/soypat/go-fem/elements"
+	"github.com/soypat/lap"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+func TestAddIsoparametricMass(t *testing.T) {
+	const (
+		tol     = 1e-10
+		density = 7800.
+	)
+	steel := solids.Isotropic{E: 200e9, Poisson: 0.3}
+	// Box of dimensions 2x1x3.
+	hexNodes := []r3.Vec{
+		{X: 0, Y: 0, Z: 0},
+		{X: 2, Y: 0, Z: 0},
+		{X: 2, Y: 1, Z: 0},
+		{X: 0, Y: 1, Z: 0},
+		{X: 0, Y: 0, Z: 3},
+		{X: 2, Y: 0, Z: 3},
+		{X: 2, Y: 1, Z: 3},
+		{X: 0, Y: 1, Z: 3},
+	}
+	tetNodes := []r3.Vec{
+		{X: 0, Y: 0, Z: 0},
+		{X: 1, Y: 0, Z: 0},
+		{X: 0, Y: 1, Z: 0},
+		{X: 0, Y: 0, Z: 1},
+		{X: 0.5, Y: 0, Z: 0},
+		{X: 0, Y: 0.5, Z: 0},
+		{X: 0, Y: 0, Z: 0.5},
+		{X: 0.5, Y: 0.5, Z: 0},
+		{X: 0, Y: 0.5, Z: 0.5},
+		{X: 0.5, Y: 0, Z: 0.5},
+	}
+	// Triangle of area 1 with legs of length 2 and 1.
+	triNodes := elements.Triangle6{}.IsoparametricNodes()
+	for i := range triNodes {
+		triNodes[i].X *= 2
+	}
+	rectNodes := []r3.Vec{{X: 0, Y: 0}, {X: 2, Y: 0}, {X: 2, Y: 1}, {X: 0, Y: 1}}
+	// Closed form scalar mass matrices of straight sided elements of volume v.
+	linearSimplex := func(v float64, nodes int) func(a, b int) (float64, bool) {
+		// Integral of Na·Nb is v·(1+δab)/(n·(n+1)) for a simplex of n nodes.
+		return func(a, b int) (float64, bool) {
+			m := density * v / float64(nodes*(nodes+1))
+			if a == b {
+				m *= 2
+			}
+			return m, true
+		}
+	}
+	quadraticSimplexDiag := func(corner, midside float64, corners int) func(a, b int) (float64, bool) {
+		return func(a, b int) (float64, bool) {
+			if a != b {
+				return 0, false
+			} else if a < corners {
+				return density * corner, true
+			}
+			return density * midside, true
+		}
+	}
+	// Axisymmetric ring of inner radius 2, outer radius 3 and height 1.
+	ringNodes := []r3.Vec{
+		{X: 2, Y: 0},
+		{X: 3, Y: 0},
+		{X: 3, Y: 1},
+		{X: 2, Y: 1},
+	}
+	for _, test := range []struct {
+		name  string
+		elemT fem.Isoparametric
+		c     fem.IsoConstituter
+		nodes []r3.Vec
+		// Expected mass per dof direction.
+		mass float64
+		// Expected entries of the mass matrix of a single dof direction, if known.
+		entry func(a, b int) (float64, bool)
+	}{
+		{name: "hexa8", elemT: elements.Hexa8{}, c: steel.Solid3D(), nodes: hexNodes, mass: density * 6},
+		{name: "hexa8 order 3", elemT: elements.Hexa8{QuadratureOrder: 3}, c: steel.Solid3D(), nodes: hexNodes, mass: density * 6},
+		{name: "tetra4", elemT: elements.Tetra4{}, c: steel.Solid3D(), nodes: tetNodes[:4], mass: density / 6, entry: linearSimplex(1.0/6, 4)},
+		{name: "tetra10", elemT: elements.Tetra10{}, c: steel.Solid3D(), nodes: tetNodes, mass: density / 6, entry: quadraticSimplexDiag(1.0/6/70, 1.0/6*8/105, 4)},
+		{name: "triangle3", elemT: elements.Triangle3{}, c: steel.PlaneStess(), nodes: triNodes[:3], mass: density, entry: linearSimplex(1, 3)},
+		{name: "triangle6", elemT: elements.Triangle6{}, c: steel.PlaneStess(), nodes: triNodes, mass: density, entry: quadraticSimplexDiag(1.0/30, 8.0/45, 3)},
+		{name: "quad4 order 1", elemT: elements.Quad4{QuadratureOrder: 1}, c: steel.PlaneStess(), nodes: rectNodes, mass: density * 2},
+		{name: "quad4 axisymmetric", elemT: elements.Quad4{}, c: steel.Axisymmetric(), nodes: ringNodes, mass: density * (3*3 - 2*2) / 2},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			elem := make([]int, test.elemT.LenNodes())
+			for i := range elem {
+				elem[i] = i
+			}
+			ga := fem.NewGeneralAssembler(test.nodes, test.elemT.Dofs())
+			err := ga.AddIsoparametricMass(test.elemT, test.c, density, 1, func(i int) ([]int, r3.Vec, r3.Vec) {
+				return elem, r3.Vec{}, r3.Vec{}
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			M := ga.Msolid()
+			dofsPerNode := test.elemT.Dofs().Count()
+			rigid := lap.NewDenseVector(ga.TotalDofs(), nil)
+			for dof := 0; dof < dofsPerNode; dof++ {
+				// Rigid body translation in direction of dof.
+				for i := 0; i < rigid.Len(); i++ {
+					rigid.SetVec(i, 0)
+					if i%dofsPerNode == dof {
+						rigid.SetVec(i, 1)
+					}
+				}
+				Mu := lap.NewDenseVector(ga.TotalDofs(), nil)
+				Mu.MulVec(M, rigid)
+				got := lap.Dot(rigid, Mu)
+				if math.Abs(got-test.mass) > tol*test.mass {
+					t.Errorf("expected mass %g in dof %d direction, got %g", test.mass, dof, got)
+				}
+			}
+			M.DoNonZero(func(i, j int, v float64) {
+				if i%dofsPerNode != j%dofsPerNode {
+					t.Errorf("mass coupling between dofs of different direction at (%d,%d)", i, j)
+				}
+				if math.Abs(v-M.At(j, i)) > tol*math.Abs(v) {
+					t.Errorf("mass matrix not symmetric at (%d,%d)", i, j)
+				}
+			})
+			// Mass matrix of the first dof direction.
+			nodes := len(elem)
+			Mn := mat.NewSymDense(nodes, nil)
+			for a := 0; a < nodes; a++ {
+				for b := a; b < nodes; b++ {
+					v := M.At(a*dofsPerNode, b*dofsPerNode)
+					Mn.SetSym(a, b, v)
+					if test.entry == nil {
+						continue
+					}
+					if want, ok := test.entry(a, b); ok && math.Abs(v-want) > tol*math.Abs(want) {
+						t.Errorf("mass matrix entry (%d,%d): want %g, got %g", a, b, want, v)
+					}
+				}
+			}
+			var chol mat.Cholesky
+			if !chol.Factorize(Mn) {
+				t.Error("mass matrix is not positive definite")
+			}
+		})
+	}
+}
+
+func TestAddIsoparametricLumpedMass(t *testing.T) {
+	const (
+		tol     = 1e-10
+		density = 2.5
+		scale   = 2.0
+	)
+	material := solids.Isotropic{E: 1, Poisson: 0.3}
+	for _, test := range []struct {
+		elemT     fem.Isoparametric
+		c         fem.IsoConstituter
+		volume    float64
+		quadratic bool
+		// Published HRZ nodal masses as fractions of the element mass
+		// of the corner nodes and midside nodes, which follow the corners.
+		hrzCorner, hrzMidside float64
+		corners               int
+	}{
+		{elemT: elements.Quad4{}, c: material.PlaneStess(), volume: 4 * scale * scale},
+		{elemT: elements.Quad8{}, c: material.PlaneStess(), volume: 4 * scale * scale, quadratic: true, hrzCorner: 3.0 / 76, hrzMidside: 16.0 / 76, corners: 4},
+		{elemT: elements.Triangle3{}, c: material.PlaneStess(), volume: scale * scale / 2},
+		{elemT: elements.Triangle6{}, c: material.PlaneStess(), volume: scale * scale / 2, quadratic: true, hrzCorner: 3.0 / 57, hrzMidside: 16.0 / 57, corners: 3},
+		{elemT: elements.Hexa8{}, c: material.Solid3D(), volume: 8 * scale * scale * scale},
+		{elemT: elements.Hexa20{}, c: material.Solid3D(), volume: 8 * scale * scale * scale, quadratic: true},
+		{elemT: elements.Tetra4{}, c: material.Solid3D(), volume: scale * scale * scale / 6},
+		{elemT: elements.Tetra10{}, c: material.Solid3D(), volume: scale * scale * scale / 6, quadratic: true, hrzCorner: 1.0 / 36, hrzMidside: 4.0 / 27, corners: 4},
+	} {
+		t.Run(test.elemT.(fmt.Stringer).String(), func(t *testing.T) {
+			// Element geometry is the scaled isoparametric element.
+			nodes := test.elemT.IsoparametricNodes()
+			elem := make([]int, len(nodes))
+			for i := range nodes {
+				nodes[i] = r3.Scale(scale, nodes[i])
+				elem[i] = i
+			}
+			getElement := func(i int) ([]int, r3.Vec, r3.Vec) { return elem, r3.Vec{}, r3.Vec{} }
+			ga := fem.NewGeneralAssembler(nodes, test.elemT.Dofs())
+			masses := make(map[fem.MassLumping]*lap.DenseV)
+			for _, lumping := range []fem.MassLumping{fem.LumpAuto, fem.LumpHRZ, fem.LumpRowSum} {
+				m := lap.NewDenseVector(ga.TotalDofs(), nil)
+				masses[lumping] = m
+				err := ga.AddIsoparametricLumpedMass(m, test.elemT, test.c, density, lumping, 1, getElement)
+				if err != nil {
+					t.Fatal(err)
+				}
+				dofsPerNode := test.elemT.Dofs().Count()
+				totalMass := 0.0
+				expectMass := density * test.volume * float64(dofsPerNode)
+				nonPositive := false
+				for i := 0; i < m.Len(); i++ {
+					totalMass += m.AtVec(i)
+					nonPositive = nonPositive || m.AtVec(i) <= tol*expectMass
+				}
+				if math.Abs(totalMass-expectMass) > tol*expectMass {
+					t.Errorf("lumping %d: expected total mass %g, got %g", lumping, expectMass, totalMass)
+				}
+				expectNonPositive := lumping == fem.LumpRowSum && test.quadratic
+				if nonPositive != expectNonPositive {
+					t.Errorf("lumping %d: expected non-positive masses to be %v, got %v", lumping, expectNonPositive, nonPositive)
+				}
+			}
+			if test.corners > 0 {
+				dofsPerNode := test.elemT.Dofs().Count()
+				for i := 0; i < masses[fem.LumpHRZ].Len(); i++ {
+					want := test.hrzMidside
+					if i/dofsPerNode < test.corners {
+						want = test.hrzCorner
+					}
+					want *= density * test.volume
+					if got := masses[fem.LumpHRZ].AtVec(i); math.Abs(got-want) > tol*want {
+						t.Errorf("dof %d: want HRZ mass %g, got %g", i, want, got)
+					}
+				}
+			}
+			// Automatic lumping switches to HRZ for quadratic elements.
+			want := masses[fem.LumpRowSum]
+			if test.quadratic {
+				want = masses[fem.LumpHRZ]
+			}
+			for i := 0; i < want.Len(); i++ {
+				if got := masses[fem.LumpAuto].AtVec(i); math.Abs(got-want.AtVec(i)) > tol*math.Abs(want.AtVec(i)) {
+					t.Errorf("dof %d: automatic lumping mass %g does not match %g", i, got, want.AtVec(i))
+				}
+			}
+		})
+	}
+}
+
+func TestAddIsoparametricBodyForce(t *testing.T) {
+	const tol = 1e-10
+	material := solids.Isotropic{E: 1, Poisson: 0.3}
+	// Box of dimensions 2x1x3.
+	boxNodes := []r3.Vec{
+		{X: 0, Y: 0, Z: 0},
+		{X: 2, Y: 0, Z: 0},
+		{X: 2, Y: 1, Z: 0},
+		{X: 0, Y: 1, Z: 0},
+		{X: 0, Y: 0, Z: 3},
+		{X: 2, Y: 0, Z: 3},
+		{X: 2, Y: 1, Z: 3},
+		{X: 0, Y: 1, Z: 3},
+	}
+	// Axisymmetric ring of inner radius 2, outer radius 3 and height 1.
+	ringNodes := []r3.Vec{
+		{X: 2, Y: 0},
+		{X: 3, Y: 0},
+		{X: 3, Y: 1},
+		{X: 2, Y: 1},
+	}
+	gravity := r3.Vec{Z: -9.8}
+	for _, test := range []struct {
+		name  string
+		elemT fem.Isoparametric
+		c     fem.IsoConstituter
+		nodes []r3.Vec
+		b     func(r3.Vec) r3.Vec
+		// Expected resultant force.
+		want r3.Vec
+	}{
+		{name: "hexa8 gravity", elemT: elements.Hexa8{}, c: material.Solid3D(), nodes: boxNodes,
+			b: fem.UniformBodyForce(r3.Scale(2, gravity)), want: r3.Scale(2*6, gravity)},
+		{name: "hexa8 linear", elemT: elements.Hexa8{}, c: material.Solid3D(), nodes: boxNodes,
+			b: func(p r3.Vec) r3.Vec { return r3.Vec{X: p.X, Y: p.Z} }, want: r3.Vec{X: 6, Y: 9}},
+		// Axisymmetric loads are integrated per radian: ∫r dA = (3²-2²)/2.
+		{name: "quad4 axisymmetric", elemT: elements.Quad4{}, c: material.Axisymmetric(), nodes: ringNodes,
+			b: fem.UniformBodyForce(r3.Vec{X: 1, Y: -2}), want: r3.Vec{X: 2.5, Y: -5}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			elem := make([]int, test.elemT.LenNodes())
+			for i := range elem {
+				elem[i] = i
+			}
+			ga := fem.NewGeneralAssembler(test.nodes, test.elemT.Dofs())
+			f := lap.NewDenseVector(ga.TotalDofs(), nil)
+			err := ga.AddIsoparametricBodyForce(f, test.elemT, test.c, 1, func(i int) ([]int, r3.Vec, r3.Vec) {
+				return elem, r3.Vec{}, r3.Vec{}
+			}, test.b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got [3]float64
+			dofsPerNode := test.elemT.Dofs().Count()
+			for i := 0; i < f.Len(); i++ {
+				got[i%dofsPerNode] += f.AtVec(i)
+			}
+			want := [3]float64{test.want.X, test.want.Y, test.want.Z}
+			for i := range got {
+				if math.Abs(got[i]-want[i]) > tol {
+					t.Errorf("expected resultant %v, got %v", want, got)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestAddIsoparametricTraction(t *testing.T) {
+	const tol = 1e-10
+	material := solids.Isotropic{E: 1, Poisson: 0.3}
+	// Box of dimensions 2x1x3.
+	boxNodes := []r3.Vec{
+		{X: 0, Y: 0, Z: 0},
+		{X: 2, Y: 0, Z: 0},
+		{X: 2, Y: 1, Z: 0},
+		{X: 0, Y: 1, Z: 0},
+		{X: 0, Y: 0, Z: 3},
+		{X: 2, Y: 0, Z: 3},
+		{X: 2, Y: 1, Z: 3},
+		{X: 0, Y: 1, Z: 3},
+	}
+	// Axisymmetric ring of inner radius 2, outer radius 3 and height 1.
+	ringNodes := []r3.Vec{
+		{X: 2, Y: 0},
+		{X: 3, Y: 0},
+		{X: 3, Y: 1},
+		{X: 2, Y: 1},
+	}
+	// Square of side 2 with midside nodes.
+	quad8Nodes := []r3.Vec{
+		{X: 0, Y: 0},
+		{X: 2, Y: 0},
+		{X: 2, Y: 2},
+		{X: 0, Y: 2},
+		{X: 1, Y: 0},
+		{X: 2, Y: 1},
+		{X: 1, Y: 2},
+		{X: 0, Y: 1},
+	}
+	for _, test := range []struct {
+		name     string
+		elemT    fem.Faceted
+		c        fem.IsoConstituter
+		nodes    []r3.Vec
+		face     int
+		traction func(pos, normal r3.Vec) r3.Vec
+		// Expected nodal forces.
+		want []float64
+	}{
+		// Pressure of 5 on the 2x1 top face: each node carries a quarter of the -10 resultant.
+		{name: "hexa8 top pressure", elemT: elements.Hexa8{}, c: material.Solid3D(), nodes: boxNodes, face: 1,
+			traction: fem.Pressure(5), want: []float64{
+				0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+				0, 0, -2.5, 0, 0, -2.5, 0, 0, -2.5, 0, 0, -2.5,
+			}},
+		// Shear traction on the X=2 face of area 3.
+		{name: "hexa8 side shear", elemT: elements.Hexa8{}, c: material.Solid3D(), nodes: boxNodes, face: 3,
+			traction: func(_, _ r3.Vec) r3.Vec { return r3.Vec{Y: 4} }, want: []float64{
+				0, 0, 0, 0, 3, 0, 0, 3, 0, 0, 0, 0,
+				0, 0, 0, 0, 3, 0, 0, 3, 0, 0, 0, 0,
+			}},
+		// Pressure on outer radius integrated per radian: resultant of -p·r·h.
+		{name: "quad4 axisymmetric outer pressure", elemT: elements.Quad4{}, c: material.Axisymmetric(), nodes: ringNodes, face: 1,
+			traction: fem.Pressure(2), want: []float64{0, 0, -3, 0, -3, 0, 0, 0}},
+		// Uniform traction on a quadratic edge is distributed as 1/6, 1/6, 2/3 of the resultant.
+		{name: "quad8 edge traction", elemT: elements.Quad8{}, c: material.PlaneStess(), nodes: quad8Nodes, face: 2,
+			traction: func(_, _ r3.Vec) r3.Vec { return r3.Vec{X: 1, Y: 3} }, want: []float64{
+				0, 0, 0, 0, 1. / 3, 1, 1. / 3, 1, 0, 0, 0, 0, 4. / 3, 4, 0, 0,
+			}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			elem := make([]int, test.elemT.LenNodes())
+			for i := range elem {
+				elem[i] = i
+			}
+			ga := fem.NewGeneralAssembler(test.nodes, test.elemT.Dofs())
+			f := lap.NewDenseVector(ga.TotalDofs(), nil)
+			err := ga.AddIsoparametricTraction(f, test.elemT, test.c, 1, func(i int) ([]int, int) {
+				return elem, test.face
+			}, test.traction)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, want := range test.want {
+				if got := f.AtVec(i); math.Abs(got-want) > tol {
+					t.Errorf("dof %d: expected force %g, got %g", i, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestIsoparametricStresses(t *testing.T) {
+	const (
+		tol    = 1e-9
+		strain = 1e-3
+	)
+	material := solids.Isotropic{E: 200e9, Poisson: 0.3}
+	elemT := elements.Hexa8{}
+	nodes := elemT.IsoparametricNodes()
+	elem := make([]int, len(nodes))
+	for i := range elem {
+		elem[i] = i
+	}
+	ga := fem.NewGeneralAssembler(nodes, elemT.Dofs())
+	// Uniaxial stress state in X direction.
+	u := lap.NewDenseVector(ga.TotalDofs(), nil)
+	for i, node := range nodes {
+		u.SetVec(3*i, strain*node.X)
+		u.SetVec(3*i+1, -material.Poisson*strain*node.Y)
+		u.SetVec(3*i+2, -material.Poisson*strain*node.Z)
+	}
+	want := material.E * strain
+	calls := 0
+	err := ga.IsoparametricStresses(u, elemT, material.Solid3D(), 1, func(i int) ([]int, r3.Vec, r3.Vec) {
+		return elem, r3.Vec{}, r3.Vec{}
+	}, func(iele int, stresses []float64) {
+		calls++
+		if len(stresses) != 8*6 {
+			t.Fatalf("expected 8 integration points of 6 stresses, got %d values", len(stresses))
+		}
+		for ipg := 0; ipg < len(stresses); ipg += 6 {
+			s := solids.Stress3D(stresses[ipg:])
+			if math.Abs(s.XX-want) > tol*want || math.Abs(s.YY) > tol*want || math.Abs(s.ZZ) > tol*want {
+				t.Errorf("expected uniaxial stress %g, got %+v", want, s)
+			}
+			if math.Abs(s.VonMises()-want) > tol*want {
+				t.Errorf("expected von Mises stress %g, got %g", want, s.VonMises())
+			}
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 callback call, got %d", calls)
+	}
+}
+
+func TestAddIsoparametricOriented(t *testing.T) {
+	const (
+		tol    = 1e-9
+		strain = 1e-3
+	)
+	material := solids.TransverselyIsotropic{Ex: 235e3, Exy: 14e3, Gxy: 28e3, PoissonXY: 0.2, PoissonYZ: 0.25}
+	elemT := elements.Hexa8{}
+	nodes := elemT.IsoparametricNodes()
+	elem := make([]int, len(nodes))
+	for i := range elem {
+		elem[i] = i
+	}
+	// Fibres along the Y axis.
+	getElement := func(i int) ([]int, r3.Vec, r3.Vec) {
+		return elem, r3.Vec{Y: 1}, r3.Vec{Z: 1}
+	}
+	ga := fem.NewGeneralAssembler(nodes, elemT.Dofs())
+	err := ga.AddIsoparametric(elemT, material.Solid3D(), 1, getElement)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Uniaxial stress state along the fibres.
+	u := lap.NewDenseVector(ga.TotalDofs(), nil)
+	for i, node := range nodes {
+		u.SetVec(3*i, -material.PoissonXY*strain*node.X)
+		u.SetVec(3*i+1, strain*node.Y)
+		u.SetVec(3*i+2, -material.PoissonXY*strain*node.Z)
+	}
+	want := material.Ex * strain
+	f := lap.NewDenseVector(ga.TotalDofs(), nil)
+	f.MulVec(ga.Ksolid(), u)
+	for i, node := range nodes {
+		// Each node carries a quarter of the force on its face of area 4.
+		got := r3.Vec{X: f.AtVec(3 * i), Y: f.AtVec(3*i + 1), Z: f.AtVec(3*i + 2)}
+		if r3.Norm(r3.Sub(got, r3.Vec{Y: node.Y * want})) > tol*want {
+			t.Errorf("node %d: want force %g in Y, got %v", i, node.Y*want, got)
+		}
+	}
+	err = ga.IsoparametricStresses(u, elemT, material.Solid3D(), 1, getElement, func(iele int, stresses []float64) {
+		for ipg := 0; ipg < len(stresses); ipg += 6 {
+			s := solids.Stress3D(stresses[ipg:])
+			if math.Abs(s.XX) > tol*want || math.Abs(s.YY-want) > tol*want || math.Abs(s.ZZ) > tol*want {
+				t.Errorf("expected uniaxial stress %g in Y, got %+v", want, s)
+			}
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Planar material axes can not be rotated out of the XY plane.
+	err = ga.AddIsoparametric(elemT, solids.Isotropic{E: 1, Poisson: 0.3}.PlaneStess(), 1, func(i int) ([]int, r3.Vec, r3.Vec) {
+		return elem, r3.Vec{X: 1, Z: 1}, r3.Vec{}
+	})
+	if err == nil {
+		t.Error("expected error orienting plane constituter out of plane")
+	}
+}
+
+func TestAddElement3Beam(t *testing.T) {
+	const (
+		tol = 1e-9
+		L   = 3.
+	)
+	material := solids.Isotropic{E: 1000, Poisson: 0.3}
+	beam := &elements.Beam2dof6{A: 0.1, Iy: 2e-3, Iz: 1e-3, J: 3e-3}
+	err := beam.SetConstitutive(material.Solid3D())
+	if err != nil {
+		t.Fatal(err)
+	}
+	E, G := material.E, material.ShearModulus()
+	// Cantilever in an arbitrary direction with its local Y axis in the plane of v.
+	axis := r3.Vec{X: 1, Y: 2, Z: 2}
+	v := r3.Vec{Z: 1}
+	ex := r3.Unit(axis)
+	ez := r3.Unit(r3.Cross(ex, v))
+	ey := r3.Cross(ez, ex)
+	nodes := []r3.Vec{{X: 1, Y: -1, Z: 0.5}}
+	nodes = append(nodes, r3.Add(nodes[0], r3.Scale(L/r3.Norm(axis), axis)))
+	ga := fem.NewGeneralAssembler(nodes, fem.Dof6)
+	err = ga.AddElement3(beam, material.Solid3D(), 1, func(i int) ([]int, r3.Vec, r3.Vec) {
+		return []int{0, 1}, r3.Vec{}, v
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fix := fem.NewFixity(fem.Dof6, len(nodes))
+	fix.Fix(0, fem.Dof6)
+	for _, test := range []struct {
+		name           string
+		force, torque  r3.Vec
+		wantU, wantRot r3.Vec
+	}{
+		{name: "axial", force: ex, wantU: r3.Scale(L/(E*beam.A), ex)},
+		{name: "bendY", force: ey, wantU: r3.Scale(L*L*L/(3*E*beam.Iz), ey), wantRot: r3.Scale(L*L/(2*E*beam.Iz), ez)},
+		{name: "bendZ", force: ez, wantU: r3.Scale(L*L*L/(3*E*beam.Iy), ez), wantRot: r3.Scale(-L*L/(2*E*beam.Iy), ey)},
+		{name: "torsion", torque: ex, wantRot: r3.Scale(L/(G*beam.J), ex)},
+	} {
+		loads := lap.NewDenseVector(ga.TotalDofs(), nil)
+		f := []float64{test.force.X, test.force.Y, test.force.Z, test.torque.X, test.torque.Y, test.torque.Z}
+		for i, v := range f {
+			loads.SetVec(6+i, v)
+		}
+		u, _, err := fem.LinearStatic(ga.Ksolid(), loads, fix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gotU := r3.Vec{X: u.AtVec(6), Y: u.AtVec(7), Z: u.AtVec(8)}
+		gotRot := r3.Vec{X: u.AtVec(9), Y: u.AtVec(10), Z: u.AtVec(11)}
+		scale := r3.Norm(test.wantU) + r3.Norm(test.wantRot)
+		if r3.Norm(r3.Sub(gotU, test.wantU)) > tol*scale || r3.Norm(r3.Sub(gotRot, test.wantRot)) > tol*scale {
+			t.Errorf("%s: want tip displacement %v and rotation %v, got %v and %v", test.name, test.wantU, test.wantRot, gotU, gotRot)
+		}
+	}
+	// Orientation vector parallel to the beam.
+	err = ga.AddElement3(beam, material.Solid3D(), 1, func(i int) ([]int, r3.Vec, r3.Vec) {
+		return []int{0, 1}, r3.Vec{}, axis
+	})
+	if err == nil {
+		t.Error("expected error with orientation vector parallel to beam")
+	}
+}
+
+func TestSetConcurrency(t *testing.T) {
+	material := solids.Isotropic{E: 1000, Poisson: 0.3}
+	nodes, hexas := boxMesh([]float64{0, 1, 2, 3, 4}, []float64{0, 0.5, 1, 2}, []float64{0, 1, 1.5})
+	elemT := elements.Hexa8{}
+	getElement := func(i int) ([]int, r3.Vec, r3.Vec) {
+		return hexas[i], r3.Vec{}, r3.Vec{}
+	}
+	assemble := func(workers int) *fem.GeneralAssembler {
+		ga := fem.NewGeneralAssembler(nodes, elemT.Dofs())
+		ga.SetConcurrency(workers)
+		err := ga.AddIsoparametric(elemT, material.Solid3D(), len(hexas), getElement)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ga.AddIsoparametricMass(elemT, material.Solid3D(), 7800, len(hexas), getElement)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ga
+	}
+	want := assemble(1)
+	for _, workers := range []int{0, 2, 3, 5, 100} {
+		got := assemble(workers)
+		for name, pair := range map[string][2]*lap.Sparse{
+			"stiffness": {want.Ksolid(), got.Ksolid()},
+			"mass":      {want.Msolid(), got.Msolid()},
+		} {
+			if pair[0].CountNonZero() != pair[1].CountNonZero() {
+				t.Fatalf("workers=%d: %s matrix non zero count mismatch", workers, name)
+			}
+			pair[0].DoNonZero(func(i, j int, v float64) {
+				if pair[1].At(i, j) != v {
+					t.Errorf("workers=%d: %s(%d,%d) want %g, got %g", workers, name, i, j, v, pair[1].At(i, j))
+				}
+			})
+		}
+	}
+	// First erroring element is reported regardless of number of workers.
+	bad := append([][]int{}, hexas...)
+	for _, i := range []int{5, 17} {
+		e := bad[i]
+		bad[i] = []int{e[1], e[0], e[3], e[2], e[5], e[4], e[7], e[6]}
+	}
+	for _, workers := range []int{1, 4} {
+		ga := fem.NewGeneralAssembler(nodes, elemT.Dofs())
+		ga.SetConcurrency(workers)
+		err := ga.AddIsoparametric(elemT, material.Solid3D(), len(bad), func(i int) ([]int, r3.Vec, r3.Vec) {
+			return bad[i], r3.Vec{}, r3.Vec{}
+		})
+		if err == nil || !strings.Contains(err.Error(), "#5,") {
+			t.Errorf("workers=%d: expected error of element #5, got %v", workers, err)
+		}
+	}
+}
+
+func TestAddIsoparametricMaterials(t *testing.T) {
+	steel := solids.Isotropic{E: 200e3, Poisson: 0.3, ThermalExpansion: 12e-6}.PlaneStess().(fem.ThermalIsoConstituter)
+	aluminium := solids.Isotropic{E: 70e3, Poisson: 0.33, ThermalExpansion: 23e-6}.PlaneStess().(fem.ThermalIsoConstituter)
+	densities := []float64{7.8e-9, 2.7e-9}
+	nodes, elems := rectangularMesh([]float64{0, 1, 2, 3}, []float64{0, 0.5, 1}, false)
+	elemT := elements.Quad4{}
+	layer := func(i int) int {
+		if nodes[elems[i][0]].Y < 0.5 {
+			return 0
+		}
+		return 1
+	}
+	deltaT := func(int, []int, []float64) float64 { return 100 }
+	// Reference assembling each material separately.
+	want := fem.NewGeneralAssembler(nodes, elemT.Dofs())
+	wantLoads := lap.NewDenseVector(want.TotalDofs(), nil)
+	for m, c := range []fem.ThermalIsoConstituter{steel, aluminium} {
+		var layerElems [][]int
+		for i := range elems {
+			if layer(i) == m {
+				layerElems = append(layerElems, elems[i])
+			}
+		}
+		getElement := func(i int) ([]int, r3.Vec, r3.Vec) {
+			return layerElems[i], r3.Vec{}, r3.Vec{}
+		}
+		err := want.AddIsoparametric(elemT, c, len(layerElems), getElement)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = want.AddIsoparametricMass(elemT, c, densities[m], len(layerElems), getElement)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = want.AddIsoparametricThermalLoad(wantLoads, elemT, c, len(layerElems), getElement, deltaT)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	got := fem.NewGeneralAssembler(nodes, elemT.Dofs())
+	gotLoads := lap.NewDenseVector(got.TotalDofs(), nil)
+	materials := []fem.IsoConstituter{steel, aluminium}
+	getElement := func(i int) ([]int, int, r3.Vec, r3.Vec) {
+		return elems[i], layer(i), r3.Vec{}, r3.Vec{}
+	}
+	err := got.AddIsoparametricMaterials(elemT, materials, len(elems), getElement)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = got.AddIsoparametricMassMaterials(elemT, materials, densities, len(elems), getElement)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = got.AddIsoparametricThermalLoadMaterials(gotLoads, elemT, []fem.ThermalIsoConstituter{steel, aluminium}, len(elems), getElement, deltaT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want.Ksolid().DoNonZero(func(i, j int, v float64) {
+		if math.Abs(got.Ksolid().At(i, j)-v) > 1e-9*math.Abs(v) {
+			t.Errorf("K(%d,%d): want %g, got %g", i, j, v, got.Ksolid().At(i, j))
+		}
+	})
+	want.Msolid().DoNonZero(func(i, j int, v float64) {
+		if math.Abs(got.Msolid().At(i, j)-v) > 1e-9*math.Abs(v) {
+			t.Errorf("M(%d,%d): want %g, got %g", i, j, v, got.Msolid().At(i, j))
+		}
+	})
+	for i := 0; i < wantLoads.Len(); i++ {
+		if v := wantLoads.AtVec(i); math.Abs(gotLoads.AtVec(i)-v) > 1e-9*math.Abs(v) {
+			t.Errorf("thermal load %d: want %g, got %g", i, v, gotLoads.AtVec(i))
+		}
+	}
+	outOfRange := func(i int) ([]int, int, r3.Vec, r3.Vec) {
+		return elems[i], 2, r3.Vec{}, r3.Vec{}
+	}
+	err = got.AddIsoparametricMaterials(elemT, materials, len(elems), outOfRange)
+	if err == nil {
+		t.Error("expected error with material index out of range")
+	}
+	err = got.AddIsoparametricMassMaterials(elemT, materials, densities, len(elems), outOfRange)
+	if err == nil {
+		t.Error("expected mass error with material index out of range")
+	}
+	err = got.AddIsoparametricThermalLoadMaterials(gotLoads, elemT, []fem.ThermalIsoConstituter{steel, aluminium}, len(elems), outOfRange, deltaT)
+	if err == nil {
+		t.Error("expected thermal load error with material index out of range")
+	}
+	err = got.AddIsoparametricMassMaterials(elemT, materials, densities[:1], len(elems), getElement)
+	if err == nil {
+		t.Error("expected error with mismatched number of densities")
+	}
+	err = got.AddIsoparametricMaterials(elemT, []fem.IsoConstituter{steel, solids.Isotropic{E: 1, Poisson: 0.3}.Solid3D()}, len(elems), func(i int) ([]int, int, r3.Vec, r3.Vec) {
+		return elems[i], 0, r3.Vec{}, r3.Vec{}
+	})
+	if err == nil {
+		t.Error("expected error with mismatched constitutive dimensions")
+	}
+}
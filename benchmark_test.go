@@ -7,6 +7,7 @@ import (
 	"github.com/soypat/go-fem"
 	"github.com/soypat/go-fem/constitution/solids"
 	"github.com/soypat/go-fem/elements"
+	"github.com/soypat/lap"
 	"github.com/soypat/manigold/tetra"
 	"gonum.org/v1/gonum/spatial/r3"
 )
@@ -50,6 +51,35 @@ func BenchmarkTetra4Assembly(b *testing.B) {
 	}
 }
 
+func BenchmarkTetra4InternalForces(b *testing.B) {
+	const dim = 1.0
+	box := r3.Box{Max: r3.Vec{X: dim, Y: dim, Z: dim}}
+	elemT := elements.Tetra4{}
+	material := solids.Isotropic{E: 200e9, Poisson: 0.3}
+	for _, div := range []float64{2, 8, 16, 32} {
+		bcc := tetra.MakeBCC(box, dim/div)
+		nodes, tetras := bcc.MeshTetraBCC()
+		totalDofs := len(nodes) * 3
+		ga := fem.NewGeneralAssembler(nodes, fem.DofPos)
+		model, err := fem.NewExplicitModel(ga, elemT, material.Solid3D(), 7800, len(tetras), func(i int) (elem []int, xC r3.Vec, yC r3.Vec) {
+			return tetras[i][:], xC, yC
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+		u := lap.NewDenseVector(totalDofs, nil)
+		f := lap.NewDenseVector(totalDofs, nil)
+		b.Run(fmt.Sprintf("%d dofs, %d elems", totalDofs, len(tetras)), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				err := model.InternalForces(f, u)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
 // var (
 // 	_benchDiv               = 16.0
 // 	benchNodes, benchTetras = tetra.MakeBCC(r3.Box{Max: r3.Vec{X: 1, Y: 1, Z: 1}}, 1.0/_benchDiv).MeshTetraBCC()
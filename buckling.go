@@ -0,0 +1,80 @@
+package fem
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/soypat/lap"
+)
+
+// BucklingModes holds the linear buckling modes of a model, the solution of
+// the eigenvalue problem (K + λ·Kσ)·φ = 0, in increasing order of the magnitude of λ.
+type BucklingModes struct {
+	// LoadFactors λ by which the reference loads are multiplied for the model to
+	// buckle. A negative load factor corresponds to buckling under reversed loads.
+	LoadFactors []float64
+	// Shapes are the buckling mode shapes φ scaled to a maximum absolute component of 1.
+	// They are indexed by the model's global dofs and are zero at fixed dofs.
+	Shapes []*lap.DenseV
+}
+
+// Len returns the number of buckling modes.
+func (b *BucklingModes) Len() int { return len(b.LoadFactors) }
+
+// Buckling returns the nModes linear buckling modes of lowest load factor magnitude of a model
+// with stiffness matrix K and geometric stiffness matrix Kσ of the stress state due to reference loads.
+// Kσ is usually assembled with AddIsoparametricGeometric and AddElement3Geometric from the
+// displacements of a linear static analysis of the reference loads. The fixed dofs of fix are
+// held at zero regardless of their prescribed values. It uses SubspaceIteration with its default values.
+func Buckling(K, Kgeom lap.Matrix, fix Fixity, nModes int) (*BucklingModes, error) {
+	var si SubspaceIteration
+	return si.Buckling(K, Kgeom, fix, nModes)
+}
+
+// Buckling returns the nModes linear buckling modes with load factors closest to the shift,
+// which must be such that K + σ·Kσ is positive definite. See the Buckling function for details.
+// A NotConvergedError is returned if the tolerance is not reached.
+func (si *SubspaceIteration) Buckling(K, Kgeom lap.Matrix, fix Fixity, nModes int) (*BucklingModes, error) {
+	var part staticPartition
+	_, err := part.reset(K, fix)
+	if err != nil {
+		return nil, err
+	}
+	r, c := Kgeom.Dims()
+	if r != part.n || c != part.n {
+		return nil, fmt.Errorf("geometric stiffness matrix dimensions %dx%d do not match stiffness matrix dimension %d", r, c, part.n)
+	}
+	// K·φ = λ·(-Kσ)·φ
+	values, vectors, err := si.solve(K, negated{Kgeom}, false, part.freeIdx, len(part.free), nModes)
+	if err != nil {
+		return nil, err
+	}
+	modes := &BucklingModes{LoadFactors: values}
+	for _, v := range vectors {
+		var maxAbs, sign float64
+		for _, vi := range v {
+			if math.Abs(vi) > maxAbs {
+				maxAbs = math.Abs(vi)
+				sign = math.Copysign(1, vi)
+			}
+		}
+		for i := range v {
+			v[i] = v[i] / maxAbs * sign
+		}
+		modes.Shapes = append(modes.Shapes, expandFree(v, part.free, part.n))
+	}
+	return modes, nil
+}
+
+// negated is the matrix -A.
+type negated struct {
+	A lap.Matrix
+}
+
+func (m negated) Dims() (r, c int)    { return m.A.Dims() }
+func (m negated) At(i, j int) float64 { return -m.A.At(i, j) }
+
+// DoNonZero calls fn for the non-zero entries of the matrix.
+func (m negated) DoNonZero(fn func(i, j int, v float64)) {
+	doNonZero(m.A, func(i, j int, v float64) { fn(i, j, -v) })
+}
@@ -0,0 +1,192 @@
+package fem_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/soypat/go-fem"
+	"github.com/soypat/go-fem/constitution/solids"
+	"github.com/soypat/go-fem/elements"
+	"github.com/soypat/lap"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+func TestBucklingBeam(t *testing.T) {
+	const (
+		tol    = 1e-3
+		L      = 4.
+		nElems = 10
+	)
+	material := solids.Isotropic{E: 1000, Poisson: 0.3}
+	beam := &elements.Beam2dof6{A: 0.1, Iy: 2e-3, Iz: 1e-3, J: 3e-3}
+	err := beam.SetConstitutive(material.Solid3D())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Cantilever column in an arbitrary direction with compressive unit load at its tip.
+	axis := r3.Unit(r3.Vec{X: 1, Y: -2, Z: 2})
+	var nodes []r3.Vec
+	for i := 0; i <= nElems; i++ {
+		nodes = append(nodes, r3.Scale(L*float64(i)/nElems, axis))
+	}
+	getElement := func(i int) ([]int, r3.Vec, r3.Vec) {
+		return []int{i, i + 1}, r3.Vec{}, r3.Vec{X: 1}
+	}
+	ga := fem.NewGeneralAssembler(nodes, fem.Dof6)
+	err = ga.AddElement3(beam, material.Solid3D(), nElems, getElement)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fix := fem.NewFixity(fem.Dof6, len(nodes))
+	fix.Fix(0, fem.Dof6)
+	loads := lap.NewDenseVector(ga.TotalDofs(), nil)
+	tip := 6 * nElems
+	loads.SetVec(tip, -axis.X)
+	loads.SetVec(tip+1, -axis.Y)
+	loads.SetVec(tip+2, -axis.Z)
+	u, _, err := fem.LinearStatic(ga.Ksolid(), loads, fix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ga.AddElement3Geometric(beam, u, nElems, getElement)
+	if err != nil {
+		t.Fatal(err)
+	}
+	modes, err := fem.Buckling(ga.Ksolid(), ga.Kgeometric(), fix, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Euler critical loads of a cantilever about each axis of the section.
+	euler := func(I float64) float64 { return math.Pi * math.Pi * material.E * I / (4 * L * L) }
+	want := []float64{euler(beam.Iz), euler(beam.Iy)}
+	for i, got := range modes.LoadFactors[:2] {
+		if math.Abs(got-want[i]) > tol*want[i] {
+			t.Errorf("mode %d: want critical load factor %g, got %g", i, want[i], got)
+		}
+	}
+	// Buckling shapes are perpendicular to the column and normalised.
+	for i, shape := range modes.Shapes[:2] {
+		d := r3.Vec{X: shape.AtVec(tip), Y: shape.AtVec(tip + 1), Z: shape.AtVec(tip + 2)}
+		if math.Abs(r3.Dot(d, axis)) > tol*r3.Norm(d) {
+			t.Errorf("mode %d: tip displacement %v not perpendicular to column", i, d)
+		}
+		var maxAbs float64
+		for j := 0; j < shape.Len(); j++ {
+			maxAbs = math.Max(maxAbs, math.Abs(shape.AtVec(j)))
+		}
+		if maxAbs != 1 {
+			t.Errorf("mode %d: expected maximum component 1, got %g", i, maxAbs)
+		}
+	}
+}
+
+func TestBucklingBeamTorsion(t *testing.T) {
+	const (
+		tol    = 1e-9
+		L      = 4.
+		nElems = 4
+	)
+	material := solids.Isotropic{E: 1000, Poisson: 0.3}
+	beam := &elements.Beam2dof6{A: 0.1, Iy: 2e-3, Iz: 1e-3, J: 3e-3}
+	err := beam.SetConstitutive(material.Solid3D())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var nodes []r3.Vec
+	for i := 0; i <= nElems; i++ {
+		nodes = append(nodes, r3.Vec{X: L * float64(i) / nElems})
+	}
+	getElement := func(i int) ([]int, r3.Vec, r3.Vec) {
+		return []int{i, i + 1}, r3.Vec{}, r3.Vec{Y: 1}
+	}
+	ga := fem.NewGeneralAssembler(nodes, fem.Dof6)
+	err = ga.AddElement3(beam, material.Solid3D(), nElems, getElement)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Column under a compressive unit load free only to shorten and twist.
+	fix := fem.NewFixity(fem.Dof6, len(nodes))
+	fix.Fix(0, fem.Dof6)
+	for i := 1; i < len(nodes); i++ {
+		fix.Fix(i, fem.DofPosY|fem.DofPosZ|fem.DofRotY|fem.DofRotZ)
+	}
+	loads := lap.NewDenseVector(ga.TotalDofs(), nil)
+	loads.SetVec(6*nElems, -1)
+	u, _, err := fem.LinearStatic(ga.Ksolid(), loads, fix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ga.AddElement3Geometric(beam, u, nElems, getElement)
+	if err != nil {
+		t.Fatal(err)
+	}
+	modes, err := fem.Buckling(ga.Ksolid(), ga.Kgeometric(), fix, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Torsional buckling load G·J/(J/A) of a section without warping.
+	want := material.E / (2 * (1 + material.Poisson)) * beam.A
+	if got := modes.LoadFactors[0]; math.Abs(got-want) > tol*want {
+		t.Errorf("want torsional critical load factor %g, got %g", want, got)
+	}
+}
+
+func TestBucklingPlate(t *testing.T) {
+	const (
+		tol = 1e-2
+		L   = 10.
+		h   = 0.5
+	)
+	var xs []float64
+	for i := 0; i <= 40; i++ {
+		xs = append(xs, L*float64(i)/40)
+	}
+	nodes, elems := rectangularMesh(xs, []float64{0, h / 2, h}, true)
+	elemT := elements.Quad8{}
+	material := solids.Isotropic{E: 1000, Poisson: 0.3}
+	c := material.PlaneStess()
+	getElement := func(i int) ([]int, r3.Vec, r3.Vec) { return elems[i], r3.Vec{}, r3.Vec{} }
+	ga := fem.NewGeneralAssembler(nodes, elemT.Dofs())
+	err := ga.AddIsoparametric(elemT, c, len(elems), getElement)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fix := fem.NewFixity(elemT.Dofs(), len(nodes))
+	loads := lap.NewDenseVector(ga.TotalDofs(), nil)
+	for i, node := range nodes {
+		switch node.X {
+		case 0:
+			fix.Fix(i, elemT.Dofs())
+		case L:
+			// Consistent nodal loads of a uniform unit compression of the free end.
+			f := 1. / 6
+			if node.Y == h/4 || node.Y == 3*h/4 {
+				f = 2. / 3
+			} else if node.Y == h/2 {
+				f = 1. / 3
+			}
+			loads.SetVec(2*i, -f/2)
+		}
+	}
+	u, _, err := fem.LinearStatic(ga.Ksolid(), loads, fix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ga.AddIsoparametricGeometric(elemT, u, c, len(elems), getElement)
+	if err != nil {
+		t.Fatal(err)
+	}
+	modes, err := fem.Buckling(ga.Ksolid(), ga.Kgeometric(), fix, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := math.Pi * math.Pi * material.E * h * h * h / 12 / (4 * L * L)
+	if got := modes.LoadFactors[0]; math.Abs(got-want) > tol*want {
+		t.Errorf("want critical load factor %g, got %g", want, got)
+	}
+	// Axisymmetric constituters are not supported.
+	err = ga.AddIsoparametricGeometric(elemT, u, material.Axisymmetric(), len(elems), getElement)
+	if err == nil {
+		t.Error("expected error for axisymmetric geometric stiffness")
+	}
+}
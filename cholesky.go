@@ -0,0 +1,509 @@
+package fem
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/soypat/lap"
+)
+
+// Ordering is a permutation strategy of the rows and columns of a sparse
+// matrix used to reduce the fill-in of its factorization.
+type Ordering int
+
+const (
+	// OrderMinimumDegree eliminates the row with the least number of off-diagonal
+	// entries first. It usually results in the least fill-in and is the default.
+	OrderMinimumDegree Ordering = iota
+	// OrderRCM is the reverse Cuthill-McKee ordering which reduces the matrix bandwidth.
+	OrderRCM
+	// OrderNatural leaves the matrix in its original order.
+	OrderNatural
+)
+
+// SparseCholesky is the LDLᵀ factorization of a sparse symmetric matrix, a square root
+// free variant of the Cholesky factorization. It also factorizes symmetric indefinite
+// matrices as long as no zero pivot is found.
+//
+// The factorization is done in two steps. The symbolic analysis computes a fill reducing ordering,
+// the elimination tree and the sparsity pattern of the factor. The numeric factorization then
+// computes the values of the factor. The symbolic analysis can be reused to factorize matrices with
+// the same sparsity pattern, and the factorization to solve for multiple right hand sides.
+type SparseCholesky struct {
+	// Ordering is the fill reducing ordering used by Analyze.
+	Ordering Ordering
+	// deferred is the number of trailing rows that are eliminated last
+	// regardless of the ordering, i.e. the Lagrange multipliers of a saddle point system.
+	deferred int
+
+	n int
+	// Permutation of rows and columns and its inverse: row k of the
+	// permuted matrix is row perm[k] of the original matrix.
+	perm, pinv []int
+	// Elimination tree: parent[k] is the parent of node k.
+	parent []int
+	// L is stored by columns: rows li[lp[j]:lp[j+1]] and values lx[lp[j]:lp[j+1]] of column j.
+	lp []int
+	li []int
+	lx []float64
+	d  []float64
+
+	analyzed   bool
+	factorized bool
+	work       []float64
+}
+
+// Analyze performs the symbolic analysis of symmetric matrix A. Only the
+// sparsity pattern of A is used, which must include both triangles of A.
+// Sparse matrices that implement DoNonZero such as lap.Sparse are read efficiently.
+func (sc *SparseCholesky) Analyze(A lap.Matrix) error {
+	Ac, err := NewCSR(A)
+	if err != nil {
+		return err
+	}
+	return sc.analyze(Ac)
+}
+
+// Factorize computes the numeric LDLᵀ factorization of symmetric matrix A. The symbolic
+// analysis is performed if Analyze was not called before or A's dimension changed.
+// A must hold both its lower and upper triangles, as the matrices of GeneralAssembler do,
+// since the lower triangle of A after being permuted by the ordering is used. Matrices
+// holding only one triangle are factorized wrong.
+func (sc *SparseCholesky) Factorize(A lap.Matrix) error {
+	Ac, err := NewCSR(A)
+	if err != nil {
+		return err
+	}
+	if !sc.analyzed || sc.n != Ac.n {
+		err = sc.analyze(Ac)
+		if err != nil {
+			return err
+		}
+	}
+	return sc.factorize(Ac)
+}
+
+// Dims returns the dimensions of the factorized matrix.
+func (sc *SparseCholesky) Dims() (r, c int) { return sc.n, sc.n }
+
+// NonZeros returns the number of off-diagonal non-zero entries of the factor L
+// as determined by the symbolic analysis.
+func (sc *SparseCholesky) NonZeros() int {
+	if !sc.analyzed {
+		return 0
+	}
+	return sc.lp[sc.n]
+}
+
+// SolveVec solves A·x = b and stores the result in dst.
+// Factorize must have been called successfully before.
+func (sc *SparseCholesky) SolveVec(dst *lap.DenseV, b lap.Vector) error {
+	if !sc.factorized {
+		return errors.New("matrix not factorized")
+	} else if b.Len() != sc.n || dst.Len() != sc.n {
+		return fmt.Errorf("vector lengths %d and %d do not match factorization dimension %d", dst.Len(), b.Len(), sc.n)
+	}
+	if len(sc.work) != sc.n {
+		sc.work = make([]float64, sc.n)
+	}
+	for i := range sc.work {
+		sc.work[i] = b.AtVec(i)
+	}
+	sc.solve(sc.work)
+	for i, v := range sc.work {
+		dst.SetVec(i, v)
+	}
+	return nil
+}
+
+// solve solves A·x = b in place, overwriting b with x.
+func (sc *SparseCholesky) solve(b []float64) {
+	n := sc.n
+	x := make([]float64, n)
+	for k, p := range sc.perm {
+		x[k] = b[p]
+	}
+	// L·y = b
+	for j := 0; j < n; j++ {
+		xj := x[j]
+		for p := sc.lp[j]; p < sc.lp[j+1]; p++ {
+			x[sc.li[p]] -= sc.lx[p] * xj
+		}
+	}
+	for j := range x {
+		x[j] /= sc.d[j]
+	}
+	// Lᵀ·x = z
+	for j := n - 1; j >= 0; j-- {
+		xj := x[j]
+		for p := sc.lp[j]; p < sc.lp[j+1]; p++ {
+			xj -= sc.lx[p] * x[sc.li[p]]
+		}
+		x[j] = xj
+	}
+	for k, p := range sc.perm {
+		b[p] = x[k]
+	}
+}
+
+func (sc *SparseCholesky) analyze(A *CSR) error {
+	n := A.n
+	switch sc.Ordering {
+	case OrderMinimumDegree:
+		sc.perm = minimumDegree(A)
+	case OrderRCM:
+		sc.perm = reverseCuthillMcKee(A)
+	case OrderNatural:
+		sc.perm = make([]int, n)
+		for i := range sc.perm {
+			sc.perm[i] = i
+		}
+	default:
+		return fmt.Errorf("unknown ordering %d", sc.Ordering)
+	}
+	if sc.deferred > 0 {
+		// Stable partition of the permutation moving deferred rows to the end.
+		first := n - sc.deferred
+		perm := make([]int, 0, n)
+		for _, p := range sc.perm {
+			if p < first {
+				perm = append(perm, p)
+			}
+		}
+		for _, p := range sc.perm {
+			if p >= first {
+				perm = append(perm, p)
+			}
+		}
+		sc.perm = perm
+	}
+	sc.n = n
+	sc.pinv = make([]int, n)
+	for k, p := range sc.perm {
+		sc.pinv[p] = k
+	}
+	// Elimination tree and column counts of L, see Tim Davis's LDL package.
+	sc.parent = make([]int, n)
+	flag := make([]int, n)
+	lnz := make([]int, n)
+	for k := 0; k < n; k++ {
+		sc.parent[k] = -1
+		flag[k] = k
+		kk := sc.perm[k]
+		for p := A.rowPtr[kk]; p < A.rowPtr[kk+1]; p++ {
+			i := sc.pinv[A.col[p]]
+			// Follow path from i to the root of the etree, stopping at flagged nodes.
+			for ; i < k && flag[i] != k; i = sc.parent[i] {
+				if sc.parent[i] == -1 {
+					sc.parent[i] = k
+				}
+				lnz[i]++
+				flag[i] = k
+			}
+		}
+	}
+	sc.lp = make([]int, n+1)
+	for k := 0; k < n; k++ {
+		sc.lp[k+1] = sc.lp[k] + lnz[k]
+	}
+	sc.li = make([]int, sc.lp[n])
+	sc.lx = make([]float64, sc.lp[n])
+	sc.d = make([]float64, n)
+	sc.analyzed = true
+	sc.factorized = false
+	return nil
+}
+
+// factorize computes the numeric factorization with an up-looking algorithm
+// that computes a row of L at a time.
+func (sc *SparseCholesky) factorize(A *CSR) error {
+	n := sc.n
+	sc.factorized = false
+	var (
+		y       = make([]float64, n)
+		pattern = make([]int, n)
+		flag    = make([]int, n)
+		lnz     = make([]int, n)
+	)
+	for k := 0; k < n; k++ {
+		// Nonzero pattern of kth row of L is computed in topological order.
+		y[k] = 0
+		top := n
+		flag[k] = k
+		kk := sc.perm[k]
+		var akk float64
+		for p := A.rowPtr[kk]; p < A.rowPtr[kk+1]; p++ {
+			i := sc.pinv[A.col[p]]
+			if i > k {
+				continue
+			}
+			if i == k {
+				akk = A.val[p]
+			}
+			y[i] += A.val[p]
+			length := 0
+			for flag[i] != k {
+				pattern[length] = i
+				length++
+				flag[i] = k
+				i = sc.parent[i]
+				if i < 0 || i > k {
+					return errPatternMismatch
+				}
+			}
+			for length > 0 {
+				top--
+				length--
+				pattern[top] = pattern[length]
+			}
+		}
+		sc.d[k] = y[k]
+		y[k] = 0
+		for ; top < n; top++ {
+			i := pattern[top]
+			yi := y[i]
+			y[i] = 0
+			p2 := sc.lp[i] + lnz[i]
+			if p2 >= sc.lp[i+1] {
+				return errPatternMismatch
+			}
+			for p := sc.lp[i]; p < p2; p++ {
+				y[sc.li[p]] -= sc.lx[p] * yi
+			}
+			lki := yi / sc.d[i]
+			sc.d[k] -= lki * yi
+			sc.li[p2] = k
+			sc.lx[p2] = lki
+			lnz[i]++
+		}
+		if sc.d[k] == 0 || math.Abs(sc.d[k]) <= 1e-14*math.Abs(akk) || math.IsNaN(sc.d[k]) {
+			return fmt.Errorf("zero pivot at row %d, matrix is singular", sc.perm[k])
+		}
+	}
+	// Matrices sparser than the analyzed one leave trailing entries of the columns
+	// of L unused, which may hold values of a previous factorization.
+	for j := 0; j < n; j++ {
+		for p := sc.lp[j] + lnz[j]; p < sc.lp[j+1]; p++ {
+			sc.lx[p] = 0
+		}
+	}
+	sc.factorized = true
+	return nil
+}
+
+var errPatternMismatch = errors.New("matrix sparsity pattern does not match symbolic analysis, call Analyze")
+
+// minimumDegree returns the approximate minimum degree ordering of the
+// symmetric sparsity pattern of A. The elimination graph is represented as a
+// quotient graph in which the cliques formed by eliminated variables are stored
+// as elements, so the ordering requires no more memory than A's pattern.
+func minimumDegree(A *CSR) []int {
+	n := A.n
+	var (
+		// Variables adjacent to each variable and elements adjacent to each variable.
+		vars  = make([][]int, n)
+		elems = make([][]int, n)
+		// Variables of each element, indexed by the eliminated pivot.
+		lists      = make([][]int, n)
+		eliminated = make([]bool, n)
+		absorbed   = make([]bool, n)
+		mark       = make([]int, n)
+		wmark      = make([]int, n)
+		w          = make([]int, n)
+		perm       = make([]int, 0, n)
+		pq         = make(degreeQueue, 0, n)
+	)
+	for i := 0; i < n; i++ {
+		for p := A.rowPtr[i]; p < A.rowPtr[i+1]; p++ {
+			if j := A.col[p]; j != i {
+				vars[i] = append(vars[i], j)
+				vars[j] = append(vars[j], i)
+			}
+		}
+	}
+	for i := range mark {
+		mark[i] = -1
+		wmark[i] = -1
+	}
+	for i := 0; i < n; i++ {
+		// Remove duplicate neighbors.
+		a := vars[i][:0]
+		for _, j := range vars[i] {
+			if mark[j] != i {
+				mark[j] = i
+				a = append(a, j)
+			}
+		}
+		vars[i] = a
+		pq = append(pq, degreeNode{node: i, degree: len(a)})
+	}
+	for i := range mark {
+		mark[i] = -1
+	}
+	heap.Init(&pq)
+	deg := make([]int, n)
+	for _, dn := range pq {
+		deg[dn.node] = dn.degree
+	}
+	for k := 0; len(perm) < n; {
+		dn := heap.Pop(&pq).(degreeNode)
+		p := dn.node
+		if eliminated[p] || dn.degree != deg[p] {
+			continue // Stale entry.
+		}
+		eliminated[p] = true
+		perm = append(perm, p)
+		// Variables of new element p are the union of its neighbors and the variables of its adjacent elements,
+		// which are absorbed by p.
+		mark[p] = k
+		var lp []int
+		for _, j := range vars[p] {
+			if !eliminated[j] && mark[j] != k {
+				mark[j] = k
+				lp = append(lp, j)
+			}
+		}
+		for _, e := range elems[p] {
+			if absorbed[e] {
+				continue
+			}
+			for _, j := range lists[e] {
+				if !eliminated[j] && mark[j] != k {
+					mark[j] = k
+					lp = append(lp, j)
+				}
+			}
+			absorbed[e] = true
+			lists[e] = nil
+		}
+		vars[p], elems[p], lists[p] = nil, nil, lp
+		// Number of variables of each element adjacent to lp that are not in lp.
+		for _, i := range lp {
+			for _, e := range elems[i] {
+				if absorbed[e] {
+					continue
+				}
+				if wmark[e] != k {
+					wmark[e] = k
+					le := lists[e][:0]
+					for _, j := range lists[e] {
+						if !eliminated[j] {
+							le = append(le, j)
+						}
+					}
+					lists[e] = le
+					w[e] = len(le)
+				}
+				w[e]--
+			}
+		}
+		// Update adjacency and approximate external degree of lp's variables.
+		remaining := n - len(perm)
+		for _, i := range lp {
+			a := vars[i][:0]
+			for _, j := range vars[i] {
+				// Neighbors in lp are now reached through element p.
+				if !eliminated[j] && mark[j] != k {
+					a = append(a, j)
+				}
+			}
+			vars[i] = a
+			d := len(a) + len(lp) - 1
+			es := elems[i][:0]
+			for _, e := range elems[i] {
+				switch {
+				case absorbed[e]:
+				case w[e] == 0:
+					// All of e's variables are in lp.
+					absorbed[e] = true
+					lists[e] = nil
+				default:
+					es = append(es, e)
+					d += w[e]
+				}
+			}
+			elems[i] = append(es, p)
+			if d > remaining-1 {
+				d = remaining - 1
+			}
+			deg[i] = d
+			heap.Push(&pq, degreeNode{node: i, degree: d})
+		}
+		k++
+	}
+	return perm
+}
+
+type degreeNode struct {
+	node, degree int
+}
+
+type degreeQueue []degreeNode
+
+func (q degreeQueue) Len() int { return len(q) }
+func (q degreeQueue) Less(i, j int) bool {
+	return q[i].degree < q[j].degree || (q[i].degree == q[j].degree && q[i].node < q[j].node)
+}
+func (q degreeQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *degreeQueue) Push(x interface{}) { *q = append(*q, x.(degreeNode)) }
+func (q *degreeQueue) Pop() interface{} {
+	old := *q
+	x := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return x
+}
+
+// reverseCuthillMcKee returns the reverse Cuthill-McKee ordering of the
+// symmetric sparsity pattern of A.
+func reverseCuthillMcKee(A *CSR) []int {
+	n := A.n
+	degree := func(i int) int { return A.rowPtr[i+1] - A.rowPtr[i] }
+	visited := make([]bool, n)
+	order := make([]int, 0, n)
+	level := make([]int, n)
+	// bfs appends the nodes reachable from root in Cuthill-McKee order
+	// and returns the last node visited, which is far from root.
+	bfs := func(root int, mark []bool) (last int) {
+		start := len(order)
+		order = append(order, root)
+		mark[root] = true
+		level[root] = 0
+		var next []int
+		for q := start; q < len(order); q++ {
+			v := order[q]
+			next = next[:0]
+			for p := A.rowPtr[v]; p < A.rowPtr[v+1]; p++ {
+				if u := A.col[p]; !mark[u] {
+					mark[u] = true
+					level[u] = level[v] + 1
+					next = append(next, u)
+				}
+			}
+			sort.Slice(next, func(a, b int) bool {
+				da, db := degree(next[a]), degree(next[b])
+				return da < db || (da == db && next[a] < next[b])
+			})
+			order = append(order, next...)
+		}
+		return order[len(order)-1]
+	}
+	for root := 0; root < n; root++ {
+		if visited[root] {
+			continue
+		}
+		// Find a pseudo-peripheral node of root's component with a trial traversal.
+		start := len(order)
+		trial := make([]bool, n)
+		far := bfs(root, trial)
+		order = order[:start]
+		bfs(far, visited)
+	}
+	// Reverse.
+	for i, j := 0, n-1; i < j; i, j = i+1, j-1 {
+		order[i], order[j] = order[j], order[i]
+	}
+	return order
+}
@@ -0,0 +1,147 @@
+package fem_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/soypat/go-fem"
+	"github.com/soypat/lap"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestSparseCholesky(t *testing.T) {
+	const tol = 1e-9
+	rng := rand.New(rand.NewSource(1))
+	// Laplacian of a grid with a random symmetric perturbation.
+	const nx, ny = 12, 9
+	n := nx * ny
+	A := lap.NewSparse(n, n)
+	for i := 0; i < nx; i++ {
+		for j := 0; j < ny; j++ {
+			k := i*ny + j
+			A.Set(k, k, 4+rng.Float64())
+			if i > 0 {
+				A.Set(k, k-ny, -1)
+				A.Set(k-ny, k, -1)
+			}
+			if j > 0 {
+				v := -1 + 0.1*rng.Float64()
+				A.Set(k, k-1, v)
+				A.Set(k-1, k, v)
+			}
+		}
+	}
+	dense := mat.NewDense(n, n, nil)
+	A.DoNonZero(func(i, j int, v float64) { dense.Set(i, j, v) })
+	var lu mat.LU
+	lu.Factorize(dense)
+	for _, ordering := range []fem.Ordering{fem.OrderMinimumDegree, fem.OrderRCM, fem.OrderNatural} {
+		chol := fem.SparseCholesky{Ordering: ordering}
+		err := chol.Factorize(A)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Multiple right hand sides with the same factorization.
+		for irhs := 0; irhs < 3; irhs++ {
+			b := lap.NewDenseVector(n, nil)
+			bdense := mat.NewVecDense(n, nil)
+			for i := 0; i < n; i++ {
+				v := rng.Float64() - 0.5
+				b.SetVec(i, v)
+				bdense.SetVec(i, v)
+			}
+			x := lap.NewDenseVector(n, nil)
+			err = chol.SolveVec(x, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var want mat.VecDense
+			err = lu.SolveVecTo(&want, false, bdense)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < n; i++ {
+				if math.Abs(x.AtVec(i)-want.AtVec(i)) > tol {
+					t.Fatalf("ordering %d rhs %d: x[%d] want %g, got %g", ordering, irhs, i, want.AtVec(i), x.AtVec(i))
+				}
+			}
+		}
+	}
+}
+
+func TestSparseCholeskyFill(t *testing.T) {
+	// Grid laplacian numbered row by row has fill proportional to its bandwidth.
+	const nx = 20
+	n := nx * nx
+	A := lap.NewSparse(n, n)
+	for i := 0; i < nx; i++ {
+		for j := 0; j < nx; j++ {
+			k := i*nx + j
+			A.Set(k, k, 4)
+			if i > 0 {
+				A.Set(k, k-nx, -1)
+				A.Set(k-nx, k, -1)
+			}
+			if j > 0 {
+				A.Set(k, k-1, -1)
+				A.Set(k-1, k, -1)
+			}
+		}
+	}
+	natural := fem.SparseCholesky{Ordering: fem.OrderNatural}
+	mindeg := fem.SparseCholesky{Ordering: fem.OrderMinimumDegree}
+	if err := natural.Analyze(A); err != nil {
+		t.Fatal(err)
+	}
+	if err := mindeg.Analyze(A); err != nil {
+		t.Fatal(err)
+	}
+	if mindeg.NonZeros() >= natural.NonZeros() {
+		t.Errorf("minimum degree fill %d not less than natural ordering fill %d", mindeg.NonZeros(), natural.NonZeros())
+	}
+	// Reuse symbolic analysis with new values.
+	if err := mindeg.Factorize(A); err != nil {
+		t.Fatal(err)
+	}
+	A.Set(0, n-1, 1)
+	A.Set(n-1, 0, 1)
+	if err := mindeg.Factorize(A); err == nil {
+		t.Error("expected error factorizing matrix with different sparsity pattern")
+	}
+}
+
+func TestSparseCholeskyRefactorize(t *testing.T) {
+	// Factorizing a matrix sparser than the analyzed one reuses the symbolic analysis.
+	const n = 4
+	tridiagonal := lap.NewSparse(n, n)
+	for i := 0; i < n; i++ {
+		tridiagonal.Set(i, i, 4)
+		if i > 0 {
+			tridiagonal.Set(i, i-1, -1)
+			tridiagonal.Set(i-1, i, -1)
+		}
+	}
+	diagonal := lap.NewSparse(n, n)
+	for i := 0; i < n; i++ {
+		diagonal.Set(i, i, 2)
+	}
+	var chol fem.SparseCholesky
+	for _, A := range []*lap.Sparse{tridiagonal, diagonal} {
+		err := chol.Factorize(A)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	b := lap.NewDenseVector(n, []float64{1, 1, 1, 1})
+	x := lap.NewDenseVector(n, nil)
+	err := chol.SolveVec(x, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		if math.Abs(x.AtVec(i)-0.5) > 1e-15 {
+			t.Errorf("x[%d]: want 0.5, got %g", i, x.AtVec(i))
+		}
+	}
+}
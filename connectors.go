@@ -0,0 +1,226 @@
+package fem
+
+import (
+	"errors"
+	"fmt"
+
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+// AddRBE2 adds to cons the constraints of a rigid body element that links the dependent
+// nodes to the master node, so that the dependent nodes follow the rigid body motion of the master:
+//
+//	u = u_master + θ_master × (x - x_master)
+//	θ = θ_master
+//
+// dofs are the dependent nodes' dofs that are constrained, i.e. DofPos for solid nodes.
+// The master's rotations only take part in the rigid motion if they are in the model, so a
+// model with a mix of solid and beam elements must be of Dof6 dofs. Rotational dofs of solid
+// nodes which are not connected to any element should be fixed to prevent a singular stiffness matrix.
+// The constraints are then enforced with penalties or Lagrange multipliers.
+func (ga *GeneralAssembler) AddRBE2(cons *Constraints, master int, dependents []int, dofs DofsFlag) error {
+	if err := ga.checkConnector(cons, master, dependents); err != nil {
+		return err
+	}
+	dofs &= ga.dofs
+	if dofs == 0 {
+		return errors.New("no dependent dofs in model")
+	}
+	xm := ga.nodes[master]
+	var (
+		dofIdx []int
+		coefs  []float64
+	)
+	for _, dep := range dependents {
+		if dep == master {
+			return errors.New("master node can not be dependent")
+		}
+		d := r3.Sub(ga.nodes[dep], xm)
+		for c := 0; c < maxDofsPerNode; c++ {
+			dof := DofsFlag(1 << c)
+			if !dofs.Has(dof) {
+				continue
+			}
+			dofIdx = append(dofIdx[:0], cons.Dof(dep, dof), cons.Dof(master, dof))
+			coefs = append(coefs[:0], 1, -1)
+			if c < 3 {
+				// Translation due to the master's rotation.
+				for r := 3; r < maxDofsPerNode; r++ {
+					rot := DofsFlag(1 << r)
+					if coef := rotationCoupling(c, r, d); coef != 0 && ga.dofs.Has(rot) {
+						dofIdx = append(dofIdx, cons.Dof(master, rot))
+						coefs = append(coefs, -coef)
+					}
+				}
+			}
+			cons.Add(dofIdx, coefs, 0)
+		}
+	}
+	return nil
+}
+
+// AddRBE3 adds to cons the constraints of an interpolation element whose reference node
+// follows the weighted least squares rigid body motion of the translations of the independent nodes.
+// Forces and moments applied at the reference node are distributed to the independent nodes
+// in proportion to weights without adding stiffness between them. Each node is given
+// a unit weight if weights is nil.
+//
+// refDofs are the reference node's dofs that are constrained. The rigid body rotation is fit only if the
+// model has rotational dofs, otherwise the reference node follows the weighted average translation.
+// The rotation about the line through collinear independent nodes is not determined and is taken as zero.
+// The constraints are then enforced with penalties or Lagrange multipliers.
+func (ga *GeneralAssembler) AddRBE3(cons *Constraints, reference int, refDofs DofsFlag, independents []int, weights []float64) error {
+	if err := ga.checkConnector(cons, reference, independents); err != nil {
+		return err
+	} else if weights != nil && len(weights) != len(independents) {
+		return errors.New("length of weights must match number of independent nodes")
+	}
+	refDofs &= ga.dofs
+	if refDofs == 0 {
+		return errors.New("no reference dofs in model")
+	}
+	// Unknowns of the fit are the model's dofs of the reference node.
+	var comps []int
+	for c := 0; c < maxDofsPerNode; c++ {
+		if ga.dofs.Has(1 << c) {
+			comps = append(comps, c)
+		}
+	}
+	nq := len(comps)
+	// Rows of the observation matrix G of each independent translation u_ic = G_ic·q.
+	type observation struct {
+		node, comp int
+		w          float64
+		g          []float64
+	}
+	var obs []observation
+	A := mat.NewSymDense(nq, nil)
+	for i, ind := range independents {
+		if ind == reference {
+			return errors.New("reference node can not be independent")
+		}
+		w := 1.0
+		if weights != nil {
+			w = weights[i]
+		}
+		if w < 0 {
+			return errors.New("negative weight")
+		}
+		d := r3.Sub(ga.nodes[ind], ga.nodes[reference])
+		for _, c := range comps {
+			if c >= 3 {
+				break
+			}
+			g := make([]float64, nq)
+			for k, r := range comps {
+				if r == c {
+					g[k] = 1
+				} else if r >= 3 {
+					g[k] = rotationCoupling(c, r, d)
+				}
+			}
+			obs = append(obs, observation{node: ind, comp: c, w: w, g: g})
+			for k := 0; k < nq; k++ {
+				for l := k; l < nq; l++ {
+					A.SetSym(k, l, A.At(k, l)+w*g[k]*g[l])
+				}
+			}
+		}
+	}
+	if len(obs) == 0 {
+		return errors.New("no translational dofs in model")
+	}
+	Ainv, err := pseudoInverse(A)
+	if err != nil {
+		return err
+	}
+	// q = A⁺·Σ wᵢ·Gᵢᵀ·uᵢ
+	var (
+		dofIdx []int
+		coefs  []float64
+		col    = mat.NewVecDense(nq, nil)
+	)
+	for k, c := range comps {
+		dof := DofsFlag(1 << c)
+		if !refDofs.Has(dof) {
+			continue
+		}
+		dofIdx = append(dofIdx[:0], cons.Dof(reference, dof))
+		coefs = append(coefs[:0], 1)
+		for _, o := range obs {
+			col.MulVec(Ainv, mat.NewVecDense(nq, o.g))
+			if coef := o.w * col.AtVec(k); coef != 0 {
+				dofIdx = append(dofIdx, cons.Dof(o.node, 1<<o.comp))
+				coefs = append(coefs, -coef)
+			}
+		}
+		cons.Add(dofIdx, coefs, 0)
+	}
+	return nil
+}
+
+func (ga *GeneralAssembler) checkConnector(cons *Constraints, node int, connected []int) error {
+	switch {
+	case cons.modelDofs != ga.dofs || cons.TotalDofs() != ga.TotalDofs():
+		return errors.New("constraints do not match model dofs")
+	case len(connected) == 0:
+		return errors.New("no nodes to connect")
+	case node < 0 || node >= len(ga.nodes):
+		return fmt.Errorf("node %d out of range", node)
+	}
+	for _, n := range connected {
+		if n < 0 || n >= len(ga.nodes) {
+			return fmt.Errorf("node %d out of range", n)
+		}
+	}
+	return nil
+}
+
+// rotationCoupling returns the coefficient of rotational dof r in
+// translational component c of the displacement θ×d due to rotation θ.
+func rotationCoupling(c, r int, d r3.Vec) float64 {
+	switch c*maxDofsPerNode + r {
+	case 0*maxDofsPerNode + 4:
+		return d.Z
+	case 0*maxDofsPerNode + 5:
+		return -d.Y
+	case 1*maxDofsPerNode + 5:
+		return d.X
+	case 1*maxDofsPerNode + 3:
+		return -d.Z
+	case 2*maxDofsPerNode + 3:
+		return d.Y
+	case 2*maxDofsPerNode + 4:
+		return -d.X
+	}
+	return 0
+}
+
+// pseudoInverse returns the Moore-Penrose pseudo inverse of symmetric matrix A.
+func pseudoInverse(A *mat.SymDense) (*mat.Dense, error) {
+	var eig mat.EigenSym
+	if !eig.Factorize(A, true) {
+		return nil, errors.New("eigen decomposition failed")
+	}
+	values := eig.Values(nil)
+	var V mat.Dense
+	eig.VectorsTo(&V)
+	n := len(values)
+	maxVal := values[n-1]
+	if maxVal <= 0 {
+		return nil, errors.New("singular matrix")
+	}
+	inv := mat.NewDense(n, n, nil)
+	for k, v := range values {
+		if v <= 1e-10*maxVal {
+			continue
+		}
+		for i := 0; i < n; i++ {
+			for j := 0; j < n; j++ {
+				inv.Set(i, j, inv.At(i, j)+V.At(i, k)*V.At(j, k)/v)
+			}
+		}
+	}
+	return inv, nil
+}
@@ -0,0 +1,188 @@
+package fem_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/soypat/go-fem"
+	"github.com/soypat/go-fem/constitution/solids"
+	"github.com/soypat/go-fem/elements"
+	"github.com/soypat/lap"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+func TestAddRBE2(t *testing.T) {
+	const tol = 1e-8
+	material := solids.Isotropic{E: 1000, Poisson: 0.3}
+	// Solid block clamped at x=0 with a beam linked to its x=2 face.
+	nodes, hexas := boxMesh([]float64{0, 1, 2}, []float64{0, 1}, []float64{0, 1})
+	master := len(nodes)
+	tip := master + 1
+	nodes = append(nodes, r3.Vec{X: 3, Y: 0.5, Z: 0.5}, r3.Vec{X: 5, Y: 0.5, Z: 0.5})
+	ga := fem.NewGeneralAssembler(nodes, fem.Dof6)
+	elemT := elements.Hexa8{}
+	err := ga.AddIsoparametric(elemT, material.Solid3D(), len(hexas), func(i int) ([]int, r3.Vec, r3.Vec) {
+		return hexas[i], r3.Vec{}, r3.Vec{}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	beam := &elements.Beam2dof6{A: 0.1, Iy: 1e-3, Iz: 1e-3, J: 2e-3}
+	err = beam.SetConstitutive(material.Solid3D())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ga.AddElement3(beam, material.Solid3D(), 1, func(i int) ([]int, r3.Vec, r3.Vec) {
+		return []int{master, tip}, r3.Vec{}, r3.Vec{}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fix := fem.NewFixity(fem.Dof6, len(nodes))
+	cons := fem.NewConstraints(fem.Dof6, len(nodes))
+	var face []int
+	for i, node := range nodes[:master] {
+		// Solid nodes have no rotational stiffness.
+		fix.Fix(i, fem.DofRot)
+		switch node.X {
+		case 0:
+			fix.Fix(i, fem.DofPos)
+		case 2:
+			face = append(face, i)
+		}
+	}
+	err = ga.AddRBE2(cons, master, face, fem.DofPos)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cons.Len() != 3*len(face) {
+		t.Fatalf("expected %d constraints, got %d", 3*len(face), cons.Len())
+	}
+	loads := lap.NewDenseVector(ga.TotalDofs(), nil)
+	force := r3.Vec{Y: 1, Z: -0.5}
+	torque := r3.Vec{X: 2}
+	loads.SetVec(cons.Dof(tip, fem.DofPosY), force.Y)
+	loads.SetVec(cons.Dof(tip, fem.DofPosZ), force.Z)
+	loads.SetVec(cons.Dof(tip, fem.DofRotX), torque.X)
+	u, R, _, err := fem.LinearStaticLagrange(ga.Ksolid(), loads, fix, cons)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Dependent nodes follow the master's rigid body motion.
+	dofVec := func(v lap.Vector, node int, dofs fem.DofsFlag) r3.Vec {
+		first := cons.Dof(node, dofs&-dofs)
+		return r3.Vec{X: v.AtVec(first), Y: v.AtVec(first + 1), Z: v.AtVec(first + 2)}
+	}
+	um, thm := dofVec(u, master, fem.DofPos), dofVec(u, master, fem.DofRot)
+	for _, dep := range face {
+		want := r3.Add(um, r3.Cross(thm, r3.Sub(nodes[dep], nodes[master])))
+		got := dofVec(u, dep, fem.DofPos)
+		if r3.Norm(r3.Sub(got, want)) > tol*r3.Norm(want) {
+			t.Errorf("node %d: want rigid displacement %v, got %v", dep, want, got)
+		}
+	}
+	// Reactions balance the applied loads.
+	var sumF, sumM r3.Vec
+	for i := 0; i < master; i++ {
+		f := dofVec(R, i, fem.DofPos)
+		sumF = r3.Add(sumF, f)
+		sumM = r3.Add(sumM, r3.Cross(nodes[i], f))
+	}
+	wantM := r3.Add(torque, r3.Cross(nodes[tip], force))
+	if r3.Norm(r3.Add(sumF, force)) > tol || r3.Norm(r3.Add(sumM, wantM)) > tol {
+		t.Errorf("reactions force %v and moment %v do not balance applied force %v and moment %v", sumF, sumM, force, wantM)
+	}
+}
+
+func TestAddRBE3(t *testing.T) {
+	const tol = 1e-8
+	material := solids.Isotropic{E: 1000, Poisson: 0.3}
+	nodes, hexas := boxMesh([]float64{0, 1, 2}, []float64{0, 1}, []float64{0, 1})
+	ref := len(nodes)
+	nodes = append(nodes, r3.Vec{X: 2.5, Y: 0.5, Z: 0.5})
+	ga := fem.NewGeneralAssembler(nodes, fem.Dof6)
+	elemT := elements.Hexa8{}
+	err := ga.AddIsoparametric(elemT, material.Solid3D(), len(hexas), func(i int) ([]int, r3.Vec, r3.Vec) {
+		return hexas[i], r3.Vec{}, r3.Vec{}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fix := fem.NewFixity(fem.Dof6, len(nodes))
+	cons := fem.NewConstraints(fem.Dof6, len(nodes))
+	var face []int
+	var weights []float64
+	for i, node := range nodes[:ref] {
+		fix.Fix(i, fem.DofRot)
+		switch node.X {
+		case 0:
+			fix.Fix(i, fem.DofPos)
+		case 2:
+			face = append(face, i)
+			weights = append(weights, 1+node.Y)
+		}
+	}
+	err = ga.AddRBE3(cons, ref, fem.Dof6, face, weights)
+	if err != nil {
+		t.Fatal(err)
+	}
+	force := r3.Vec{Y: 1, Z: 0.5}
+	loads := lap.NewDenseVector(ga.TotalDofs(), nil)
+	loads.SetVec(cons.Dof(ref, fem.DofPosY), force.Y)
+	loads.SetVec(cons.Dof(ref, fem.DofPosZ), force.Z)
+	u, _, lambda, err := fem.LinearStaticLagrange(ga.Ksolid(), loads, fix, cons)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Forces transferred to the independent nodes are statically equivalent to the load.
+	forces := cons.Forces(lambda)
+	var sumF, sumM r3.Vec
+	direct := lap.NewDenseVector(ga.TotalDofs(), nil)
+	for _, ind := range face {
+		first := cons.Dof(ind, fem.DofPosX)
+		f := r3.Vec{X: forces.AtVec(first), Y: forces.AtVec(first + 1), Z: forces.AtVec(first + 2)}
+		sumF = r3.Add(sumF, f)
+		sumM = r3.Add(sumM, r3.Cross(r3.Sub(nodes[ind], nodes[ref]), f))
+		for d := 0; d < 3; d++ {
+			direct.SetVec(first+d, forces.AtVec(first+d))
+		}
+	}
+	if r3.Norm(r3.Sub(sumF, force)) > tol || r3.Norm(sumM) > tol {
+		t.Errorf("transferred force %v and moment %v not equivalent to applied force %v", sumF, sumM, force)
+	}
+	// The interpolation element adds no stiffness: the solid deforms as if loaded directly.
+	fix.Fix(ref, fem.Dof6)
+	want, _, err := fem.LinearStatic(ga.Ksolid(), direct, fix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < cons.Dof(ref, fem.DofPosX); i++ {
+		if math.Abs(u.AtVec(i)-want.AtVec(i)) > tol {
+			t.Errorf("dof %d: want displacement %g, got %g", i, want.AtVec(i), u.AtVec(i))
+		}
+	}
+}
+
+// boxMesh returns a mesh of Hexa8 elements on the grid defined by coordinates xs, ys and zs.
+func boxMesh(xs, ys, zs []float64) (nodes []r3.Vec, elems [][]int) {
+	nx, ny := len(xs), len(ys)
+	for _, z := range zs {
+		for _, y := range ys {
+			for _, x := range xs {
+				nodes = append(nodes, r3.Vec{X: x, Y: y, Z: z})
+			}
+		}
+	}
+	node := func(i, j, k int) int { return (k*ny+j)*nx + i }
+	for k := 0; k < len(zs)-1; k++ {
+		for j := 0; j < ny-1; j++ {
+			for i := 0; i < nx-1; i++ {
+				elems = append(elems, []int{
+					node(i, j, k), node(i+1, j, k), node(i+1, j+1, k), node(i, j+1, k),
+					node(i, j, k+1), node(i+1, j, k+1), node(i+1, j+1, k+1), node(i, j+1, k+1),
+				})
+			}
+		}
+	}
+	return nodes, elems
+}
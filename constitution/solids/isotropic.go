@@ -15,6 +15,8 @@ type Isotropic struct {
 	E float64
 	// Poisson modulus. Usually uses nu as symbol in literature.
 	Poisson float64
+	// Coefficient of linear thermal expansion. Usually uses alpha as symbol in literature.
+	ThermalExpansion float64
 }
 
 // return value will be concrete in future.
@@ -38,6 +40,9 @@ func (m Isotropic) Solid3D() fem.IsoConstituter {
 	var isoc isoconstituter
 	isoc.C, isoc.err = m.Constitutive()
 	isoc.strain = SetStrainDisplacementMatrixXYZ
+	isoc.layout = layout3D
+	a := m.ThermalExpansion
+	isoc.eps0 = []float64{a, a, a, 0, 0, 0}
 	return isoc
 }
 
@@ -63,6 +68,8 @@ func (m Isotropic) PlaneStess() fem.IsoConstituter {
 			0, 0, factor * (1 - nu) / 2,
 		}),
 		strain: SetStrainDisplacementMatrixPlane,
+		eps0:   []float64{m.ThermalExpansion, m.ThermalExpansion, 0},
+		layout: layoutPlane,
 	}
 }
 
@@ -71,13 +78,17 @@ func (m Isotropic) PlaneStrain() fem.IsoConstituter {
 	nu := m.Poisson
 	factor := E / (1 + nu) / (1 - 2*nu)
 	nuf := nu * factor
+	// Out of plane expansion is restrained which increases in-plane thermal strain.
+	a := (1 + nu) * m.ThermalExpansion
 	return isoconstituter{
 		C: mat.NewDense(3, 3, []float64{
-			factor * (1 - nu), nuf, nuf,
-			nuf, factor * (1 - nu), nuf,
-			nuf, nuf, factor * (1 - 2*nu),
+			factor * (1 - nu), nuf, 0,
+			nuf, factor * (1 - nu), 0,
+			0, 0, factor * (1 - 2*nu) / 2,
 		}),
 		strain: SetStrainDisplacementMatrixPlane,
+		eps0:   []float64{a, a, 0},
+		layout: layoutPlane,
 	}
 }
 
@@ -95,8 +106,11 @@ func (m Isotropic) Axisymmetric() fem.IsoConstituter {
 		0, 0, 0, g,
 	}
 	d := mat.NewDense(4, 4, data)
+	a := m.ThermalExpansion
 	return isoconstituter{
 		C:      d,
 		strain: SetStrainDisplacementMatrixAxisymmetric,
+		eps0:   []float64{a, a, a, 0},
+		layout: layoutAxisymmetric,
 	}
 }
@@ -0,0 +1,120 @@
+package solids_test
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/soypat/go-fem"
+	"github.com/soypat/go-fem/constitution/solids"
+	"github.com/soypat/go-fem/elements"
+	"github.com/soypat/lap"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+func TestIsotropicPlaneStrain(t *testing.T) {
+	const tol = 1e-12
+	material := solids.Isotropic{E: 200e9, Poisson: 0.3}
+	C3, err := material.Constitutive()
+	if err != nil {
+		t.Fatal(err)
+	}
+	C2, err := material.PlaneStrain().Constitutive()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Plane strain constitutive matrix is the 3D one restricted to the XX, YY and XY components.
+	idx := []int{0, 1, 3}
+	for i := range idx {
+		for j := range idx {
+			want := C3.At(idx[i], idx[j])
+			if got := C2.At(i, j); math.Abs(got-want) > tol*material.E {
+				t.Errorf("C(%d,%d): want %g, got %g", i, j, want, got)
+			}
+		}
+	}
+}
+
+// A uniform temperature change on an unrestrained body
+// produces strains equal to the thermal strain and no stress.
+func TestIsotropicThermalExpansion(t *testing.T) {
+	const (
+		tol    = 1e-9
+		alpha  = 12e-6
+		deltaT = 150.
+	)
+	material := solids.Isotropic{E: 200e9, Poisson: 0.3, ThermalExpansion: alpha}
+	for _, test := range []struct {
+		elemT fem.Isoparametric
+		c     fem.ThermalIsoConstituter
+		// Free expansion displacement field.
+		u func(pos r3.Vec) r3.Vec
+	}{
+		{elemT: elements.Hexa8{}, c: material.Solid3D().(fem.ThermalIsoConstituter),
+			u: func(p r3.Vec) r3.Vec { return r3.Scale(alpha*deltaT, p) }},
+		{elemT: elements.Quad8{}, c: material.PlaneStess().(fem.ThermalIsoConstituter),
+			u: func(p r3.Vec) r3.Vec { return r3.Scale(alpha*deltaT, p) }},
+		{elemT: elements.Quad4{}, c: material.PlaneStrain().(fem.ThermalIsoConstituter),
+			u: func(p r3.Vec) r3.Vec { return r3.Scale((1+material.Poisson)*alpha*deltaT, p) }},
+		{elemT: elements.Quad4{}, c: material.Axisymmetric().(fem.ThermalIsoConstituter),
+			u: func(p r3.Vec) r3.Vec { return r3.Scale(alpha*deltaT, p) }},
+	} {
+		t.Run(fmt.Sprintf("%v", test.elemT), func(t *testing.T) {
+			// Distorted element away from the axis of revolution.
+			nodes := test.elemT.IsoparametricNodes()
+			elem := make([]int, len(nodes))
+			for i := range nodes {
+				nodes[i] = r3.Add(r3.Vec{X: 3, Y: 1, Z: 2}, r3.Vec{X: nodes[i].X + 0.2*nodes[i].Y, Y: nodes[i].Y, Z: nodes[i].Z + 0.1*nodes[i].X})
+				elem[i] = i
+			}
+			getElement := func(i int) ([]int, r3.Vec, r3.Vec) { return elem, r3.Vec{}, r3.Vec{} }
+			dofs := test.elemT.Dofs()
+			ga := fem.NewGeneralAssembler(nodes, dofs)
+			err := ga.AddIsoparametric(test.elemT, test.c, 1, getElement)
+			if err != nil {
+				t.Fatal(err)
+			}
+			f := lap.NewDenseVector(ga.TotalDofs(), nil)
+			dT := func(int, []int, []float64) float64 { return deltaT }
+			err = ga.AddIsoparametricThermalLoad(f, test.elemT, test.c, 1, getElement, dT)
+			if err != nil {
+				t.Fatal(err)
+			}
+			dofsPerNode := dofs.Count()
+			u := lap.NewDenseVector(ga.TotalDofs(), nil)
+			for i, node := range nodes {
+				ui := test.u(node)
+				comps := []float64{ui.X, ui.Y, ui.Z}
+				for j := 0; j < dofsPerNode; j++ {
+					u.SetVec(i*dofsPerNode+j, comps[j])
+				}
+			}
+			// Internal forces of free expansion balance thermal loads.
+			Ku := lap.NewDenseVector(ga.TotalDofs(), nil)
+			Ku.MulVec(ga.Ksolid(), u)
+			fmax := 0.0
+			for i := 0; i < f.Len(); i++ {
+				fmax = math.Max(fmax, math.Abs(f.AtVec(i)))
+			}
+			if fmax == 0 {
+				t.Fatal("got zero thermal load")
+			}
+			for i := 0; i < f.Len(); i++ {
+				if math.Abs(Ku.AtVec(i)-f.AtVec(i)) > tol*fmax {
+					t.Errorf("dof %d: thermal load %g does not match free expansion internal force %g", i, f.AtVec(i), Ku.AtVec(i))
+				}
+			}
+			err = ga.IsoparametricThermalStresses(u, test.elemT, test.c, 1, getElement, dT, func(iele int, stresses []float64) {
+				for i := range stresses {
+					if math.Abs(stresses[i]) > tol*material.E*alpha*deltaT {
+						t.Errorf("expected no stress for free expansion, got %g", stresses[i])
+						break
+					}
+				}
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
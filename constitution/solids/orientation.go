@@ -0,0 +1,99 @@
+package solids
+
+import (
+	"errors"
+
+	"github.com/soypat/go-fem"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+// voigtLayout holds the tensor indices (i,j) of each strain component
+// of a constituter, where 0, 1 and 2 correspond to the X, Y and Z axes.
+type voigtLayout [][2]int
+
+var (
+	// [XX, YY, ZZ, XY, YZ, XZ]
+	layout3D = voigtLayout{{0, 0}, {1, 1}, {2, 2}, {0, 1}, {1, 2}, {0, 2}}
+	// [XX, YY, XY]
+	layoutPlane = voigtLayout{{0, 0}, {1, 1}, {0, 1}}
+	// [RR, θθ, ZZ, RZ] where the radial direction R is X,
+	// axial direction Z is Y and the hoop direction θ is Z.
+	layoutAxisymmetric = voigtLayout{{0, 0}, {2, 2}, {1, 1}, {0, 1}}
+)
+
+// Oriented returns the constituter with its material axes oriented so that the
+// material's X axis points in the direction of x and its Y axis lies in the
+// plane of x and y. For plane and axisymmetric constituters the material axes
+// may only be rotated in the XY plane of the model so only x is used.
+func (isoc isoconstituter) Oriented(x, y r3.Vec) (fem.IsoConstituter, error) {
+	if isoc.err != nil {
+		return nil, isoc.err
+	} else if isoc.layout == nil {
+		return nil, errors.New("constituter does not support orientation")
+	}
+	R, err := isoc.layout.materialAxes(x, y)
+	if err != nil {
+		return nil, err
+	}
+	n := len(isoc.layout)
+	// Strains in material axes εₘ = T·ε, so the constitutive matrix in model axes is Tᵀ·Cₘ·T.
+	T := isoc.layout.strainTransform(R)
+	C := mat.NewDense(n, n, nil)
+	C.Product(T.T(), isoc.C, T)
+	oriented := isoc
+	oriented.C = C
+	if isoc.eps0 != nil {
+		// Material axes to model axes is the inverse rotation Rᵀ.
+		var eps0 mat.VecDense
+		eps0.MulVec(isoc.layout.strainTransform(R.T()), mat.NewVecDense(n, isoc.ThermalStrain()))
+		oriented.eps0 = eps0.RawVector().Data
+	}
+	return oriented, nil
+}
+
+// materialAxes returns the rotation matrix whose rows are the material axes.
+func (layout voigtLayout) materialAxes(x, y r3.Vec) (*mat.Dense, error) {
+	var ex, ey, ez r3.Vec
+	if len(layout) == len(layout3D) {
+		ex = r3.Unit(x)
+		ez = r3.Cross(x, y)
+		if r3.Norm(ez) == 0 {
+			return nil, errors.New("material orientation vectors must be non-zero and not parallel")
+		}
+		ez = r3.Unit(ez)
+		ey = r3.Cross(ez, ex)
+	} else {
+		if x.Z != 0 || x == (r3.Vec{}) {
+			return nil, errors.New("material X axis must be non-zero and lie in XY plane")
+		}
+		ex = r3.Unit(x)
+		ez = r3.Vec{Z: 1}
+		ey = r3.Cross(ez, ex)
+	}
+	return mat.NewDense(3, 3, []float64{
+		ex.X, ex.Y, ex.Z,
+		ey.X, ey.Y, ey.Z,
+		ez.X, ez.Y, ez.Z,
+	}), nil
+}
+
+// strainTransform returns the matrix T that transforms strains in engineering
+// notation to the axes given by the rows of rotation R, i.e. ε' = T·ε.
+func (layout voigtLayout) strainTransform(R mat.Matrix) *mat.Dense {
+	n := len(layout)
+	T := mat.NewDense(n, n, nil)
+	for a, ij := range layout {
+		i, j := ij[0], ij[1]
+		// Normal strains are half the sum of the tensor terms.
+		f := 0.5
+		if i != j {
+			f = 1
+		}
+		for b, kl := range layout {
+			k, l := kl[0], kl[1]
+			T.Set(a, b, f*(R.At(i, k)*R.At(j, l)+R.At(i, l)*R.At(j, k)))
+		}
+	}
+	return T
+}
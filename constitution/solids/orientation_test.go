@@ -0,0 +1,85 @@
+package solids_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/soypat/go-fem"
+	"github.com/soypat/go-fem/constitution/solids"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+func TestOrientedTransverselyIsotropic(t *testing.T) {
+	const tol = 1e-9
+	material := solids.TransverselyIsotropic{Ex: 235e3, Exy: 14e3, Gxy: 28e3, PoissonXY: 0.2, PoissonYZ: 0.25}
+	c := material.Solid3D()
+	C, err := c.Constitutive()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Rotation of 90 degrees about Z: fibres along Y.
+	oc, err := c.(fem.OrientableIsoConstituter).Oriented(r3.Vec{Y: 1}, r3.Vec{X: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	Co, err := oc.Constitutive()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Index of the material's strain component for each of the model's [XX, YY, ZZ, XY, YZ, XZ].
+	idx := []int{1, 0, 2, 3, 5, 4}
+	for i := range idx {
+		for j := range idx {
+			want := C.At(idx[i], idx[j])
+			if got := Co.At(i, j); math.Abs(got-want) > tol*material.Ex {
+				t.Errorf("C(%d,%d): want %g, got %g", i, j, want, got)
+			}
+		}
+	}
+	// Fibres must be oriented by non-parallel vectors.
+	_, err = c.(fem.OrientableIsoConstituter).Oriented(r3.Vec{X: 1}, r3.Vec{X: 2})
+	if err == nil {
+		t.Error("expected error orienting with parallel vectors")
+	}
+}
+
+func TestOrientedIsotropic(t *testing.T) {
+	const tol = 1e-9
+	material := solids.Isotropic{E: 1000, Poisson: 0.3, ThermalExpansion: 1e-5}
+	for name, c := range map[string]fem.IsoConstituter{
+		"solid3d":      material.Solid3D(),
+		"planestress":  material.PlaneStess(),
+		"planestrain":  material.PlaneStrain(),
+		"axisymmetric": material.Axisymmetric(),
+	} {
+		x, y := r3.Vec{X: 1, Y: 2, Z: -1}, r3.Vec{X: -1, Y: 0.5, Z: 3}
+		if name != "solid3d" {
+			// Planar constituters rotate about Z.
+			x.Z, y = 0, r3.Vec{}
+		}
+		oc, err := c.(fem.OrientableIsoConstituter).Oriented(x, y)
+		if err != nil {
+			t.Fatal(name, err)
+		}
+		C, _ := c.Constitutive()
+		Co, err := oc.Constitutive()
+		if err != nil {
+			t.Fatal(name, err)
+		}
+		r, _ := C.Dims()
+		for i := 0; i < r; i++ {
+			for j := 0; j < r; j++ {
+				if math.Abs(Co.At(i, j)-C.At(i, j)) > tol*material.E {
+					t.Errorf("%s C(%d,%d): want %g, got %g", name, i, j, C.At(i, j), Co.At(i, j))
+				}
+			}
+		}
+		eps0 := c.(fem.ThermalIsoConstituter).ThermalStrain()
+		eps0o := oc.(fem.ThermalIsoConstituter).ThermalStrain()
+		for i := range eps0 {
+			if math.Abs(eps0o[i]-eps0[i]) > tol*material.ThermalExpansion {
+				t.Errorf("%s thermal strain %d: want %g, got %g", name, i, eps0[i], eps0o[i])
+			}
+		}
+	}
+}
@@ -9,7 +9,6 @@ import (
 	"github.com/soypat/go-fem/constitution/solids"
 	"github.com/soypat/go-fem/elements"
 	"github.com/soypat/lap"
-	"gonum.org/v1/gonum/mat"
 	"gonum.org/v1/gonum/spatial/r3"
 )
 
@@ -59,7 +58,6 @@ func TestAxisymmetricPatchTest(t *testing.T) {
 	}
 
 	t.Run("Case 1", func(t *testing.T) {
-		displacements := lap.NewDenseVector(ga.TotalDofs(), nil)
 		fix := fem.NewFixity(fem.DofPosX|fem.DofPosY, len(nodes))
 		// Displacement loads for case 1.
 		for i, node := range nodes {
@@ -69,27 +67,16 @@ func TestAxisymmetricPatchTest(t *testing.T) {
 			isRightNode := node.X == ri+lx
 			isExternalNode := isTopNode || isBottomNode || isLeftNode || isRightNode
 			if isExternalNode {
-				displacements.SetVec(i*2, node.X/1000)
-				displacements.SetVec(i*2+1, (node.Y+node.X)/1000)
-				fix.Fix(i, fem.DofPosX|fem.DofPosY)
+				fix.Prescribe(i, fem.DofPosX, node.X/1000)
+				fix.Prescribe(i, fem.DofPosY, (node.Y+node.X)/1000)
 			}
 		}
-		free := fix.FreeDofs()
-		fixed := fix.FixedDofs()
-		loadsFromDispl := lap.NewDenseVector(len(free), nil)
-		loadsFromDispl.MulVec(lap.Slice(ga.Ksolid(), free, fixed), lap.SliceVec(displacements, fixed))
-
-		// 0 imposed initial imposed loads.  K(free,free)\(R(free)-K(free,fix)*D(fix))
-		imposedLoads := lap.NewDenseVector(len(free), nil)
-		imposedLoads.SubVec(imposedLoads, loadsFromDispl)
-
-		resultDisplacements := mat.NewVecDense(len(free), nil)
-		resultDisplacements.SolveVec(lapmat{lap.Slice(ga.Ksolid(), free, free)}, lapvec{imposedLoads})
-		// Extract the solution to obtain all displacements, both fixed and free.
-		lap.SliceVec(displacements, free).CopyVec(resultDisplacements)
-		// Compute Reaction forces.
-		forces := lap.NewDenseVector(ga.TotalDofs(), nil)
-		forces.MulVec(ga.Ksolid(), displacements)
+		// No imposed loads, only imposed displacements.
+		loads := lap.NewDenseVector(ga.TotalDofs(), nil)
+		displacements, _, err := fem.LinearStatic(ga.Ksolid(), loads, fix)
+		if err != nil {
+			t.Fatal(err)
+		}
 
 		err = ga.IsoparametricStrains(displacements, elemtype, material.Axisymmetric(), len(q4elems), getelementR3, func(iele int, strains []float64) {
 			for i := range strains {
@@ -103,7 +90,6 @@ func TestAxisymmetricPatchTest(t *testing.T) {
 
 	// Case 2: Displacement loads for case 2.
 	t.Run("Case 2", func(t *testing.T) {
-		displacements := lap.NewDenseVector(ga.TotalDofs(), nil)
 		fix := fem.NewFixity(fem.DofPosX|fem.DofPosY, len(nodes))
 		// Displacement loads for case 1.
 		for i, node := range nodes {
@@ -113,27 +99,16 @@ func TestAxisymmetricPatchTest(t *testing.T) {
 			isRightNode := node.X == ri+lx
 			isExternalNode := isTopNode || isBottomNode || isLeftNode || isRightNode
 			if isExternalNode {
-				displacements.SetVec(i*2, node.X/100)
-				displacements.SetVec(i*2+1, (node.Y)/100)
-				fix.Fix(i, fem.DofPosX|fem.DofPosY)
+				fix.Prescribe(i, fem.DofPosX, node.X/100)
+				fix.Prescribe(i, fem.DofPosY, (node.Y)/100)
 			}
 		}
-		free := fix.FreeDofs()
-		fixed := fix.FixedDofs()
-		loadsFromDispl := lap.NewDenseVector(len(free), nil)
-		loadsFromDispl.MulVec(lap.Slice(ga.Ksolid(), free, fixed), lap.SliceVec(displacements, fixed))
-
-		// 0 imposed initial imposed loads.  K(free,free)\(R(free)-K(free,fix)*D(fix))
-		imposedLoads := lap.NewDenseVector(len(free), nil)
-		imposedLoads.SubVec(imposedLoads, loadsFromDispl)
-
-		resultDisplacements := mat.NewVecDense(len(free), nil)
-		resultDisplacements.SolveVec(lapmat{lap.Slice(ga.Ksolid(), free, free)}, lapvec{imposedLoads})
-		// Extract the solution to obtain all displacements, both fixed and free.
-		lap.SliceVec(displacements, free).CopyVec(resultDisplacements)
-		// Compute Reaction forces.
-		forces := lap.NewDenseVector(ga.TotalDofs(), nil)
-		forces.MulVec(ga.Ksolid(), displacements)
+		// No imposed loads, only imposed displacements.
+		loads := lap.NewDenseVector(ga.TotalDofs(), nil)
+		displacements, _, err := fem.LinearStatic(ga.Ksolid(), loads, fix)
+		if err != nil {
+			t.Fatal(err)
+		}
 
 		err = ga.IsoparametricStrains(displacements, elemtype, material.Axisymmetric(), len(q4elems), getelementR3, func(iele int, strains []float64) {
 			for i := 0; i < len(strains); i += 4 {
@@ -202,39 +177,23 @@ func TestAxisymmetricThermalPatchTest(t *testing.T) {
 	}
 
 	t.Run("Case 1", func(t *testing.T) {
-		temperatures := lap.NewDenseVector(ga.TotalDofs(), nil)
 		adiabatic := fem.NewFixity(fem.DofPosX, len(nodes))
 		// Displacement loads for case 1.
 		for i, node := range nodes {
 			isTopNode := node.Y == ly
 			isBottomNode := node.Y == 0
 			if isTopNode {
-				temperatures.SetVec(i, topTemp)
-				adiabatic.Fix(i, dofTemp)
+				adiabatic.Prescribe(i, dofTemp, topTemp)
 			} else if isBottomNode {
-				temperatures.SetVec(i, bottomTemp)
-				adiabatic.Fix(i, dofTemp)
+				adiabatic.Prescribe(i, dofTemp, bottomTemp)
 			}
 		}
-		free := adiabatic.FreeDofs()
-		fixed := adiabatic.FixedDofs()
-		loadsFromDispl := lap.NewDenseVector(len(free), nil)
-		loadsFromDispl.MulVec(lap.Slice(ga.Ksolid(), free, fixed), lap.SliceVec(temperatures, fixed))
-
-		// 0 imposed initial imposed loads.  K(free,free)\(R(free)-K(free,fix)*D(fix))
-		imposedLoads := lap.NewDenseVector(len(free), nil)
-		imposedLoads.SubVec(imposedLoads, loadsFromDispl)
-
-		resultDisplacements := mat.NewVecDense(len(free), nil)
-		err = resultDisplacements.SolveVec(lapmat{lap.Slice(ga.Ksolid(), free, free)}, lapvec{imposedLoads})
+		// No imposed heat flows.
+		heat := lap.NewDenseVector(ga.TotalDofs(), nil)
+		temperatures, _, err := fem.LinearStatic(ga.Ksolid(), heat, adiabatic)
 		if err != nil {
 			t.Fatal(err)
 		}
-		// Extract the solution to obtain all displacements, both fixed and free.
-		lap.SliceVec(temperatures, free).CopyVec(resultDisplacements)
-		// Compute Reaction forces.
-		forces := lap.NewDenseVector(ga.TotalDofs(), nil)
-		forces.MulVec(ga.Ksolid(), temperatures)
 
 		for i, node := range nodes {
 			temp := temperatures.AtVec(i)
@@ -245,22 +204,3 @@ func TestAxisymmetricThermalPatchTest(t *testing.T) {
 		}
 	})
 }
-
-type lapvec struct {
-	lap.Vector
-}
-
-func (v lapvec) T() mat.Matrix {
-	return lapmat{lap.T(v.Vector)}
-}
-
-type lapmat struct {
-	lap.Matrix
-}
-
-func (m lapmat) T() mat.Matrix {
-	if ter, ok := m.Matrix.(mat.Matrix); ok {
-		return ter.T()
-	}
-	return lapmat{lap.T(m.Matrix)}
-}
@@ -8,6 +8,11 @@ import "gonum.org/v1/gonum/mat"
 type isoconstituter struct {
 	C      mat.Matrix
 	strain func(B, elemNod, dN *mat.Dense, N *mat.VecDense) float64
+	// eps0 is the thermal strain per unit temperature change.
+	eps0 []float64
+	// layout holds the tensor indices of each strain component. Nil if
+	// the constituter can not be oriented.
+	layout voigtLayout
 	err    error
 }
 
@@ -18,3 +23,7 @@ func (c2d isoconstituter) Constitutive() (mat.Matrix, error) {
 func (isoc isoconstituter) SetStrainDisplacementMatrix(dstB, elemNod, dN *mat.Dense, N *mat.VecDense) float64 {
 	return isoc.strain(dstB, elemNod, dN, N)
 }
+
+func (isoc isoconstituter) ThermalStrain() []float64 {
+	return append([]float64{}, isoc.eps0...)
+}
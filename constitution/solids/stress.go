@@ -0,0 +1,108 @@
+package solids
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+// Stress is the symmetric Cauchy stress tensor at a point.
+type Stress struct {
+	// Normal stresses.
+	XX, YY, ZZ float64
+	// Shear stresses.
+	XY, YZ, XZ float64
+}
+
+// Stress3D returns the stress tensor of Voigt vector v with the layout of
+// Isotropic.Solid3D stresses: [XX, YY, ZZ, XY, YZ, XZ].
+func Stress3D(v []float64) Stress {
+	return Stress{XX: v[0], YY: v[1], ZZ: v[2], XY: v[3], YZ: v[4], XZ: v[5]}
+}
+
+// StressPlaneStress returns the stress tensor of Voigt vector v with the layout of
+// Isotropic.PlaneStess stresses: [XX, YY, XY]. The out of plane components are zero.
+func StressPlaneStress(v []float64) Stress {
+	return Stress{XX: v[0], YY: v[1], XY: v[2]}
+}
+
+// StressPlaneStrain returns the stress tensor of Voigt vector v with the layout of
+// Isotropic.PlaneStrain stresses: [XX, YY, XY]. The out of plane normal stress
+// that restrains the ZZ strain is calculated from the material's poisson modulus.
+// Thermal strain is not accounted for, see Isotropic.StressPlaneStrain for thermal stresses.
+func StressPlaneStrain(v []float64, poisson float64) Stress {
+	return Stress{XX: v[0], YY: v[1], ZZ: poisson * (v[0] + v[1]), XY: v[2]}
+}
+
+// StressPlaneStrain returns the stress tensor of Voigt vector v with the layout of
+// Isotropic.PlaneStrain stresses: [XX, YY, XY] for a temperature change deltaT.
+// The out of plane normal stress restrains both the poisson and the thermal ZZ strain.
+func (m Isotropic) StressPlaneStrain(v []float64, deltaT float64) Stress {
+	s := StressPlaneStrain(v, m.Poisson)
+	s.ZZ -= m.E * m.ThermalExpansion * deltaT
+	return s
+}
+
+// StressAxisymmetric returns the stress tensor of Voigt vector v with the layout of
+// Isotropic.Axisymmetric stresses: [RR, θθ, ZZ, RZ]. The radial direction R is mapped to X,
+// the axial direction Z is mapped to Y and the hoop direction θ is mapped to Z so that the
+// tensor components match the node coordinates of the model.
+func StressAxisymmetric(v []float64) Stress {
+	return Stress{XX: v[0], ZZ: v[1], YY: v[2], XY: v[3]}
+}
+
+// Hydrostatic returns the mean normal stress.
+func (s Stress) Hydrostatic() float64 {
+	return (s.XX + s.YY + s.ZZ) / 3
+}
+
+// Pressure returns the hydrostatic pressure, which is positive in compression.
+func (s Stress) Pressure() float64 {
+	return -s.Hydrostatic()
+}
+
+// VonMises returns the von Mises equivalent stress.
+func (s Stress) VonMises() float64 {
+	dxy := s.XX - s.YY
+	dyz := s.YY - s.ZZ
+	dzx := s.ZZ - s.XX
+	shear := s.XY*s.XY + s.YZ*s.YZ + s.XZ*s.XZ
+	return math.Sqrt((dxy*dxy+dyz*dyz+dzx*dzx)/2 + 3*shear)
+}
+
+// Tresca returns the Tresca equivalent stress, which is the difference
+// between the maximum and minimum principal stresses, or twice the maximum shear stress.
+func (s Stress) Tresca() float64 {
+	p, _ := s.Principal()
+	return p[0] - p[2]
+}
+
+// Principal returns the principal stresses sorted in decreasing order
+// and their corresponding unit directions.
+func (s Stress) Principal() (values [3]float64, directions [3]r3.Vec) {
+	var eig mat.EigenSym
+	ok := eig.Factorize(s.matrix(), true)
+	if !ok {
+		nan := math.NaN()
+		return [3]float64{nan, nan, nan}, directions
+	}
+	var vecs mat.Dense
+	eig.VectorsTo(&vecs)
+	ascending := eig.Values(nil)
+	for i := range values {
+		// Eigenvalues are returned in ascending order.
+		j := 2 - i
+		values[i] = ascending[j]
+		directions[i] = r3.Vec{X: vecs.At(0, j), Y: vecs.At(1, j), Z: vecs.At(2, j)}
+	}
+	return values, directions
+}
+
+func (s Stress) matrix() *mat.SymDense {
+	return mat.NewSymDense(3, []float64{
+		s.XX, s.XY, s.XZ,
+		s.XY, s.YY, s.YZ,
+		s.XZ, s.YZ, s.ZZ,
+	})
+}
@@ -0,0 +1,95 @@
+package solids_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/soypat/go-fem/constitution/solids"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+func TestStress(t *testing.T) {
+	const tol = 1e-12
+	for _, test := range []struct {
+		name                  string
+		s                     solids.Stress
+		vonMises, tresca, hyd float64
+		principal             [3]float64
+	}{
+		{name: "uniaxial", s: solids.StressPlaneStress([]float64{10, 0, 0}),
+			vonMises: 10, tresca: 10, hyd: 10. / 3, principal: [3]float64{10, 0, 0}},
+		{name: "pure shear", s: solids.Stress3D([]float64{0, 0, 0, 0, 5, 0}),
+			vonMises: 5 * math.Sqrt(3), tresca: 10, hyd: 0, principal: [3]float64{5, 0, -5}},
+		{name: "hydrostatic", s: solids.Stress3D([]float64{-3, -3, -3, 0, 0, 0}),
+			vonMises: 0, tresca: 0, hyd: -3, principal: [3]float64{-3, -3, -3}},
+		{name: "plane strain", s: solids.StressPlaneStrain([]float64{4, 6, 0}, 0.25),
+			vonMises: math.Sqrt(((4-6)*(4-6) + (6-2.5)*(6-2.5) + (2.5-4)*(2.5-4)) / 2), tresca: 3.5, hyd: 12.5 / 3, principal: [3]float64{6, 4, 2.5}},
+		{name: "plane strain thermal", s: solids.Isotropic{E: 100, Poisson: 0.25, ThermalExpansion: 0.01}.StressPlaneStrain([]float64{4, 6, 0}, 1),
+			vonMises: math.Sqrt(((4-6)*(4-6) + (6-1.5)*(6-1.5) + (1.5-4)*(1.5-4)) / 2), tresca: 4.5, hyd: 11.5 / 3, principal: [3]float64{6, 4, 1.5}},
+		// Hoop stress maps to ZZ.
+		{name: "axisymmetric hoop", s: solids.StressAxisymmetric([]float64{0, 7, 0, 0}),
+			vonMises: 7, tresca: 7, hyd: 7. / 3, principal: [3]float64{7, 0, 0}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.s.VonMises(); math.Abs(got-test.vonMises) > tol {
+				t.Errorf("von Mises: want %g, got %g", test.vonMises, got)
+			}
+			if got := test.s.Tresca(); math.Abs(got-test.tresca) > tol {
+				t.Errorf("Tresca: want %g, got %g", test.tresca, got)
+			}
+			if got := test.s.Hydrostatic(); math.Abs(got-test.hyd) > tol {
+				t.Errorf("hydrostatic: want %g, got %g", test.hyd, got)
+			}
+			if got := test.s.Pressure(); math.Abs(got+test.hyd) > tol {
+				t.Errorf("pressure: want %g, got %g", -test.hyd, got)
+			}
+			got, _ := test.s.Principal()
+			for i := range got {
+				if math.Abs(got[i]-test.principal[i]) > tol {
+					t.Errorf("principal: want %v, got %v", test.principal, got)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestStressPrincipalDirections(t *testing.T) {
+	const tol = 1e-10
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 50; i++ {
+		v := make([]float64, 6)
+		for j := range v {
+			v[j] = 200*rng.Float64() - 100
+		}
+		s := solids.Stress3D(v)
+		values, dirs := s.Principal()
+		if values[0] < values[1] || values[1] < values[2] {
+			t.Errorf("principal stresses not in decreasing order: %v", values)
+		}
+		invariant := values[0] + values[1] + values[2]
+		if math.Abs(invariant-3*s.Hydrostatic()) > tol {
+			t.Errorf("principal stresses trace %g does not match stress trace %g", invariant, 3*s.Hydrostatic())
+		}
+		for j, d := range dirs {
+			// Check σ·d = λ·d.
+			sd := r3.Vec{
+				X: s.XX*d.X + s.XY*d.Y + s.XZ*d.Z,
+				Y: s.XY*d.X + s.YY*d.Y + s.YZ*d.Z,
+				Z: s.XZ*d.X + s.YZ*d.Y + s.ZZ*d.Z,
+			}
+			if r3.Norm(r3.Sub(sd, r3.Scale(values[j], d))) > tol {
+				t.Errorf("direction %v is not principal for stress %g", d, values[j])
+			}
+			if math.Abs(r3.Norm(d)-1) > tol {
+				t.Errorf("principal direction %v not of unit length", d)
+			}
+		}
+		// Principal von Mises must match tensor von Mises.
+		p := solids.Stress{XX: values[0], YY: values[1], ZZ: values[2]}
+		if math.Abs(p.VonMises()-s.VonMises()) > tol {
+			t.Errorf("von Mises not invariant: %g != %g", p.VonMises(), s.VonMises())
+		}
+	}
+}
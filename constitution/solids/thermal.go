@@ -39,19 +39,10 @@ func (k IsotropicConductivity) Axisymmetric() fem.IsoConstituter {
 	isoc := isoconstituter{
 		C: mat.NewDiagDense(2, []float64{k.K, k.K}),
 		strain: func(B, elemNod, dN *mat.Dense, N *mat.VecDense) float64 {
-			// Page 458, Cook, Concepts and Applications of Finite Element Analysis, Fourth edition.
-			// Eq. 12.1-16 for the case of axisymmetric elements.
-			NnodperElem, _ := elemNod.Dims()
-			radius := mat.Dot(elemNod.ColView(0), N) // radius inverse.
-			rInverse := 1 / radius
-			for i := 0; i < NnodperElem; i++ {
-				Nir := N.AtVec(i) * rInverse
-				Ndr := dN.At(0, i)
-				Ndz := dN.At(1, i)
-				B.Set(0, i, Nir+Ndr)
-				B.Set(1, i, Ndz)
-			}
-			return radius
+			// The temperature gradient is [∂T/∂r, ∂T/∂z] and the
+			// domain is integrated per radian with dV = r·dr·dz.
+			B.Copy(dN)
+			return mat.Dot(elemNod.ColView(0), N)
 		},
 	}
 	return isoc
@@ -0,0 +1,46 @@
+package solids_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/soypat/go-fem"
+	"github.com/soypat/go-fem/constitution/solids"
+	"github.com/soypat/go-fem/elements"
+	"github.com/soypat/lap"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+func TestIsotropicConductivityAxisymmetric(t *testing.T) {
+	const (
+		tol = 1e-12
+		k   = 45.
+	)
+	// Ring of rectangular cross section between radii r0 and r1.
+	const r0, r1, h = 20., 30., 1.
+	nodes := []r3.Vec{{X: r0}, {X: r1}, {X: r1, Y: h}, {X: r0, Y: h}}
+	elemT := elements.Quad4{NodeDofs: fem.DofPosX}
+	ga := fem.NewGeneralAssembler(nodes, elemT.Dofs())
+	err := ga.AddIsoparametric(elemT, solids.IsotropicConductivity{K: k}.Axisymmetric(), 1, func(int) ([]int, r3.Vec, r3.Vec) {
+		return []int{0, 1, 2, 3}, r3.Vec{}, r3.Vec{}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	K := ga.Ksolid()
+	// A uniform temperature produces no heat flow.
+	q := lap.NewDenseVector(4, nil)
+	q.MulVec(K, lap.NewDenseVector(4, []float64{1, 1, 1, 1}))
+	for i := 0; i < 4; i++ {
+		if math.Abs(q.AtVec(i)) > tol*k {
+			t.Errorf("node %d: expected no heat flow for uniform temperature, got %g", i, q.AtVec(i))
+		}
+	}
+	// Axial temperature gradient conducts k·π·(r1²-r0²)/h through the ring per 2π radians.
+	q.MulVec(K, lap.NewDenseVector(4, []float64{0, 0, 1, 1}))
+	got := 2 * math.Pi * (q.AtVec(2) + q.AtVec(3))
+	want := k * math.Pi * (r1*r1 - r0*r0) / h
+	if math.Abs(got-want) > 1e-9*want {
+		t.Errorf("want axial heat flow %g, got %g", want, got)
+	}
+}
@@ -3,6 +3,7 @@ package solids
 import (
 	"math"
 
+	"github.com/soypat/go-fem"
 	"gonum.org/v1/gonum/floats/scalar"
 	"gonum.org/v1/gonum/mat"
 )
@@ -23,6 +24,10 @@ type TransverselyIsotropic struct {
 	// PoissonXY represents the quotient between deformations in directions Y and Z
 	// when stress is applied in direction Y. Is positive when material contracts in transversal direction.
 	PoissonYZ float64
+	// Longitudinal coefficient of linear thermal expansion (X direction). Found as alpha_1 in literature.
+	ThermalExpansionX float64
+	// Transversal coefficient of linear thermal expansion (YZ plane). Found as alpha_2 in literature.
+	ThermalExpansionYZ float64
 }
 
 // return value will be concrete in future.
@@ -62,3 +67,29 @@ func (m TransverselyIsotropic) Constitutive() (mat.Matrix, error) {
 	}
 	return mat.NewDense(6, 6, data), nil
 }
+
+// Solid3D returns the constituter of the material for 3D solid elements with
+// the strain layout [XX, YY, ZZ, XY, YZ, XZ]. The fibres follow the X axis
+// of the material axes, which may be oriented for each element.
+func (m TransverselyIsotropic) Solid3D() fem.IsoConstituter {
+	var isoc isoconstituter
+	C, err := m.Constitutive()
+	if err != nil {
+		isoc.err = err
+	} else {
+		// Constitutive's shear terms are ordered as [YZ, XZ, XY].
+		perm := [6]int{0, 1, 2, 5, 3, 4}
+		Cp := mat.NewDense(6, 6, nil)
+		for i, pi := range perm {
+			for j, pj := range perm {
+				Cp.Set(i, j, C.At(pi, pj))
+			}
+		}
+		isoc.C = Cp
+	}
+	isoc.strain = SetStrainDisplacementMatrixXYZ
+	isoc.layout = layout3D
+	ax, at := m.ThermalExpansionX, m.ThermalExpansionYZ
+	isoc.eps0 = []float64{ax, at, at, 0, 0, 0}
+	return isoc
+}
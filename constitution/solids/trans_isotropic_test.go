@@ -0,0 +1,80 @@
+package solids_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/soypat/go-fem"
+	"github.com/soypat/go-fem/constitution/solids"
+	"github.com/soypat/go-fem/elements"
+	"github.com/soypat/lap"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+// A uniform temperature change on an unrestrained body of fibres oriented
+// off the model axes produces the free thermal strain and no stress.
+func TestTransverselyIsotropicThermalExpansion(t *testing.T) {
+	const (
+		tol     = 1e-9
+		alphaX  = -0.5e-6
+		alphaYZ = 30e-6
+		deltaT  = 100.
+	)
+	material := solids.TransverselyIsotropic{Ex: 235e9, Exy: 14e9, Gxy: 28e9, PoissonXY: 0.2, PoissonYZ: 0.25,
+		ThermalExpansionX: alphaX, ThermalExpansionYZ: alphaYZ}
+	c := material.Solid3D().(fem.ThermalIsoConstituter)
+	fibre := r3.Unit(r3.Vec{X: 1, Y: 1, Z: 1})
+	// Free expansion is alphaX along the fibres and alphaYZ across them.
+	u := func(p r3.Vec) r3.Vec {
+		along := r3.Dot(fibre, p)
+		return r3.Add(r3.Scale(alphaYZ*deltaT, p), r3.Scale((alphaX-alphaYZ)*deltaT*along, fibre))
+	}
+	elemT := elements.Hexa8{}
+	nodes := elemT.IsoparametricNodes()
+	elem := make([]int, len(nodes))
+	for i := range nodes {
+		nodes[i] = r3.Add(r3.Vec{X: 1, Y: 2, Z: 3}, r3.Vec{X: nodes[i].X + 0.2*nodes[i].Y, Y: nodes[i].Y, Z: nodes[i].Z})
+		elem[i] = i
+	}
+	getElement := func(i int) ([]int, r3.Vec, r3.Vec) { return elem, fibre, r3.Vec{X: 1} }
+	ga := fem.NewGeneralAssembler(nodes, elemT.Dofs())
+	err := ga.AddIsoparametric(elemT, c, 1, getElement)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := lap.NewDenseVector(ga.TotalDofs(), nil)
+	dT := func(int, []int, []float64) float64 { return deltaT }
+	err = ga.AddIsoparametricThermalLoad(f, elemT, c, 1, getElement, dT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	U := lap.NewDenseVector(ga.TotalDofs(), nil)
+	for i, node := range nodes {
+		ui := u(node)
+		U.SetVec(3*i, ui.X)
+		U.SetVec(3*i+1, ui.Y)
+		U.SetVec(3*i+2, ui.Z)
+	}
+	Ku := lap.NewDenseVector(ga.TotalDofs(), nil)
+	Ku.MulVec(ga.Ksolid(), U)
+	fmax := 0.0
+	for i := 0; i < f.Len(); i++ {
+		fmax = math.Max(fmax, math.Abs(f.AtVec(i)))
+	}
+	for i := 0; i < f.Len(); i++ {
+		if math.Abs(Ku.AtVec(i)-f.AtVec(i)) > tol*fmax {
+			t.Errorf("dof %d: thermal load %g does not match free expansion internal force %g", i, f.AtVec(i), Ku.AtVec(i))
+		}
+	}
+	err = ga.IsoparametricThermalStresses(U, elemT, c, 1, getElement, dT, func(iele int, stresses []float64) {
+		for i := range stresses {
+			if math.Abs(stresses[i]) > tol*material.Ex*alphaYZ*deltaT {
+				t.Errorf("expected no stress for free expansion, got %g", stresses[i])
+				break
+			}
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
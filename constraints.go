@@ -0,0 +1,303 @@
+package fem
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+
+	"github.com/soypat/lap"
+)
+
+// NewConstraints returns an empty set of constraints of a model
+// with the given modelDofs and number of nodes.
+func NewConstraints(modelDofs DofsFlag, numNodes int) *Constraints {
+	return &Constraints{
+		modelDofs: modelDofs,
+		totalDofs: numNodes * modelDofs.Count(),
+	}
+}
+
+// Constraints holds linear multi-point constraints between the
+// model's global dofs of the form
+//
+//	Σ aᵢ·u[dofᵢ] = b
+//
+// The constraints are enforced with Lagrange multipliers by LinearStaticLagrange or with
+// penalties by GeneralAssembler.AddPenaltyConstraints. The Lagrange multipliers λ, one per constraint,
+// are the forces required to satisfy the constraints. Written in matrix form as C·u = b,
+// the constraint forces acting on the model are -Cᵀ·λ.
+type Constraints struct {
+	modelDofs DofsFlag
+	totalDofs int
+	rows      []constraint
+}
+
+type constraint struct {
+	dofs  []int
+	coefs []float64
+	value float64
+}
+
+// Add adds the constraint Σ coefs[i]·u[dofs[i]] = value where dofs are the model's
+// global dofs. Use Dof to obtain the global dof of a node. Add panics if the lengths of
+// dofs and coefs do not match or a dof is out of range.
+func (c *Constraints) Add(dofs []int, coefs []float64, value float64) {
+	if len(dofs) != len(coefs) || len(dofs) == 0 {
+		panic("dofs and coefs must be of equal non-zero length")
+	}
+	for _, dof := range dofs {
+		if dof < 0 || dof >= c.totalDofs {
+			panic(fmt.Sprintf("dof %d out of range [0, %d)", dof, c.totalDofs))
+		}
+	}
+	c.rows = append(c.rows, constraint{
+		dofs:  append([]int{}, dofs...),
+		coefs: append([]float64{}, coefs...),
+		value: value,
+	})
+}
+
+// Tie constrains the given dofs of nodes a and b to be equal, u[a] = u[b].
+// It adds one constraint per dof in dofs that is in the model.
+func (c *Constraints) Tie(a, b int, dofs DofsFlag) {
+	for i := 0; i < maxDofsPerNode; i++ {
+		dof := DofsFlag(1 << i)
+		if !dofs.Has(dof) || !c.modelDofs.Has(dof) {
+			continue
+		}
+		c.Add([]int{c.Dof(a, dof), c.Dof(b, dof)}, []float64{1, -1}, 0)
+	}
+}
+
+// Dof returns the model's global dof corresponding to a single dof of a node.
+// It panics if dof is not a single dof of the model.
+func (c *Constraints) Dof(node int, dof DofsFlag) int {
+	if dof.Count() != 1 || !c.modelDofs.Has(dof) {
+		panic(fmt.Sprintf("dof %s is not a single dof of model dofs %s", dof, c.modelDofs))
+	}
+	// Number of model dofs before dof.
+	j := bits.OnesCount16(uint16(c.modelDofs & (dof - 1)))
+	return node*c.modelDofs.Count() + j
+}
+
+// Len returns the number of constraints.
+func (c *Constraints) Len() int { return len(c.rows) }
+
+// TotalDofs returns the total number of dofs in the model.
+func (c *Constraints) TotalDofs() int { return c.totalDofs }
+
+// Forces returns the forces -Cᵀ·λ the constraints exert on the model's dofs
+// given the Lagrange multipliers λ.
+func (c *Constraints) Forces(multipliers lap.Vector) *lap.DenseV {
+	if multipliers.Len() != len(c.rows) {
+		panic("multipliers length does not match number of constraints")
+	}
+	f := lap.NewDenseVector(c.totalDofs, nil)
+	for k, row := range c.rows {
+		lambda := multipliers.AtVec(k)
+		for i, dof := range row.dofs {
+			f.SetVec(dof, f.AtVec(dof)-row.coefs[i]*lambda)
+		}
+	}
+	return f
+}
+
+// Augmented returns the saddle point system of the linear static problem
+// constrained by c
+//
+//	[ K  Cᵀ ] [ u ]   [ F ]
+//	[ C  0  ]·[ λ ] = [ b ]
+//
+// where the Lagrange multipliers λ are ordered after the model's dofs.
+// The augmented matrix is symmetric indefinite.
+func (c *Constraints) Augmented(K lap.Matrix, loads lap.Vector) (*lap.Sparse, *lap.DenseV) {
+	n := c.totalDofs
+	if r, _ := K.Dims(); r != n || loads.Len() != n {
+		panic("stiffness matrix and loads dimensions do not match model dofs")
+	}
+	m := len(c.rows)
+	A := lap.NewSparse(n+m, n+m)
+	doNonZero(K, A.Set)
+	b := lap.NewDenseVector(n+m, nil)
+	for i := 0; i < n; i++ {
+		b.SetVec(i, loads.AtVec(i))
+	}
+	for k, row := range c.rows {
+		for i, dof := range row.dofs {
+			A.Set(n+k, dof, A.At(n+k, dof)+row.coefs[i])
+			A.Set(dof, n+k, A.At(dof, n+k)+row.coefs[i])
+		}
+		b.SetVec(n+k, row.value)
+	}
+	return A, b
+}
+
+// LinearStaticLagrange solves the linear static problem K·u = F + R - Cᵀ·λ subject to the
+// constraints C·u = b with Lagrange multipliers λ, where R are the reaction forces at the fixed dofs
+// of fix. Terms of the constraints corresponding to fixed dofs take their prescribed values.
+//
+// It returns the full displacement vector, the reactions at the fixed dofs and the Lagrange multipliers
+// of the constraints. The constraint forces are obtained with Constraints.Forces. The constraints must be
+// linearly independent and the model must be restrained once the constraints are enforced.
+func LinearStaticLagrange(K lap.Matrix, loads lap.Vector, fix Fixity, cons *Constraints) (displacements, reactions, multipliers *lap.DenseV, err error) {
+	if cons.TotalDofs() != fix.TotalDofs() {
+		return nil, nil, nil, fmt.Errorf("constraints total dofs %d does not match fixity total dofs %d", cons.TotalDofs(), fix.TotalDofs())
+	}
+	var part staticPartition
+	Kff, err := part.reset(K, fix)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	rhs, err := part.rhs(loads)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	nf, m := Kff.n, len(cons.rows)
+	// Constraints restricted to the free dofs: Cf·uf = b - Cp·up.
+	cf := make([]constraint, m)
+	var maxRowNorm float64
+	for k, row := range cons.rows {
+		cf[k].value = row.value
+		rowNorm := 0.0
+		for i, dof := range row.dofs {
+			if fi := part.freeIdx[dof]; fi >= 0 {
+				cf[k].dofs = append(cf[k].dofs, fi)
+				cf[k].coefs = append(cf[k].coefs, row.coefs[i])
+				rowNorm += row.coefs[i] * row.coefs[i]
+			} else {
+				cf[k].value -= row.coefs[i] * part.up.AtVec(dof)
+			}
+		}
+		if len(cf[k].dofs) == 0 {
+			return nil, nil, nil, fmt.Errorf("constraint %d only involves fixed dofs", k)
+		}
+		maxRowNorm = math.Max(maxRowNorm, rowNorm)
+	}
+	// The augmented Lagrangian term ρ·Cᵀ·(C·u - b) = 0 is added to the stiffness equations so that
+	// the stiffness block is positive definite for models restrained only by the constraints.
+	var maxDiag float64
+	for _, d := range Kff.diagonal() {
+		maxDiag = math.Max(maxDiag, math.Abs(d))
+	}
+	rho := 0.0
+	if maxRowNorm > 0 {
+		rho = maxDiag / maxRowNorm
+	}
+	A := newCSRBuilder(nf + m)
+	Kff.DoNonZero(A.add)
+	x := make([]float64, nf+m)
+	copy(x, rhs)
+	for k, row := range cf {
+		for i, fi := range row.dofs {
+			a := row.coefs[i]
+			A.add(nf+k, fi, a)
+			A.add(fi, nf+k, a)
+			x[fi] += rho * a * row.value
+			for j, fj := range row.dofs {
+				A.add(fi, fj, rho*a*row.coefs[j])
+			}
+		}
+		x[nf+k] = row.value
+	}
+	// Multipliers are eliminated last so that pivots are taken from the positive definite block.
+	chol := SparseCholesky{deferred: m}
+	Ac := A.compress()
+	err = chol.analyze(Ac)
+	if err == nil {
+		err = chol.factorize(Ac)
+	}
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("factorizing constrained stiffness matrix, check model fixity and constraint independence: %w", err)
+	}
+	chol.solve(x)
+	multipliers = lap.NewDenseVector(m, append([]float64{}, x[nf:]...))
+	displacements, reactions = part.results(loads, x[:nf])
+	// The reactions at the fixed dofs balance the constraint forces acting on them.
+	forces := cons.Forces(multipliers)
+	for i := 0; i < part.n; i++ {
+		if part.freeIdx[i] < 0 {
+			reactions.SetVec(i, reactions.AtVec(i)-forces.AtVec(i))
+		}
+	}
+	return displacements, reactions, multipliers, nil
+}
+
+// DefaultPenaltyScale is the factor applied to the largest diagonal entry of the
+// stiffness matrix to obtain the penalty factor of AddPenaltyConstraints when none is given.
+// The constraint violation is roughly inversely proportional to it.
+const DefaultPenaltyScale = 1e6
+
+// AddPenaltyConstraints enforces the constraints of cons with the penalty method by adding
+// the stiffness α·Cᵀ·C to the model's stiffness matrix and the loads α·Cᵀ·b to loads, where
+// α is the penalty factor. Unlike Lagrange multipliers, the stiffness matrix remains symmetric
+// positive definite so it can be solved with LinearStatic or PCG. Constraints with non-zero values
+// require loads, which may be nil otherwise.
+//
+// If penalty is zero the penalty factor is DefaultPenaltyScale times the largest diagonal
+// entry of the stiffness matrix. The penalty factor used is returned. The constraints are only
+// approximately satisfied, see Constraints.Residuals.
+func (ga *GeneralAssembler) AddPenaltyConstraints(loads *lap.DenseV, cons *Constraints, penalty float64) (float64, error) {
+	n := ga.TotalDofs()
+	switch {
+	case cons.TotalDofs() != n:
+		return 0, fmt.Errorf("constraints total dofs %d does not match model total dofs %d", cons.TotalDofs(), n)
+	case loads != nil && loads.Len() != n:
+		return 0, fmt.Errorf("loads vector length %d does not match model total dofs %d", loads.Len(), n)
+	case penalty < 0:
+		return 0, errors.New("penalty factor must be positive")
+	case cons.Len() == 0:
+		return penalty, nil
+	}
+	if penalty == 0 {
+		var maxDiag float64
+		for i := 0; i < n; i++ {
+			maxDiag = math.Max(maxDiag, math.Abs(ga.ksolid.At(i, i)))
+		}
+		if maxDiag == 0 {
+			return 0, errors.New("stiffness matrix is empty, unable to calculate penalty factor")
+		}
+		penalty = DefaultPenaltyScale * maxDiag
+	}
+	nvals := 0
+	for _, row := range cons.rows {
+		if row.value != 0 && loads == nil {
+			return 0, errors.New("nil loads for constraint with non-zero value")
+		}
+		nvals += len(row.dofs) * len(row.dofs)
+	}
+	spac := lap.NewSparseAccum(nvals)
+	offset := 0
+	for _, row := range cons.rows {
+		for i, di := range row.dofs {
+			a := penalty * row.coefs[i]
+			for j, dj := range row.dofs {
+				spac.Set(offset, di, dj, a*row.coefs[j])
+				offset++
+			}
+			if row.value != 0 {
+				loads.SetVec(di, loads.AtVec(di)+a*row.value)
+			}
+		}
+	}
+	ga.ksolid.Accumulate(spac)
+	return penalty, nil
+}
+
+// Residuals returns the violation C·u - b of each constraint given the model's displacements.
+// For constraints enforced with penalties the Lagrange multipliers are approximated
+// by the residuals times the penalty factor.
+func (c *Constraints) Residuals(displacements lap.Vector) *lap.DenseV {
+	if displacements.Len() != c.totalDofs {
+		panic("displacements length does not match model total dofs")
+	}
+	r := lap.NewDenseVector(len(c.rows), nil)
+	for k, row := range c.rows {
+		sum := -row.value
+		for i, dof := range row.dofs {
+			sum += row.coefs[i] * displacements.AtVec(dof)
+		}
+		r.SetVec(k, sum)
+	}
+	return r
+}
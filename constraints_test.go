@@ -0,0 +1,220 @@
+package fem_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/soypat/go-fem"
+	"github.com/soypat/go-fem/constitution/solids"
+	"github.com/soypat/go-fem/elements"
+	"github.com/soypat/lap"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+func TestLinearStaticLagrangeTie(t *testing.T) {
+	const tol = 1e-8
+	ys := []float64{0, 0.5, 1}
+	// Monolithic plate clamped on its left edge and pulled on its right edge.
+	nodes, elems := rectangularMesh([]float64{0, 1, 2, 3, 4}, ys, false)
+	want := solvePlate(t, nodes, elems, nil)
+	split, splitElems, cons := splitPlate(ys)
+	if cons.Len() != 2*len(ys) {
+		t.Fatalf("expected %d constraints, got %d", 2*len(ys), cons.Len())
+	}
+	got := solvePlate(t, split, splitElems, cons)
+	for i, a := range split {
+		for j, b := range nodes {
+			if a != b {
+				continue
+			}
+			for d := 0; d < 2; d++ {
+				if math.Abs(got.AtVec(2*i+d)-want.AtVec(2*j+d)) > tol*math.Abs(want.AtVec(2*j)) {
+					t.Errorf("node %v dof %d: want %g, got %g", a, d, want.AtVec(2*j+d), got.AtVec(2*i+d))
+				}
+			}
+		}
+	}
+}
+
+func TestLinearStaticLagrangePrescribed(t *testing.T) {
+	const (
+		tol  = 1e-9
+		disp = 0.01
+	)
+	nodes, elems := rectangularMesh([]float64{0, 1, 2}, []float64{0, 1}, false)
+	elemT := elements.Quad4{}
+	ga := fem.NewGeneralAssembler(nodes, elemT.Dofs())
+	err := ga.AddIsoparametric(elemT, solids.Isotropic{E: 1000, Poisson: 0.3}.PlaneStess(), len(elems), func(i int) ([]int, r3.Vec, r3.Vec) {
+		return elems[i], r3.Vec{}, r3.Vec{}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fix := fem.NewFixity(elemT.Dofs(), len(nodes))
+	cons := fem.NewConstraints(elemT.Dofs(), len(nodes))
+	prescribed := fem.NewFixity(elemT.Dofs(), len(nodes))
+	for i, node := range nodes {
+		switch node.X {
+		case 0:
+			fix.Fix(i, elemT.Dofs())
+			prescribed.Fix(i, elemT.Dofs())
+		case 2:
+			// Constraint involving a fixed dof is equivalent to a prescribed displacement.
+			cons.Add([]int{cons.Dof(i, fem.DofPosX), cons.Dof(0, fem.DofPosX)}, []float64{1, 5}, disp)
+			prescribed.Prescribe(i, fem.DofPosX, disp)
+		}
+	}
+	K := ga.Ksolid()
+	loads := lap.NewDenseVector(ga.TotalDofs(), nil)
+	want, wantR, err := fem.LinearStatic(K, loads, prescribed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, R, lambda, err := fem.LinearStaticLagrange(K, loads, fix, cons)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forces := cons.Forces(lambda)
+	for i := 0; i < u.Len(); i++ {
+		if math.Abs(u.AtVec(i)-want.AtVec(i)) > tol {
+			t.Errorf("dof %d: want displacement %g, got %g", i, want.AtVec(i), u.AtVec(i))
+		}
+		// Reactions of prescribed displacements are the constraint forces.
+		if math.Abs(R.AtVec(i)+forces.AtVec(i)-wantR.AtVec(i)) > tol*10 {
+			t.Errorf("dof %d: want reaction %g, got %g", i, wantR.AtVec(i), R.AtVec(i)+forces.AtVec(i))
+		}
+	}
+}
+
+func TestAddPenaltyConstraints(t *testing.T) {
+	const tol = 1e-5
+	split, splitElems, cons := splitPlate([]float64{0, 0.5, 1})
+	// Top right corner follows an inclined roller at 45 degrees with an offset.
+	corner := len(split) - 1
+	cons.Add([]int{cons.Dof(corner, fem.DofPosX), cons.Dof(corner, fem.DofPosY)}, []float64{1, -1}, 0.001)
+	elemT := elements.Quad4{}
+	ga := fem.NewGeneralAssembler(split, elemT.Dofs())
+	err := ga.AddIsoparametric(elemT, solids.Isotropic{E: 1000, Poisson: 0.3}.PlaneStess(), len(splitElems), func(i int) ([]int, r3.Vec, r3.Vec) {
+		return splitElems[i], r3.Vec{}, r3.Vec{}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fix := fem.NewFixity(elemT.Dofs(), len(split))
+	loads := lap.NewDenseVector(ga.TotalDofs(), nil)
+	for i, node := range split {
+		switch node.X {
+		case 0:
+			fix.Fix(i, elemT.Dofs())
+		case 4:
+			loads.SetVec(2*i, 1)
+		}
+	}
+	// Reference solution with Lagrange multipliers.
+	want, _, wantLambda, err := fem.LinearStaticLagrange(ga.Ksolid(), loads, fix, cons)
+	if err != nil {
+		t.Fatal(err)
+	}
+	penalty, err := ga.AddPenaltyConstraints(loads, cons, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pcg := range []*fem.PCG{nil, {Tolerance: 1e-14, Preconditioner: &fem.IncompleteCholesky{}}} {
+		var u *lap.DenseV
+		if pcg == nil {
+			u, _, err = fem.LinearStatic(ga.Ksolid(), loads, fix)
+		} else {
+			u, _, err = pcg.LinearStatic(ga.Ksolid(), loads, fix)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		var maxDisp float64
+		for i := 0; i < want.Len(); i++ {
+			maxDisp = math.Max(maxDisp, math.Abs(want.AtVec(i)))
+		}
+		for i := 0; i < u.Len(); i++ {
+			if math.Abs(u.AtVec(i)-want.AtVec(i)) > tol*maxDisp {
+				t.Errorf("dof %d: want displacement %g, got %g", i, want.AtVec(i), u.AtVec(i))
+			}
+		}
+		residuals := cons.Residuals(u)
+		for k := 0; k < residuals.Len(); k++ {
+			r := residuals.AtVec(k)
+			if math.Abs(r) > tol*maxDisp {
+				t.Errorf("constraint %d violated by %g", k, r)
+			}
+			if lambda := penalty * r; math.Abs(lambda-wantLambda.AtVec(k)) > 1e-3 {
+				t.Errorf("constraint %d: want multiplier %g, got %g", k, wantLambda.AtVec(k), lambda)
+			}
+		}
+	}
+}
+
+// splitPlate returns a plate of 4 units of length split in two meshes with coincident
+// nodes at x=2 that are tied by the returned constraints.
+func splitPlate(ys []float64) (nodes []r3.Vec, elems [][]int, cons *fem.Constraints) {
+	left, leftElems := rectangularMesh([]float64{0, 1, 2}, ys, false)
+	right, rightElems := rectangularMesh([]float64{2, 3, 4}, ys, false)
+	for _, e := range rightElems {
+		for i := range e {
+			e[i] += len(left)
+		}
+	}
+	nodes = append(left, right...)
+	elems = append(leftElems, rightElems...)
+	cons = fem.NewConstraints(fem.DofPosX|fem.DofPosY, len(nodes))
+	for i, a := range left {
+		for j, b := range right {
+			if a == b {
+				cons.Tie(i, len(left)+j, fem.DofPos)
+			}
+		}
+	}
+	return nodes, elems, cons
+}
+
+// solvePlate solves a plane stress plate clamped at x=0 with a unit load
+// in X distributed on the nodes of x=4, with optional constraints.
+func solvePlate(t *testing.T, nodes []r3.Vec, elems [][]int, cons *fem.Constraints) *lap.DenseV {
+	t.Helper()
+	elemT := elements.Quad4{}
+	ga := fem.NewGeneralAssembler(nodes, elemT.Dofs())
+	err := ga.AddIsoparametric(elemT, solids.Isotropic{E: 1000, Poisson: 0.3}.PlaneStess(), len(elems), func(i int) ([]int, r3.Vec, r3.Vec) {
+		return elems[i], r3.Vec{}, r3.Vec{}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fix := fem.NewFixity(elemT.Dofs(), len(nodes))
+	loads := lap.NewDenseVector(ga.TotalDofs(), nil)
+	for i, node := range nodes {
+		switch node.X {
+		case 0:
+			fix.Fix(i, elemT.Dofs())
+		case 4:
+			loads.SetVec(2*i, 1)
+		}
+	}
+	if cons == nil {
+		u, _, err := fem.LinearStatic(ga.Ksolid(), loads, fix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return u
+	}
+	u, R, lambda, err := fem.LinearStaticLagrange(ga.Ksolid(), loads, fix, cons)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// K·u = F + R - Cᵀ·λ
+	forces := cons.Forces(lambda)
+	Ku := lap.NewDenseVector(u.Len(), nil)
+	Ku.MulVec(ga.Ksolid(), u)
+	for i := 0; i < u.Len(); i++ {
+		if residual := Ku.AtVec(i) - loads.AtVec(i) - R.AtVec(i) - forces.AtVec(i); math.Abs(residual) > 1e-9 {
+			t.Errorf("dof %d: equilibrium residual %g", i, residual)
+		}
+	}
+	return u
+}
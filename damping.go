@@ -0,0 +1,124 @@
+package fem
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/soypat/lap"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+// RayleighCoefficients returns the coefficients α and β of the Rayleigh damping matrix C = α·M + β·K
+// whose damping ratio ζ(ω) = α/(2ω) + β·ω/2 equals zeta1 at angular frequency omega1 and zeta2 at omega2.
+// Frequencies are in radians per unit time like Modes.AngularFrequency. Modes between the two frequencies
+// are damped less and modes outside of them more than the target ratios when these are equal.
+// Target ratios that differ greatly yield a negative α or β, which gives negative damping
+// ratios to modes far below or above the two frequencies.
+func RayleighCoefficients(omega1, zeta1, omega2, zeta2 float64) (alpha, beta float64, err error) {
+	switch {
+	case !(omega1 > 0) || !(omega2 > 0) || math.IsInf(omega1, 0) || math.IsInf(omega2, 0):
+		return 0, 0, fmt.Errorf("frequencies must be positive finite numbers, got %g and %g", omega1, omega2)
+	case omega1 == omega2:
+		return 0, 0, errors.New("frequencies must be distinct")
+	case zeta1 < 0 || zeta2 < 0 || math.IsNaN(zeta1) || math.IsNaN(zeta2):
+		return 0, 0, fmt.Errorf("damping ratios must be non-negative, got %g and %g", zeta1, zeta2)
+	}
+	// Solution of the 2x2 system ζᵢ = α/(2ωᵢ) + β·ωᵢ/2.
+	den := omega2*omega2 - omega1*omega1
+	alpha = 2 * omega1 * omega2 * (omega2*zeta1 - omega1*zeta2) / den
+	beta = 2 * (omega2*zeta2 - omega1*zeta1) / den
+	return alpha, beta, nil
+}
+
+// RayleighDamping returns the Rayleigh damping matrix C = α·M + β·K of a model with mass matrix M
+// and stiffness matrix K, usually those returned by GeneralAssembler.Msolid and GeneralAssembler.Ksolid.
+// Use AddIsoparametricRayleighDamping to damp groups of elements with different coefficients.
+func RayleighDamping(M, K lap.Matrix, alpha, beta float64) (*lap.Sparse, error) {
+	n, c := K.Dims()
+	if r, cm := M.Dims(); n != c || r != n || cm != n {
+		return nil, fmt.Errorf("mass matrix %dx%d and stiffness matrix %dx%d must be square of equal dimensions", r, cm, n, c)
+	}
+	C := lap.NewSparse(n, n)
+	addScaled(C, alpha, M)
+	addScaled(C, beta, K)
+	return C, nil
+}
+
+// ModalDamping returns the damping matrix C = Σ 2·ζᵢ·ωᵢ·(M·φᵢ)·(M·φᵢ)ᵀ which damps each of the
+// mass normalised modes φᵢ of a model with mass matrix M with ratio ζᵢ = ratios[i] and leaves
+// other modes undamped. The modes are usually those returned by Modal. Unlike Rayleigh damping
+// the matrix is dense over the free dofs, so it is only suited to small models.
+func ModalDamping(M lap.Matrix, modes *Modes, ratios []float64) (*lap.Sparse, error) {
+	if len(ratios) != modes.Len() {
+		return nil, fmt.Errorf("number of damping ratios %d does not match number of modes %d", len(ratios), modes.Len())
+	}
+	n, c := M.Dims()
+	if n != c {
+		return nil, fmt.Errorf("expected square mass matrix, got %dx%d", n, c)
+	}
+	C := lap.NewSparse(n, n)
+	Mphi := lap.NewDenseVector(n, nil)
+	for i, zeta := range ratios {
+		if zeta < 0 || math.IsNaN(zeta) {
+			return nil, fmt.Errorf("damping ratio of mode %d must be non-negative, got %g", i, zeta)
+		} else if modes.Shapes[i].Len() != n {
+			return nil, fmt.Errorf("mode %d shape length %d does not match mass matrix dimension %d", i, modes.Shapes[i].Len(), n)
+		}
+		Mphi.MulVec(M, modes.Shapes[i])
+		var nz []int
+		for j := 0; j < n; j++ {
+			if Mphi.AtVec(j) != 0 {
+				nz = append(nz, j)
+			}
+		}
+		f := 2 * zeta * modes.AngularFrequency(i)
+		for _, j := range nz {
+			for _, k := range nz {
+				C.Set(j, k, C.At(j, k)+f*Mphi.AtVec(j)*Mphi.AtVec(k))
+			}
+		}
+	}
+	return C, nil
+}
+
+// AddIsoparametricRayleighDamping adds the Rayleigh damping matrix α·Me + β·Ke of a group of isoparametric
+// elements to the model's damping matrix, which is returned by Csolid, where Me and Ke are the consistent
+// mass and stiffness matrices of the elements as added by AddIsoparametricMass and AddIsoparametric.
+// Calling it for each group of elements of the same material with its own coefficients yields a damping
+// matrix with per material damping. The stiffness and mass matrices of the model are not modified.
+// The coefficients may be negative, such as those returned by RayleighCoefficients for very different
+// target damping ratios.
+func (ga *GeneralAssembler) AddIsoparametricRayleighDamping(elemT Isoparametric, c IsoConstituter, density, alpha, beta float64, Nelem int, getElement func(i int) (elem []int, xC, yC r3.Vec)) error {
+	if math.IsNaN(alpha) || math.IsNaN(beta) || math.IsInf(alpha, 0) || math.IsInf(beta, 0) {
+		return fmt.Errorf("rayleigh coefficients must be finite numbers, got α=%g β=%g", alpha, beta)
+	}
+	// The element matrices are assembled apart and then scaled into the damping matrix.
+	group := NewGeneralAssembler(ga.nodes, ga.dofs)
+	group.workers = ga.workers
+	if beta != 0 {
+		err := group.AddIsoparametric(elemT, c, Nelem, getElement)
+		if err != nil {
+			return err
+		}
+	}
+	if alpha != 0 {
+		err := group.AddIsoparametricMass(elemT, c, density, Nelem, getElement)
+		if err != nil {
+			return err
+		}
+	}
+	addScaled(&ga.csolid, alpha, &group.msolid)
+	addScaled(&ga.csolid, beta, &group.ksolid)
+	return nil
+}
+
+// addScaled adds f·A to dst.
+func addScaled(dst *lap.Sparse, f float64, A lap.Matrix) {
+	if f == 0 {
+		return
+	}
+	doNonZero(A, func(i, j int, v float64) {
+		dst.Set(i, j, dst.At(i, j)+f*v)
+	})
+}
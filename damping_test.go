@@ -0,0 +1,146 @@
+package fem_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/soypat/go-fem"
+	"github.com/soypat/go-fem/constitution/solids"
+	"github.com/soypat/go-fem/elements"
+	"github.com/soypat/lap"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+func TestRayleighDamping(t *testing.T) {
+	const tol = 1e-8
+	ga, fix := vibratingPlate(t, []float64{0, 0.25, 0.5, 0.75, 1}, []float64{0, 0.1})
+	K, M := ga.Ksolid(), ga.Msolid()
+	modes, err := fem.Modal(K, M, fix, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Target 2% damping at the first mode and 5% at the fourth.
+	w1, w4 := modes.AngularFrequency(0), modes.AngularFrequency(3)
+	alpha, beta, err := fem.RayleighCoefficients(w1, 0.02, w4, 0.05)
+	if err != nil {
+		t.Fatal(err)
+	}
+	C, err := fem.RayleighDamping(M, K, alpha, beta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Damping ratio of each mode is φᵀ·C·φ/(2ω).
+	for i := 0; i < modes.Len(); i++ {
+		w := modes.AngularFrequency(i)
+		Cphi := lap.NewDenseVector(ga.TotalDofs(), nil)
+		Cphi.MulVec(C, modes.Shapes[i])
+		got := lap.Dot(modes.Shapes[i], Cphi) / (2 * w)
+		want := alpha/(2*w) + beta*w/2
+		switch i {
+		case 0:
+			want = 0.02
+		case 3:
+			want = 0.05
+		}
+		if math.Abs(got-want) > tol {
+			t.Errorf("mode %d: want damping ratio %g, got %g", i, want, got)
+		}
+	}
+	_, _, err = fem.RayleighCoefficients(w1, 0.02, w1, 0.05)
+	if err == nil {
+		t.Error("expected error for equal frequencies")
+	}
+}
+
+func TestAddIsoparametricRayleighDamping(t *testing.T) {
+	const tol = 1e-9
+	// Plate of two materials with different damping.
+	nodes, elems := rectangularMesh([]float64{0, 0.5, 1, 1.5, 2}, []float64{0, 0.5, 1}, false)
+	elemT := elements.Quad4{}
+	var groups [2][][]int
+	for _, elem := range elems {
+		g := 0
+		if nodes[elem[0]].X >= 1 {
+			g = 1
+		}
+		groups[g] = append(groups[g], elem)
+	}
+	materials := [2]solids.Isotropic{{E: 200e9, Poisson: 0.3}, {E: 70e9, Poisson: 0.33}}
+	densities := [2]float64{7800, 2700}
+	coefs := [2][2]float64{{2, 1e-5}}
+	// Very different target damping ratios yield a negative α.
+	alpha, beta, err := fem.RayleighCoefficients(1, 0.01, 10, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	} else if alpha >= 0 {
+		t.Fatalf("want negative α, got %g", alpha)
+	}
+	coefs[1] = [2]float64{alpha, beta}
+	ga := fem.NewGeneralAssembler(nodes, elemT.Dofs())
+	want := lap.NewSparse(ga.TotalDofs(), ga.TotalDofs())
+	for g, group := range groups {
+		c := materials[g].PlaneStess()
+		getElement := func(i int) ([]int, r3.Vec, r3.Vec) { return group[i], r3.Vec{}, r3.Vec{} }
+		err = ga.AddIsoparametricRayleighDamping(elemT, c, densities[g], coefs[g][0], coefs[g][1], len(group), getElement)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Damping of the group alone.
+		single := fem.NewGeneralAssembler(nodes, elemT.Dofs())
+		err = single.AddIsoparametric(elemT, c, len(group), getElement)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = single.AddIsoparametricMass(elemT, c, densities[g], len(group), getElement)
+		if err != nil {
+			t.Fatal(err)
+		}
+		Cg, err := fem.RayleighDamping(single.Msolid(), single.Ksolid(), coefs[g][0], coefs[g][1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		Cg.DoNonZero(func(i, j int, v float64) { want.Set(i, j, want.At(i, j)+v) })
+	}
+	if ga.Ksolid().CountNonZero() != 0 || ga.Msolid().CountNonZero() != 0 {
+		t.Error("stiffness and mass matrices must not be modified")
+	}
+	got := ga.Csolid()
+	for i := 0; i < ga.TotalDofs(); i++ {
+		for j := 0; j < ga.TotalDofs(); j++ {
+			if math.Abs(got.At(i, j)-want.At(i, j)) > tol*math.Abs(want.At(i, j))+tol {
+				t.Errorf("C[%d,%d]: want %g, got %g", i, j, want.At(i, j), got.At(i, j))
+			}
+		}
+	}
+}
+
+func TestModalDamping(t *testing.T) {
+	const tol = 1e-8
+	ga, fix := vibratingPlate(t, []float64{0, 0.25, 0.5, 0.75, 1}, []float64{0, 0.1})
+	K, M := ga.Ksolid(), ga.Msolid()
+	modes, err := fem.Modal(K, M, fix, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ratios := []float64{0.01, 0.02, 0.03, 0, 0.05}
+	C, err := fem.ModalDamping(M, modes, ratios)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Modal damping matrix is diagonal in modal coordinates: φᵢᵀ·C·φⱼ = 2·ζᵢ·ωᵢ·δᵢⱼ.
+	for i := 0; i < modes.Len(); i++ {
+		Cphi := lap.NewDenseVector(ga.TotalDofs(), nil)
+		Cphi.MulVec(C, modes.Shapes[i])
+		scale := 2 * modes.AngularFrequency(i)
+		for j := 0; j < modes.Len(); j++ {
+			want := 0.0
+			if i == j {
+				want = ratios[i]
+			}
+			got := lap.Dot(modes.Shapes[j], Cphi) / scale
+			if math.Abs(got-want) > tol {
+				t.Errorf("modes %d,%d: want modal damping ratio %g, got %g", i, j, want, got)
+			}
+		}
+	}
+}
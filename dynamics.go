@@ -0,0 +1,215 @@
+package fem
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/soypat/lap"
+)
+
+// Newmark integrates the equations of motion M·ü + C·u̇ + K·u = F(t) in time with the implicit
+// Newmark-β method and the Hilber-Hughes-Taylor (HHT-α) method, where M is the mass matrix, C the
+// damping matrix, K the stiffness matrix and F the loads. The displacements are updated as
+//
+//	uₙ₊₁ = uₙ + Δt·u̇ₙ + Δt²·((1/2-β)·üₙ + β·üₙ₊₁)
+//	u̇ₙ₊₁ = u̇ₙ + Δt·((1-γ)·üₙ + γ·üₙ₊₁)
+//
+// and the equations of motion are enforced at the end of each step, weighted by α for HHT-α:
+//
+//	M·üₙ₊₁ + (1+α)·(C·u̇ₙ₊₁ + K·uₙ₊₁) - α·(C·u̇ₙ + K·uₙ) = (1+α)·Fₙ₊₁ - α·Fₙ
+//
+// Each step is solved with a single SparseCholesky factorization of the effective stiffness matrix.
+// The zero value with a time step is the average acceleration method, also known as the trapezoidal
+// rule, which is unconditionally stable and conserves the energy of undamped systems. The linear
+// acceleration method is β=1/6 and γ=1/2 and is stable for Δt < 0.551·T of the shortest period T.
+type Newmark struct {
+	// TimeStep Δt of the integration.
+	TimeStep float64
+	// Alpha in [-1/3, 0] is the HHT-α parameter. Negative values dissipate the energy of
+	// the high frequency modes, which are poorly represented by the mesh, while keeping
+	// second order accuracy. The Newmark method is Alpha=0.
+	Alpha float64
+	// Beta and Gamma are the Newmark parameters. If both are zero they default to
+	// β = (1-α)²/4 and γ = 1/2-α, the unconditionally stable values for the HHT-α
+	// method, which reduce to average acceleration β=1/4 and γ=1/2 for α=0.
+	Beta, Gamma float64
+	// Loads stores the loads F of the model at time t in dst, which is indexed by the
+	// model's global dofs and zeroed before each call. The model has no loads if nil.
+	Loads func(dst *lap.DenseV, t float64)
+}
+
+// Dynamic integrates the displacements of a model with stiffness matrix K, mass matrix M and damping
+// matrix C over the given number of steps from the initial displacements u0 and velocities v0.
+// K and M are usually those returned by GeneralAssembler.Ksolid and GeneralAssembler.Msolid.
+// C is usually built with RayleighDamping or returned by GeneralAssembler.Csolid and the model
+// is undamped if C is nil. The fixed dofs of fix are held at their prescribed values with zero
+// velocity, which overwrite u0 and v0. The initial accelerations are those that satisfy
+// the equations of motion at t=0, so M must be non-singular for the free dofs.
+//
+// stepCallback is called with the displacements, velocities and accelerations of all dofs at
+// time t = step·Δt, starting with the initial conditions at step 0. The vectors are not modified after the call.
+func (nm *Newmark) Dynamic(K, M, C lap.Matrix, fix Fixity, u0, v0 lap.Vector, steps int, stepCallback func(step int, t float64, u, v, a *lap.DenseV)) error {
+	alpha, beta, gamma, dt := nm.Alpha, nm.Beta, nm.Gamma, nm.TimeStep
+	if beta == 0 && gamma == 0 {
+		beta = (1 - alpha) * (1 - alpha) / 4
+		gamma = 0.5 - alpha
+	}
+	switch {
+	case dt <= 0 || math.IsNaN(dt) || math.IsInf(dt, 0):
+		return fmt.Errorf("time step must be a positive finite number, got %g", dt)
+	case alpha < -1./3 || alpha > 0 || math.IsNaN(alpha):
+		return fmt.Errorf("HHT alpha must be in [-1/3, 0], got %g", alpha)
+	case !(beta > 0) || !(gamma >= 0.5) || math.IsInf(beta, 0) || math.IsInf(gamma, 0):
+		return fmt.Errorf("newmark parameters must be β > 0 and γ ≥ 1/2, got β=%g γ=%g", beta, gamma)
+	case steps < 0:
+		return errors.New("number of steps must be non-negative")
+	}
+	if C == nil {
+		n, _ := K.Dims()
+		C = lap.NewSparse(n, n)
+	}
+	// Coefficients of the displacement increment in the accelerations and velocities.
+	a0 := 1 / (beta * dt * dt)
+	c0 := gamma / (beta * dt)
+	var part staticPartition
+	Keff, err := sumCSR([]float64{a0, (1 + alpha) * c0, 1 + alpha}, M, C, K)
+	if err != nil {
+		return err
+	}
+	Kff, err := part.reset(Keff, fix)
+	if err != nil {
+		return err
+	}
+	n := part.n
+	if u0.Len() != n || v0.Len() != n {
+		return fmt.Errorf("initial displacements and velocities lengths %d and %d do not match stiffness matrix dimension %d", u0.Len(), v0.Len(), n)
+	}
+	var chol SparseCholesky
+	if Kff.n > 0 {
+		err = chol.analyze(Kff)
+		if err == nil {
+			err = chol.factorize(Kff)
+		}
+		if err != nil {
+			return fmt.Errorf("factorizing effective stiffness matrix, check model fixity: %w", err)
+		}
+	}
+	Kc, err := NewCSR(K)
+	if err != nil {
+		return err
+	}
+	Mc, err := NewCSR(M)
+	if err != nil {
+		return err
+	}
+	Cc, err := NewCSR(C)
+	if err != nil {
+		return err
+	}
+	loads := func(t float64) *lap.DenseV {
+		f := lap.NewDenseVector(n, nil)
+		if nm.Loads != nil {
+			nm.Loads(f, t)
+		}
+		return f
+	}
+	u := make([]float64, n)
+	v := make([]float64, n)
+	for i := range u {
+		if part.freeIdx[i] < 0 {
+			u[i] = part.up.AtVec(i)
+		} else {
+			u[i] = u0.AtVec(i)
+			v[i] = v0.AtVec(i)
+		}
+	}
+	f0 := loads(0)
+	a, err := initialAccelerations(M, fix, Kc, Cc, f0, u, v)
+	if err != nil {
+		return err
+	}
+	stepCallback(0, 0, lap.NewDenseVector(n, u), lap.NewDenseVector(n, v), lap.NewDenseVector(n, a))
+	var (
+		// Vectors multiplied by M, C and K and their products.
+		mu, cu, ku = make([]float64, n), make([]float64, n), make([]float64, n)
+		Mu, Cu, Ku = make([]float64, n), make([]float64, n), make([]float64, n)
+		rhs        = lap.NewDenseVector(n, nil)
+	)
+	for step := 1; step <= steps; step++ {
+		t := float64(step) * dt
+		f1 := loads(t)
+		// Contributions of the previous step to the right hand side.
+		for i := range u {
+			mu[i] = a0*u[i] + v[i]/(beta*dt) + (1/(2*beta)-1)*a[i]
+			cu[i] = (1+alpha)*(c0*u[i]+(gamma/beta-1)*v[i]+dt*(gamma/(2*beta)-1)*a[i]) + alpha*v[i]
+			ku[i] = alpha * u[i]
+		}
+		Mc.MulVecTo(Mu, mu)
+		Cc.MulVecTo(Cu, cu)
+		Kc.MulVecTo(Ku, ku)
+		for i := range u {
+			rhs.SetVec(i, (1+alpha)*f1.AtVec(i)-alpha*f0.AtVec(i)+Mu[i]+Cu[i]+Ku[i])
+		}
+		uf, err := part.rhs(rhs)
+		if err != nil {
+			return err
+		}
+		if len(uf) > 0 {
+			chol.solve(uf)
+		}
+		unext, _ := part.results(rhs, uf)
+		u1 := make([]float64, n)
+		v1 := make([]float64, n)
+		a1 := make([]float64, n)
+		for i := range u1 {
+			u1[i] = unext.AtVec(i)
+			a1[i] = a0*(u1[i]-u[i]) - v[i]/(beta*dt) - (1/(2*beta)-1)*a[i]
+			v1[i] = v[i] + dt*((1-gamma)*a[i]+gamma*a1[i])
+		}
+		u, v, a, f0 = u1, v1, a1, f1
+		stepCallback(step, t, unext, lap.NewDenseVector(n, v), lap.NewDenseVector(n, a))
+	}
+	return nil
+}
+
+// initialAccelerations returns the accelerations ü₀ that satisfy the equations
+// of motion M·ü₀ = F₀ - C·u̇₀ - K·u₀ for the free dofs. Fixed dofs are not accelerated.
+func initialAccelerations(M lap.Matrix, fix Fixity, K, C *CSR, f0 lap.Vector, u, v []float64) ([]float64, error) {
+	var part staticPartition
+	Mff, err := part.reset(M, fix)
+	if err != nil {
+		return nil, err
+	}
+	n := part.n
+	Ku := make([]float64, n)
+	Cv := make([]float64, n)
+	K.MulVecTo(Ku, u)
+	C.MulVecTo(Cv, v)
+	residual := lap.NewDenseVector(n, nil)
+	for i := range Ku {
+		residual.SetVec(i, f0.AtVec(i)-Ku[i]-Cv[i])
+	}
+	part.up = lap.NewDenseVector(n, nil)
+	af, err := part.rhs(residual)
+	if err != nil {
+		return nil, err
+	}
+	if len(af) > 0 {
+		var chol SparseCholesky
+		err = chol.analyze(Mff)
+		if err == nil {
+			err = chol.factorize(Mff)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("factorizing mass matrix for initial accelerations: %w", err)
+		}
+		chol.solve(af)
+	}
+	accel, _ := part.results(residual, af)
+	a := make([]float64, n)
+	for i := range a {
+		a[i] = accel.AtVec(i)
+	}
+	return a, nil
+}
@@ -0,0 +1,146 @@
+package fem_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/soypat/go-fem"
+	"github.com/soypat/lap"
+)
+
+func TestNewmarkOscillator(t *testing.T) {
+	// Damped oscillator released from unit displacement:
+	// u = exp(-ζ·ω·t)·(cos(ωd·t) + ζ·ω/ωd·sin(ωd·t))
+	const (
+		tol  = 2e-3
+		k, m = 400.0, 1.0
+	)
+	omega := math.Sqrt(k / m)
+	period := 2 * math.Pi / omega
+	for _, test := range []struct {
+		name  string
+		nm    fem.Newmark
+		zeta  float64
+		steps int
+	}{
+		{name: "average acceleration", nm: fem.Newmark{}, steps: 400},
+		{name: "linear acceleration", nm: fem.Newmark{Beta: 1. / 6, Gamma: 0.5}, zeta: 0.05, steps: 400},
+		{name: "HHT", nm: fem.Newmark{Alpha: -0.05}, zeta: 0.02, steps: 400},
+	} {
+		K := lap.NewSparse(1, 1)
+		K.Set(0, 0, k)
+		M := lap.NewSparse(1, 1)
+		M.Set(0, 0, m)
+		C := lap.NewSparse(1, 1)
+		C.Set(0, 0, 2*test.zeta*omega*m)
+		fix := fem.NewFixity(fem.DofPosX, 1)
+		test.nm.TimeStep = 2 * period / float64(test.steps)
+		omegaD := omega * math.Sqrt(1-test.zeta*test.zeta)
+		calls := 0
+		err := test.nm.Dynamic(K, M, C, fix, lap.NewDenseVector(1, []float64{1}), lap.NewDenseVector(1, nil), test.steps, func(step int, time float64, u, v, a *lap.DenseV) {
+			calls++
+			want := math.Exp(-test.zeta*omega*time) * (math.Cos(omegaD*time) + test.zeta*omega/omegaD*math.Sin(omegaD*time))
+			if math.Abs(u.AtVec(0)-want) > tol {
+				t.Fatalf("%s step %d: want displacement %g, got %g", test.name, step, want, u.AtVec(0))
+			}
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if calls != test.steps+1 {
+			t.Errorf("%s: expected %d step callbacks, got %d", test.name, test.steps+1, calls)
+		}
+	}
+}
+
+func TestNewmarkEnergy(t *testing.T) {
+	// Free vibration of an undamped cantilever plate. Average acceleration conserves
+	// the energy of the system while HHT-α dissipates its high frequency content.
+	ga, fix := vibratingPlate(t, []float64{0, 0.25, 0.5, 0.75, 1}, []float64{0, 0.1})
+	K, M := ga.Ksolid(), ga.Msolid()
+	n := ga.TotalDofs()
+	energy := func(u, v *lap.DenseV) float64 {
+		Ku := lap.NewDenseVector(n, nil)
+		Ku.MulVec(K, u)
+		Mv := lap.NewDenseVector(n, nil)
+		Mv.MulVec(M, v)
+		return (lap.Dot(u, Ku) + lap.Dot(v, Mv)) / 2
+	}
+	// Initial velocities excite both low and high frequency modes.
+	v0 := lap.NewDenseVector(n, nil)
+	for _, dof := range fix.FreeDofs() {
+		v0.SetVec(dof, float64(dof%3)-1)
+	}
+	u0 := lap.NewDenseVector(n, nil)
+	E0 := energy(u0, v0)
+	for _, alpha := range []float64{0, -0.3} {
+		nm := fem.Newmark{TimeStep: 1e-4, Alpha: alpha}
+		var last float64
+		err := nm.Dynamic(K, M, nil, fix, u0, v0, 200, func(step int, time float64, u, v, a *lap.DenseV) {
+			last = energy(u, v)
+			switch {
+			case alpha == 0 && math.Abs(last-E0) > 1e-9*E0:
+				t.Fatalf("step %d: energy %g not conserved, initial energy %g", step, last, E0)
+			case alpha != 0 && last > E0*(1+1e-9):
+				t.Fatalf("step %d: energy increased from %g to %g with HHT", step, E0, last)
+			}
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if alpha != 0 && last > 0.9*E0 {
+			t.Errorf("expected HHT to dissipate energy, initial %g, final %g", E0, last)
+		}
+	}
+}
+
+func TestNewmarkStepLoad(t *testing.T) {
+	// A damped cantilever plate under a suddenly applied load
+	// overshoots its static deflection and settles to it.
+	const tol = 1e-4
+	ga, fix := vibratingPlate(t, []float64{0, 0.25, 0.5, 0.75, 1}, []float64{0, 0.1})
+	K, M := ga.Ksolid(), ga.Msolid()
+	n := ga.TotalDofs()
+	tip := n - 1
+	loads := lap.NewDenseVector(n, nil)
+	loads.SetVec(tip, -1e3)
+	static, _, err := fem.LinearStatic(K, loads, fix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Mass proportional damping, 10% of critical for the first mode.
+	modes, err := fem.Modal(K, M, fix, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	omega := modes.AngularFrequency(0)
+	C := lap.NewSparse(n, n)
+	M.DoNonZero(func(i, j int, v float64) { C.Set(i, j, 2*0.1*omega*v) })
+	period := 2 * math.Pi / omega
+	nm := fem.Newmark{
+		TimeStep: period / 50,
+		Loads: func(dst *lap.DenseV, time float64) {
+			if time > 0 {
+				dst.CopyVec(loads)
+			}
+		},
+	}
+	var peak float64
+	var last *lap.DenseV
+	err = nm.Dynamic(K, M, C, fix, lap.NewDenseVector(n, nil), lap.NewDenseVector(n, nil), 2000, func(step int, time float64, u, v, a *lap.DenseV) {
+		peak = math.Min(peak, u.AtVec(tip))
+		last = u
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := static.AtVec(tip)
+	if peak > 1.5*want || peak < 2*want {
+		t.Errorf("expected peak tip deflection between 1.5 and 2 times static %g, got %g", want, peak)
+	}
+	for i := 0; i < n; i++ {
+		if math.Abs(last.AtVec(i)-static.AtVec(i)) > tol*math.Abs(want) {
+			t.Errorf("dof %d: want settled displacement %g, got %g", i, static.AtVec(i), last.AtVec(i))
+		}
+	}
+}
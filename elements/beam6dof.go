@@ -10,7 +10,8 @@ import (
 )
 
 // Beam2dof6 represents a 1D beam element with 6 dof on each of it's two nodes.
-// The beam is by default oriented in X direction.
+// The beam's local X axis points from its first node to its second node. The local
+// Y and Z axes of its cross section are oriented by the vector passed to AddElement3.
 type Beam2dof6 struct {
 	// Cross-sectional area of beam (YZ plane in local coordinates)
 	A float64
@@ -24,12 +25,18 @@ type Beam2dof6 struct {
 	g float64
 }
 
-var _ fem.Element3 = (*Beam2dof6)(nil)
+var (
+	_ fem.AxialElement3     = (*Beam2dof6)(nil)
+	_ fem.GeometricElement3 = (*Beam2dof6)(nil)
+)
 
 func (b *Beam2dof6) LenNodes() int { return 2 }
 
 func (b *Beam2dof6) Dofs() fem.DofsFlag { return fem.Dof6 }
 
+// LocalX returns the direction of the beam's axis, from its first node to its second node.
+func (b *Beam2dof6) LocalX(v []r3.Vec) r3.Vec { return r3.Sub(v[1], v[0]) }
+
 func (b *Beam2dof6) CopyK(dst *mat.Dense, v []r3.Vec) error {
 	if len(v) != 2 {
 		return errors.New("need 2 nodes")
@@ -69,6 +76,48 @@ func (b *Beam2dof6) CopyK(dst *mat.Dense, v []r3.Vec) error {
 	return nil
 }
 
+// CopyKGeometric stores the consistent geometric stiffness matrix of the beam due to its axial
+// force, obtained from the axial elongation of the local displacements u, in dst.
+// Tension stiffens the beam in bending and torsion and compression softens it.
+// The torsional term P·J/(A·L) takes J as the polar moment of the section, which
+// matches the torsion constant of circular sections only.
+func (b *Beam2dof6) CopyKGeometric(dst *mat.Dense, v []r3.Vec, u []float64) error {
+	if len(v) != 2 {
+		return errors.New("need 2 nodes")
+	} else if len(u) != 12 {
+		return errors.New("need 12 displacements")
+	}
+	L := r3.Norm(r3.Sub(v[0], v[1]))
+	// Axial force of the beam, positive in tension.
+	P := b.e * b.A * (u[6] - u[0]) / L
+	F := P / L
+	A := F * 6 / 5
+	B := F * L / 10
+	C := F * 2 * L * L / 15
+	D := F * L * L / 30
+	T := F * b.J / b.A
+	dst.SetRawMatrix(blas64.General{
+		Rows:   12,
+		Cols:   12,
+		Stride: 12,
+		Data: []float64{
+			0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+			0, A, 0, 0, 0, B, 0, -A, 0, 0, 0, B,
+			0, 0, A, 0, -B, 0, 0, 0, -A, 0, -B, 0,
+			0, 0, 0, T, 0, 0, 0, 0, 0, -T, 0, 0,
+			0, 0, -B, 0, C, 0, 0, 0, B, 0, -D, 0,
+			0, B, 0, 0, 0, C, 0, -B, 0, 0, 0, -D,
+			0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+			0, -A, 0, 0, 0, -B, 0, A, 0, 0, 0, -B,
+			0, 0, -A, 0, B, 0, 0, 0, A, 0, B, 0,
+			0, 0, 0, -T, 0, 0, 0, 0, 0, T, 0, 0,
+			0, 0, -B, 0, -D, 0, 0, 0, B, 0, C, 0,
+			0, B, 0, 0, 0, -D, 0, -B, 0, 0, 0, C,
+		},
+	})
+	return nil
+}
+
 func (b *Beam2dof6) SetConstitutive(c fem.Constituter) error {
 	sp, err := extractSolidProps(c)
 	if err != nil {
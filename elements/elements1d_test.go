@@ -0,0 +1,103 @@
+package elements
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/soypat/go-fem"
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/floats/scalar"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+var elements1 = []iso1{
+	Line2{},
+	Line3{},
+}
+
+type iso1 interface {
+	fem.Isoparametric
+	fmt.Stringer
+	length() float64
+}
+
+func TestBasis1d(t *testing.T) {
+	const tol = 1e-16
+	for _, element := range elements1 {
+		t.Run(element.String(), func(t *testing.T) {
+			nod := element.IsoparametricNodes()
+			for i, n := range nod {
+				ff := element.Basis(n)
+				for j := range ff {
+					if i == j && !scalar.EqualWithinAbs(ff[j], 1, tol) {
+						t.Errorf("basis%d must be 1 at corresponding node, got %f", i, ff[j])
+					} else if i != j && !scalar.EqualWithinAbs(ff[j], 0, tol) {
+						t.Errorf("basis%d must be 0 at non corresponding nodes, got %f", i, ff[j])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestBasisDiff1d(t *testing.T) {
+	const (
+		tol = 1e-11
+		h   = 1e-4
+	)
+	ix := r3.Vec{X: h / 2}
+	rng := rand.New(rand.NewSource(1))
+	for _, element := range elements1 {
+		t.Run(element.String(), func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				p := r3.Vec{X: 2*rng.Float64() - 1}
+				ffxm := element.Basis(r3.Sub(p, ix))
+				ffxp := element.Basis(r3.Add(p, ix))
+				floats.Sub(ffxp, ffxm)
+				floats.Scale(1/h, ffxp)
+				got := element.BasisDiff(p)
+				if !floats.EqualApprox(got, ffxp, tol) {
+					t.Errorf("X basis differential not equal\ngot:%v\nwant:%v", got, ffxp)
+					return
+				}
+			}
+		})
+	}
+}
+
+func TestQuadrature1d(t *testing.T) {
+	const tol = 1e-11
+	for order := 1; order <= 6; order++ {
+		element := Line2{QuadratureOrder: order}
+		t.Run(element.String(), func(t *testing.T) {
+			pos, weights := element.Quadrature()
+			if len(pos) != len(weights) {
+				t.Fatal("Quadrature points and weights must have same length")
+			}
+			// Gauss quadrature of n points integrates polynomials up to degree 2n-1 exactly.
+			for degree := 0; degree < 2*order; degree++ {
+				got := 0.0
+				for i, w := range weights {
+					got += w * math.Pow(pos[i].X, float64(degree))
+				}
+				want := 0.0
+				if degree%2 == 0 {
+					want = 2 / float64(degree+1)
+				}
+				if !scalar.EqualWithinAbs(got, want, tol) {
+					t.Errorf("integral of x^%d: want %g, got %g", degree, want, got)
+				}
+			}
+		})
+	}
+	for _, element := range elements1 {
+		t.Run(element.String(), func(t *testing.T) {
+			_, weights := element.Quadrature()
+			if sumW := floats.Sum(weights); !scalar.EqualWithinAbs(sumW, element.length(), tol) {
+				t.Errorf("Sum of weights must be element length %g, got %g", element.length(), sumW)
+			}
+		})
+	}
+}
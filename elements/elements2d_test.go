@@ -15,7 +15,7 @@ var elements2 = []iso2{
 	Quad8{},
 	Quad4{},
 	Triangle3{},
-	// Triangle6{},
+	Triangle6{},
 }
 
 type iso2 interface {
@@ -2,6 +2,7 @@ package elements
 
 import (
 	"fmt"
+	"math"
 	"math/rand"
 	"testing"
 
@@ -128,3 +129,43 @@ func TestQuadrature3d(t *testing.T) {
 		})
 	}
 }
+
+func TestQuadratureTetra(t *testing.T) {
+	const tol = 1e-14
+	// Integral of x^a·y^b·z^c over the reference tetrahedron is a!·b!·c!/(a+b+c+3)!.
+	factorial := func(n int) float64 {
+		f := 1.0
+		for i := 2; i <= n; i++ {
+			f *= float64(i)
+		}
+		return f
+	}
+	for _, test := range []struct {
+		name       string
+		quadrature func() ([]r3.Vec, []float64)
+		degree     int
+	}{
+		{name: "TETRA4", quadrature: Tetra4{}.Quadrature, degree: 1},
+		{name: "TETRA10", quadrature: Tetra10{}.Quadrature, degree: 2},
+		{name: "TETRA4 mass", quadrature: Tetra4{}.MassQuadrature, degree: 2},
+		{name: "TETRA10 mass", quadrature: Tetra10{}.MassQuadrature, degree: 5},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			pos, weights := test.quadrature()
+			for a := 0; a <= test.degree; a++ {
+				for b := 0; a+b <= test.degree; b++ {
+					for c := 0; a+b+c <= test.degree; c++ {
+						got := 0.0
+						for i, w := range weights {
+							got += w * math.Pow(pos[i].X, float64(a)) * math.Pow(pos[i].Y, float64(b)) * math.Pow(pos[i].Z, float64(c))
+						}
+						want := factorial(a) * factorial(b) * factorial(c) / factorial(a+b+c+3)
+						if !scalar.EqualWithinAbs(got, want, tol) {
+							t.Errorf("integral of x^%d·y^%d·z^%d: want %g, got %g", a, b, c, want, got)
+						}
+					}
+				}
+			}
+		})
+	}
+}
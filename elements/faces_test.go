@@ -0,0 +1,89 @@
+package elements
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/soypat/go-fem"
+	"gonum.org/v1/gonum/floats/scalar"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+var facetedElements = []interface {
+	fem.Faceted
+	fmt.Stringer
+}{
+	Quad4{}, Quad8{}, Triangle3{}, Triangle6{},
+	Tetra4{}, Tetra10{}, Hexa8{}, Hexa20{},
+}
+
+func TestFaces(t *testing.T) {
+	const tol = 1e-12
+	for _, element := range facetedElements {
+		t.Run(element.String(), func(t *testing.T) {
+			isoNodes := element.IsoparametricNodes()
+			dims := len(element.BasisDiff(r3.Vec{})) / element.LenNodes()
+			var volume float64
+			if dims == 2 {
+				volume = element.(iso2).area()
+			} else {
+				volume = element.(iso3).volume()
+			}
+			// Divergence theorem over the element's boundary:
+			// ∮n dA = 0 and ∮x·n dA = dims*volume.
+			var sumN r3.Vec
+			var sumXN float64
+			for iface := 0; iface < element.LenFaces(); iface++ {
+				nodes, faceT := element.Face(iface)
+				if len(nodes) != faceT.LenNodes() {
+					t.Fatalf("face %d: got %d nodes for %d node face element", iface, len(nodes), faceT.LenNodes())
+				}
+				if faceT.Dofs() != element.Dofs() {
+					t.Errorf("face %d: face dofs %v do not match element dofs %v", iface, faceT.Dofs(), element.Dofs())
+				}
+				upg, wpg := faceT.Quadrature()
+				nf := len(nodes)
+				for ipg, pg := range upg {
+					Nf := faceT.Basis(pg)
+					dNf := faceT.BasisDiff(pg)
+					var x, t1, t2 r3.Vec
+					for k, node := range nodes {
+						x = r3.Add(x, r3.Scale(Nf[k], isoNodes[node]))
+						t1 = r3.Add(t1, r3.Scale(dNf[k], isoNodes[node]))
+						if dims == 3 {
+							t2 = r3.Add(t2, r3.Scale(dNf[nf+k], isoNodes[node]))
+						}
+					}
+					// Element form functions on the face must be those of the face element.
+					N := element.Basis(x)
+					onFace := make([]bool, len(N))
+					for k, node := range nodes {
+						onFace[node] = true
+						if !scalar.EqualWithinAbs(N[node], Nf[k], tol) {
+							t.Errorf("face %d: basis of node %d does not match face basis %d: %g != %g", iface, node, k, N[node], Nf[k])
+						}
+					}
+					for i := range N {
+						if !onFace[i] && !scalar.EqualWithinAbs(N[i], 0, tol) {
+							t.Errorf("face %d: basis of node %d not on face must vanish, got %g", iface, i, N[i])
+						}
+					}
+					var ndA r3.Vec
+					if dims == 2 {
+						ndA = r3.Vec{X: t1.Y, Y: -t1.X}
+					} else {
+						ndA = r3.Cross(t1, t2)
+					}
+					sumN = r3.Add(sumN, r3.Scale(wpg[ipg], ndA))
+					sumXN += wpg[ipg] * r3.Dot(x, ndA)
+				}
+			}
+			if r3.Norm(sumN) > tol {
+				t.Errorf("integral of normal over boundary must vanish, got %v", sumN)
+			}
+			if !scalar.EqualWithinAbs(sumXN, float64(dims)*volume, tol) {
+				t.Errorf("integral of x·n over boundary must be %g, got %g", float64(dims)*volume, sumXN)
+			}
+		})
+	}
+}
@@ -16,7 +16,7 @@ type Hexa20 struct {
 	NodeDofs fem.DofsFlag
 }
 
-var _ fem.Isoparametric = Hexa20{}
+var _ fem.MassIsoparametric = Hexa20{}
 
 // LenNodes returns the number of nodes in the element.
 func (Hexa20) LenNodes() int { return 20 }
@@ -175,7 +175,38 @@ func (h8 Hexa20) Quadrature() (positions []r3.Vec, weights []float64) {
 	return positions, weights
 }
 
+// MassQuadrature returns the quadrature integration positions and weights of the element's
+// consistent mass matrix, which are those of Quadrature with at least 3 points per direction.
+func (h8 Hexa20) MassQuadrature() (positions []r3.Vec, weights []float64) {
+	quad := h8.QuadratureOrder
+	if quad < 3 {
+		quad = 3
+	}
+	positions, weights, err := uniformGaussQuad(quad, quad, quad)
+	if err != nil {
+		panic(err)
+	}
+	return positions, weights
+}
+
 // String returns string representation of element type.
 func (Hexa20) String() string { return "HEXA20" }
 
 func (Hexa20) volume() float64 { return 8 }
+
+// LenFaces returns the number of faces of the element.
+func (Hexa20) LenFaces() int { return 6 }
+
+// Face returns the node indices and element type of the ith face of the element.
+func (h20 Hexa20) Face(i int) (faceNodes []int, faceT fem.Isoparametric) {
+	return append([]int{}, hexa20Faces[i][:]...), Quad8{NodeDofs: h20.Dofs()}
+}
+
+var hexa20Faces = [6][8]int{
+	{0, 1, 2, 3, 8, 9, 10, 11},   // Z=-1
+	{4, 7, 6, 5, 15, 14, 13, 12}, // Z=+1
+	{0, 4, 5, 1, 16, 12, 17, 8},  // X=-1
+	{3, 2, 6, 7, 10, 18, 14, 19}, // X=+1
+	{0, 3, 7, 4, 11, 19, 15, 16}, // Y=-1
+	{1, 5, 6, 2, 17, 13, 18, 9},  // Y=+1
+}
@@ -16,7 +16,7 @@ type Hexa8 struct {
 	NodeDofs fem.DofsFlag
 }
 
-var _ fem.Isoparametric = Hexa8{}
+var _ fem.MassIsoparametric = Hexa8{}
 
 // LenNodes returns the number of nodes in the element.
 func (Hexa8) LenNodes() int { return 8 }
@@ -107,7 +107,38 @@ func (h8 Hexa8) Quadrature() (positions []r3.Vec, weights []float64) {
 	return positions, weights
 }
 
+// MassQuadrature returns the quadrature integration positions and weights of the element's
+// consistent mass matrix, which are those of Quadrature with at least 2 points per direction.
+func (h8 Hexa8) MassQuadrature() (positions []r3.Vec, weights []float64) {
+	quad := h8.QuadratureOrder
+	if quad < 2 {
+		quad = 2
+	}
+	positions, weights, err := uniformGaussQuad(quad, quad, quad)
+	if err != nil {
+		panic(err)
+	}
+	return positions, weights
+}
+
 // String returns string representation of element type.
 func (Hexa8) String() string { return "HEXA8" }
 
 func (Hexa8) volume() float64 { return 8 }
+
+// LenFaces returns the number of faces of the element.
+func (Hexa8) LenFaces() int { return 6 }
+
+// Face returns the node indices and element type of the ith face of the element.
+func (h8 Hexa8) Face(i int) (faceNodes []int, faceT fem.Isoparametric) {
+	return append([]int{}, hexa8Faces[i][:]...), Quad4{NodeDofs: h8.Dofs()}
+}
+
+var hexa8Faces = [6][4]int{
+	{0, 3, 2, 1}, // Z=-1
+	{4, 5, 6, 7}, // Z=+1
+	{0, 1, 5, 4}, // Y=-1
+	{1, 2, 6, 5}, // X=+1
+	{2, 3, 7, 6}, // Y=+1
+	{3, 0, 4, 7}, // X=-1
+}
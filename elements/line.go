@@ -0,0 +1,175 @@
+package elements
+
+import (
+	"strconv"
+
+	"github.com/soypat/go-fem"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+// Line2 is the 2 node 1D line element. It is used to describe the edges of
+// linear 2D elements such as Quad4 and Triangle3.
+type Line2 struct {
+	// QuadratureOrder is the order (a.k.a degree) of the quadrature used for integration.
+	// If zero a default value of 2 is used (2 point gauss quadrature).
+	QuadratureOrder int
+	// NodeDofs is the number of degrees of freedom per node.
+	// If set to 0 a default value of fem.DofX (0b1) is used.
+	NodeDofs fem.DofsFlag
+}
+
+var _ fem.MassIsoparametric = Line2{}
+
+// Dofs returns the degrees of freedom of the nodes of the element.
+func (l2 Line2) Dofs() fem.DofsFlag {
+	if l2.NodeDofs != 0 {
+		return l2.NodeDofs
+	}
+	return fem.DofPosX
+}
+
+// LenNodes returns the number of nodes of the element.
+func (Line2) LenNodes() int { return 2 }
+
+// IsoparametricNodes returns the positions of the nodes relative to the origin of the element.
+func (Line2) IsoparametricNodes() []r3.Vec {
+	return []r3.Vec{
+		0: {X: -1},
+		1: {X: 1},
+	}
+}
+
+// Basis returns the form functions of the Line2 element evaluated at v.
+func (Line2) Basis(v r3.Vec) []float64 {
+	return []float64{
+		(1 - v.X) / 2,
+		(1 + v.X) / 2,
+	}
+}
+
+// BasisDiff returns the differentiated form functions of the Line2 element evaluated at v.
+func (Line2) BasisDiff(v r3.Vec) []float64 {
+	return []float64{-0.5, 0.5}
+}
+
+// Quadrature returns the quadrature nodes and weights of the element.
+func (l2 Line2) Quadrature() ([]r3.Vec, []float64) {
+	return lineGaussQuad(l2.order())
+}
+
+func (l2 Line2) order() int {
+	quad := l2.QuadratureOrder
+	if quad <= 0 {
+		quad = 2
+	}
+	return quad
+}
+
+// MassQuadrature returns the quadrature integration positions and weights of the element's
+// consistent mass matrix, which are those of Quadrature with at least 2 points.
+func (l2 Line2) MassQuadrature() ([]r3.Vec, []float64) {
+	quad := l2.order()
+	if quad < 2 {
+		quad = 2
+	}
+	return lineGaussQuad(quad)
+}
+
+// String returns a string representation of the element.
+func (l2 Line2) String() string { return "LINE2(order=" + strconv.Itoa(l2.order()) + ")" }
+
+func (Line2) length() float64 { return 2 }
+
+// Line3 is the 3 node 1D quadratic line element. The first two nodes are the
+// ends of the line and the third is located in the middle. It is used to describe
+// the edges of quadratic 2D elements such as Quad8 and Triangle6.
+type Line3 struct {
+	// QuadratureOrder is the order (a.k.a degree) of the quadrature used for integration.
+	// If zero a default value of 3 is used (3 point gauss quadrature).
+	QuadratureOrder int
+	// NodeDofs is the number of degrees of freedom per node.
+	// If set to 0 a default value of fem.DofX (0b1) is used.
+	NodeDofs fem.DofsFlag
+}
+
+var _ fem.MassIsoparametric = Line3{}
+
+// Dofs returns the degrees of freedom of the nodes of the element.
+func (l3 Line3) Dofs() fem.DofsFlag {
+	if l3.NodeDofs != 0 {
+		return l3.NodeDofs
+	}
+	return fem.DofPosX
+}
+
+// LenNodes returns the number of nodes of the element.
+func (Line3) LenNodes() int { return 3 }
+
+// IsoparametricNodes returns the positions of the nodes relative to the origin of the element.
+func (Line3) IsoparametricNodes() []r3.Vec {
+	return []r3.Vec{
+		0: {X: -1},
+		1: {X: 1},
+		2: {X: 0},
+	}
+}
+
+// Basis returns the form functions of the Line3 element evaluated at v.
+func (Line3) Basis(v r3.Vec) []float64 {
+	x := v.X
+	return []float64{
+		x * (x - 1) / 2,
+		x * (x + 1) / 2,
+		1 - x*x,
+	}
+}
+
+// BasisDiff returns the differentiated form functions of the Line3 element evaluated at v.
+func (Line3) BasisDiff(v r3.Vec) []float64 {
+	x := v.X
+	return []float64{
+		x - 0.5,
+		x + 0.5,
+		-2 * x,
+	}
+}
+
+// Quadrature returns the quadrature nodes and weights of the element.
+func (l3 Line3) Quadrature() ([]r3.Vec, []float64) {
+	return lineGaussQuad(l3.order())
+}
+
+func (l3 Line3) order() int {
+	quad := l3.QuadratureOrder
+	if quad <= 0 {
+		quad = 3
+	}
+	return quad
+}
+
+// MassQuadrature returns the quadrature integration positions and weights of the element's
+// consistent mass matrix, which are those of Quadrature with at least 3 points.
+func (l3 Line3) MassQuadrature() ([]r3.Vec, []float64) {
+	quad := l3.order()
+	if quad < 3 {
+		quad = 3
+	}
+	return lineGaussQuad(quad)
+}
+
+// String returns a string representation of the element.
+func (l3 Line3) String() string { return "LINE3(order=" + strconv.Itoa(l3.order()) + ")" }
+
+func (Line3) length() float64 { return 2 }
+
+func lineGaussQuad(n int) ([]r3.Vec, []float64) {
+	x, w, err := gaussQuad1D(n)
+	if err != nil {
+		panic(err)
+	}
+	pos := make([]r3.Vec, n)
+	for i := range x {
+		pos[i].X = x[i]
+	}
+	return pos, w
+}
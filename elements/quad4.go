@@ -17,7 +17,7 @@ type Quad4 struct {
 	NodeDofs fem.DofsFlag
 }
 
-var _ fem.Isoparametric = Quad4{}
+var _ fem.MassIsoparametric = Quad4{}
 
 // Dofs returns the degrees of freedom of the nodes of the element.
 func (q4 Quad4) Dofs() fem.DofsFlag {
@@ -87,7 +87,29 @@ func (q4 Quad4) order() int {
 	return quad
 }
 
+// MassQuadrature returns the quadrature integration positions and weights of the element's
+// consistent mass matrix, which are those of Quadrature with at least 2 points per direction.
+func (q4 Quad4) MassQuadrature() ([]r3.Vec, []float64) {
+	quad := q4.order()
+	if quad < 2 {
+		quad = 2
+	}
+	pos, w, err := uniformGaussQuad2d(quad, quad)
+	if err != nil {
+		panic(err)
+	}
+	return pos, w
+}
+
 // String returns a string representation of the element.
 func (q4 Quad4) String() string { return "Quad43d(order=" + strconv.Itoa(q4.order()) + ")" }
 
 func (Quad4) area() float64 { return 4 }
+
+// LenFaces returns the number of edges of the element.
+func (Quad4) LenFaces() int { return 4 }
+
+// Face returns the node indices and element type of the ith edge of the element.
+func (q4 Quad4) Face(i int) (faceNodes []int, faceT fem.Isoparametric) {
+	return []int{i, (i + 1) % 4}, Line2{NodeDofs: q4.Dofs()}
+}
@@ -18,7 +18,7 @@ type Quad8 struct {
 	NodeDofs fem.DofsFlag
 }
 
-var _ fem.Isoparametric = Quad8{}
+var _ fem.MassIsoparametric = Quad8{}
 
 // Dofs returns the degrees of freedom of the nodes of the element.
 func (q8 Quad8) Dofs() fem.DofsFlag {
@@ -103,7 +103,29 @@ func (q8 Quad8) order() int {
 	return quad
 }
 
+// MassQuadrature returns the quadrature integration positions and weights of the element's
+// consistent mass matrix, which are those of Quadrature with at least 3 points per direction.
+func (q8 Quad8) MassQuadrature() ([]r3.Vec, []float64) {
+	quad := q8.order()
+	if quad < 3 {
+		quad = 3
+	}
+	pos, w, err := uniformGaussQuad2d(quad, quad)
+	if err != nil {
+		panic(err)
+	}
+	return pos, w
+}
+
 // String returns a string representation of the element.
 func (q8 Quad8) String() string { return "QUAD8(order=" + strconv.Itoa(q8.order()) + ")" }
 
 func (Quad8) area() float64 { return 4 }
+
+// LenFaces returns the number of edges of the element.
+func (Quad8) LenFaces() int { return 4 }
+
+// Face returns the node indices and element type of the ith edge of the element.
+func (q8 Quad8) Face(i int) (faceNodes []int, faceT fem.Isoparametric) {
+	return []int{i, (i + 1) % 4, i + 4}, Line3{NodeDofs: q8.Dofs()}
+}
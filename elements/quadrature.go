@@ -70,10 +70,10 @@ func gaussQuad1D(n int) (x, w []float64, err error) {
 	case 4:
 		const (
 			sqrt30 = 5.4772255750516611345696978280080213395274469499798325422689444973
-			// a = sqrt((3 - sqrt(6/5)) / 7)
-			a = 0.5216121828372476321853091190703617791381046921172457657794949152
-			// b = sqrt((3 + sqrt(6/5)) / 7)
-			b  = 0.5216121828372476321853091190703617791381046921172457657794949152
+			// a = sqrt(3/7 - 2/7*sqrt(6/5))
+			a = 0.3399810435848562648026657591032446872005758697709143525929539768
+			// b = sqrt(3/7 + 2/7*sqrt(6/5))
+			b  = 0.8611363115940525752239464888928095050957253796297176376157219209
 			wa = (18 + sqrt30) / 36
 			wb = (18 - sqrt30) / 36
 		)
@@ -0,0 +1,35 @@
+package elements
+
+import (
+	"math"
+	"testing"
+
+	"gonum.org/v1/gonum/floats/scalar"
+)
+
+func TestGaussQuad1D(t *testing.T) {
+	const tol = 1e-14
+	for n := 1; n <= 6; n++ {
+		x, w, err := gaussQuad1D(n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(x) != n || len(w) != n {
+			t.Fatalf("%d point quadrature: got %d positions and %d weights", n, len(x), len(w))
+		}
+		// Gauss quadrature of n points integrates polynomials up to degree 2n-1 exactly.
+		for degree := 0; degree < 2*n; degree++ {
+			got := 0.0
+			for i := range x {
+				got += w[i] * math.Pow(x[i], float64(degree))
+			}
+			want := 0.0
+			if degree%2 == 0 {
+				want = 2 / float64(degree+1)
+			}
+			if !scalar.EqualWithinAbs(got, want, tol) {
+				t.Errorf("%d point quadrature: integral of x^%d: want %g, got %g", n, degree, want, got)
+			}
+		}
+	}
+}
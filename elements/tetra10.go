@@ -14,7 +14,7 @@ type Tetra10 struct {
 	NodeDofs fem.DofsFlag
 }
 
-var _ fem.Isoparametric = Tetra10{}
+var _ fem.MassIsoparametric = Tetra10{}
 
 func (t10 Tetra10) Dofs() fem.DofsFlag {
 	if t10.NodeDofs != 0 {
@@ -95,6 +95,8 @@ func (Tetra10) BasisDiff(v r3.Vec) []float64 {
 	}
 }
 
+// Quadrature returns the quadrature integration positions and weights of the element.
+// The weights add up to the volume of the reference tetrahedron, 1/6.
 func (Tetra10) Quadrature() (positions []r3.Vec, weights []float64) {
 	const (
 		sqrt5 = 2.2360679774997896964091736687312762354
@@ -107,11 +109,44 @@ func (Tetra10) Quadrature() (positions []r3.Vec, weights []float64) {
 		{X: b, Y: b, Z: a},
 		{X: b, Y: a, Z: b},
 	}
-	weights = []float64{0.25, 0.25, 0.25, 0.25}
+	weights = []float64{1.0 / 24, 1.0 / 24, 1.0 / 24, 1.0 / 24}
+	return positions, weights
+}
+
+// MassQuadrature returns the quadrature integration positions and weights of the element's
+// consistent mass matrix. It is the 14 point rule of positive weights exact for polynomials of
+// degree 5, which includes the fourth degree products of the element's form functions.
+func (Tetra10) MassQuadrature() (positions []r3.Vec, weights []float64) {
+	const (
+		// Points at the edges' symmetry planes.
+		a  = 0.0455037041256496494918805262793394
+		b  = 0.5 - a
+		wa = 0.00709100346284691107301156135481063
+		// Points along the lines joining the vertices to the centroid.
+		c  = 0.310885919263300609797345733763458
+		wc = 0.0187813209530026417998642753888810
+		d  = 0.0927352503108912264023239137370306
+		wd = 0.0122488405193936582572850342477212
+	)
+	positions = []r3.Vec{
+		{X: a, Y: a, Z: b}, {X: a, Y: b, Z: a}, {X: b, Y: a, Z: a},
+		{X: a, Y: b, Z: b}, {X: b, Y: a, Z: b}, {X: b, Y: b, Z: a},
+		{X: c, Y: c, Z: c}, {X: 1 - 3*c, Y: c, Z: c}, {X: c, Y: 1 - 3*c, Z: c}, {X: c, Y: c, Z: 1 - 3*c},
+		{X: d, Y: d, Z: d}, {X: 1 - 3*d, Y: d, Z: d}, {X: d, Y: 1 - 3*d, Z: d}, {X: d, Y: d, Z: 1 - 3*d},
+	}
+	weights = []float64{wa, wa, wa, wa, wa, wa, wc, wc, wc, wc, wd, wd, wd, wd}
 	return positions, weights
 }
 
 // String returns string representation of element type.
 func (Tetra10) String() string { return "TETRA10" }
 
-func (Tetra10) volume() float64 { return 1 }
+func (Tetra10) volume() float64 { return 1.0 / 6 }
+
+// LenFaces returns the number of faces of the element.
+func (Tetra10) LenFaces() int { return 4 }
+
+// Face returns the node indices and element type of the ith face of the element.
+func (t10 Tetra10) Face(i int) (faceNodes []int, faceT fem.Isoparametric) {
+	return append([]int{}, tetraFaces[i][:]...), Triangle6{NodeDofs: t10.Dofs()}
+}
@@ -13,7 +13,7 @@ type Tetra4 struct {
 	NodeDofs fem.DofsFlag
 }
 
-var _ fem.Isoparametric = Tetra4{}
+var _ fem.MassIsoparametric = Tetra4{}
 
 func (Tetra4) Dofs() fem.DofsFlag {
 	return fem.DofPos
@@ -45,11 +45,36 @@ func (Tetra4) BasisDiff(v r3.Vec) []float64 {
 	}
 }
 
+// Quadrature returns the quadrature integration positions and weights of the element.
+// The weights add up to the volume of the reference tetrahedron, 1/6.
 func (Tetra4) Quadrature() (positions []r3.Vec, weights []float64) {
-	return []r3.Vec{{X: .25, Y: .25, Z: .25}}, []float64{1}
+	return []r3.Vec{{X: .25, Y: .25, Z: .25}}, []float64{1.0 / 6}
+}
+
+// MassQuadrature returns the quadrature integration positions and weights of the element's
+// consistent mass matrix, exact for the second degree products of its form functions.
+func (Tetra4) MassQuadrature() (positions []r3.Vec, weights []float64) {
+	return Tetra10{}.Quadrature()
 }
 
 // String returns string representation of element type.
 func (Tetra4) String() string { return "TETRA4" }
 
-func (Tetra4) volume() float64 { return 1 }
+func (Tetra4) volume() float64 { return 1.0 / 6 }
+
+// LenFaces returns the number of faces of the element.
+func (Tetra4) LenFaces() int { return 4 }
+
+// Face returns the node indices and element type of the ith face of the element.
+func (t4 Tetra4) Face(i int) (faceNodes []int, faceT fem.Isoparametric) {
+	return append([]int{}, tetraFaces[i][:3]...), Triangle3{QuadratureOrder: 2, NodeDofs: t4.Dofs()}
+}
+
+// tetraFaces holds the corner nodes of the faces of a tetrahedron
+// followed by the midside nodes of Tetra10 in Triangle6 order.
+var tetraFaces = [4][6]int{
+	{0, 2, 1, 7, 4, 5},
+	{0, 1, 3, 9, 6, 4},
+	{0, 3, 2, 8, 5, 6},
+	{1, 2, 3, 8, 9, 7},
+}
@@ -17,7 +17,7 @@ type Triangle3 struct {
 	NodeDofs fem.DofsFlag
 }
 
-var _ fem.Isoparametric = Triangle3{}
+var _ fem.MassIsoparametric = Triangle3{}
 
 // Dofs returns the degrees of freedom of the nodes of the element.
 func (t3 Triangle3) Dofs() fem.DofsFlag {
@@ -75,22 +75,46 @@ func (t3 Triangle3) order() int {
 	return quad
 }
 
-func (t3 Triangle3) String() string { return "TRI6(order=" + strconv.Itoa(t3.order()) + ")" }
+// MassQuadrature returns the quadrature integration nodes and weights of the element's
+// consistent mass matrix, which are those of Quadrature of at least order 2.
+func (t3 Triangle3) MassQuadrature() (nodes []r3.Vec, weights []float64) {
+	quad := t3.order()
+	if quad < 2 {
+		quad = 2
+	}
+	return getTriangleQuads(quad)
+}
+
+func (t3 Triangle3) String() string { return "TRI3(order=" + strconv.Itoa(t3.order()) + ")" }
 
 func (Triangle3) area() float64 { return 0.5 }
 
+// LenFaces returns the number of edges of the element.
+func (Triangle3) LenFaces() int { return 3 }
+
+// Face returns the node indices and element type of the ith edge of the element.
+func (t3 Triangle3) Face(i int) (faceNodes []int, faceT fem.Isoparametric) {
+	return []int{i, (i + 1) % 3}, Line2{NodeDofs: t3.Dofs()}
+}
+
 // Triangle6 is the 6 node 2D triangle element. The 3 nodes besides the corners
 // are located at the middle of the edges.
 type Triangle6 struct {
 	// QuadratureOrder is the degree of the quadrature used for integration.
 	// If zero a default value of 2 is used (3 node gauss quadrature).
 	QuadratureOrder int
+	// NodeDofs is the number of degrees of freedom per node.
+	// If set to 0 a default value of fem.DofX|fem.DofY (0b11) is used.
+	NodeDofs fem.DofsFlag
 }
 
-var _ fem.Isoparametric = Triangle6{}
+var _ fem.MassIsoparametric = Triangle6{}
 
 // Dofs returns the degrees of freedom of the nodes of the element.
-func (Triangle6) Dofs() fem.DofsFlag {
+func (t6 Triangle6) Dofs() fem.DofsFlag {
+	if t6.NodeDofs != 0 {
+		return t6.NodeDofs
+	}
 	return fem.DofPosX | fem.DofPosY
 }
 
@@ -99,7 +123,7 @@ func (Triangle6) LenNodes() int { return 6 }
 
 // IsoparametricNodes returns the positions of the nodes relative to the origin of the element.
 func (Triangle6) IsoparametricNodes() []r3.Vec {
-	const b, h = 1, 1
+	const b, h = 1.0, 1.0
 	return []r3.Vec{
 		0: {X: 0, Y: 0},
 		1: {X: b, Y: 0},
@@ -112,29 +136,36 @@ func (Triangle6) IsoparametricNodes() []r3.Vec {
 
 // Basis returns the form functions of the Triangle6 element evaluated at v.
 func (Triangle6) Basis(v r3.Vec) []float64 {
-	x, y := v.X, v.Y
+	// Area coordinates.
+	L0, L1, L2 := 1-v.X-v.Y, v.X, v.Y
 	return []float64{
-		1 - 3*x - 3*y + 2*x*x + 4*x*y + 2*y*y,
-		4*x - 4*x*x - 4*x*y,
-		-x + 2*x*x,
-		4 * x * y,
-		-y + 2*y*y,
-		4*y - 4*x*y - 4*y*y,
+		L0 * (2*L0 - 1),
+		L1 * (2*L1 - 1),
+		L2 * (2*L2 - 1),
+		4 * L1 * L2,
+		4 * L0 * L2,
+		4 * L0 * L1,
 	}
 }
 
 // BasisDiff returns the differentiated form functions of the Triangle6 element evaluated at v.
 func (Triangle6) BasisDiff(v r3.Vec) []float64 {
-	x, y := v.X, v.Y
+	L0, L1, L2 := 1-v.X-v.Y, v.X, v.Y
 	return []float64{
-		-3 + 4*x + 4*y,
-		4 - 8*x - 4*y,
-		-1 + 4*x,
-		4 * y,
-		-4 * y,
-		-4 + 4*x + 8*y,
-		4 * x,
-		-4 * x,
+		// w.r.t X
+		1 - 4*L0,
+		4*L1 - 1,
+		0,
+		4 * L2,
+		-4 * L2,
+		4 * (L0 - L1),
+		// w.r.t Y
+		1 - 4*L0,
+		0,
+		4*L2 - 1,
+		4 * L1,
+		4 * (L0 - L2),
+		-4 * L1,
 	}
 }
 
@@ -151,10 +182,26 @@ func (t6 Triangle6) order() int {
 	return quad
 }
 
+// MassQuadrature returns the quadrature integration nodes and weights of the element's
+// consistent mass matrix, the 6 point rule of order 4.
+func (Triangle6) MassQuadrature() (nodes []r3.Vec, weights []float64) {
+	return getTriangleQuads(4)
+}
+
 func (t6 Triangle6) String() string { return "TRI6(order=" + strconv.Itoa(t6.order()) + ")" }
 
 func (Triangle6) area() float64 { return 0.5 }
 
+// LenFaces returns the number of edges of the element.
+func (Triangle6) LenFaces() int { return 3 }
+
+// Face returns the node indices and element type of the ith edge of the element.
+func (t6 Triangle6) Face(i int) (faceNodes []int, faceT fem.Isoparametric) {
+	// Midside nodes of edges 0-1, 1-2 and 2-0.
+	mid := [3]int{5, 3, 4}
+	return []int{i, (i + 1) % 3, mid[i]}, Line3{NodeDofs: t6.Dofs()}
+}
+
 func getTriangleQuads(order int) (nodes []r3.Vec, weights []float64) {
 	if order < 1 {
 		panic("bad triangle quadrature order")
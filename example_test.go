@@ -71,18 +71,18 @@ func ExampleGeneralAssembler() {
 	fmt.Printf("K=\n%.5g", lap.Formatted(ga.Ksolid()))
 	// Output:
 	//  K=
-	// ⎡ 4.2308e+11   1.9231e+11   1.9231e+11  -2.6923e+11  -7.6923e+10  -7.6923e+10  -7.6923e+10  -1.1538e+11            0  -7.6923e+10            0  -1.1538e+11⎤
-	// ⎢ 1.9231e+11   4.2308e+11   1.9231e+11  -1.1538e+11  -7.6923e+10            0  -7.6923e+10  -2.6923e+11  -7.6923e+10            0  -7.6923e+10  -1.1538e+11⎥
-	// ⎢ 1.9231e+11   1.9231e+11   4.2308e+11  -1.1538e+11            0  -7.6923e+10            0  -1.1538e+11  -7.6923e+10  -7.6923e+10  -7.6923e+10  -2.6923e+11⎥
-	// ⎢-2.6923e+11  -1.1538e+11  -1.1538e+11   2.6923e+11            0            0            0   1.1538e+11            0            0            0   1.1538e+11⎥
-	// ⎢-7.6923e+10  -7.6923e+10            0            0   7.6923e+10            0   7.6923e+10            0            0            0            0            0⎥
-	// ⎢-7.6923e+10            0  -7.6923e+10            0            0   7.6923e+10            0            0            0   7.6923e+10            0            0⎥
-	// ⎢-7.6923e+10  -7.6923e+10            0            0   7.6923e+10            0   7.6923e+10            0            0            0            0            0⎥
-	// ⎢-1.1538e+11  -2.6923e+11  -1.1538e+11   1.1538e+11            0            0            0   2.6923e+11            0            0            0   1.1538e+11⎥
-	// ⎢          0  -7.6923e+10  -7.6923e+10            0            0            0            0            0   7.6923e+10            0   7.6923e+10            0⎥
-	// ⎢-7.6923e+10            0  -7.6923e+10            0            0   7.6923e+10            0            0            0   7.6923e+10            0            0⎥
-	// ⎢          0  -7.6923e+10  -7.6923e+10            0            0            0            0            0   7.6923e+10            0   7.6923e+10            0⎥
-	// ⎣-1.1538e+11  -1.1538e+11  -2.6923e+11   1.1538e+11            0            0            0   1.1538e+11            0            0            0   2.6923e+11⎦
+	// ⎡ 7.0513e+10   3.2051e+10   3.2051e+10  -4.4872e+10  -1.2821e+10  -1.2821e+10  -1.2821e+10  -1.9231e+10            0  -1.2821e+10            0  -1.9231e+10⎤
+	// ⎢ 3.2051e+10   7.0513e+10   3.2051e+10  -1.9231e+10  -1.2821e+10            0  -1.2821e+10  -4.4872e+10  -1.2821e+10            0  -1.2821e+10  -1.9231e+10⎥
+	// ⎢ 3.2051e+10   3.2051e+10   7.0513e+10  -1.9231e+10            0  -1.2821e+10            0  -1.9231e+10  -1.2821e+10  -1.2821e+10  -1.2821e+10  -4.4872e+10⎥
+	// ⎢-4.4872e+10  -1.9231e+10  -1.9231e+10   4.4872e+10            0            0            0   1.9231e+10            0            0            0   1.9231e+10⎥
+	// ⎢-1.2821e+10  -1.2821e+10            0            0   1.2821e+10            0   1.2821e+10            0            0            0            0            0⎥
+	// ⎢-1.2821e+10            0  -1.2821e+10            0            0   1.2821e+10            0            0            0   1.2821e+10            0            0⎥
+	// ⎢-1.2821e+10  -1.2821e+10            0            0   1.2821e+10            0   1.2821e+10            0            0            0            0            0⎥
+	// ⎢-1.9231e+10  -4.4872e+10  -1.9231e+10   1.9231e+10            0            0            0   4.4872e+10            0            0            0   1.9231e+10⎥
+	// ⎢          0  -1.2821e+10  -1.2821e+10            0            0            0            0            0   1.2821e+10            0   1.2821e+10            0⎥
+	// ⎢-1.2821e+10            0  -1.2821e+10            0            0   1.2821e+10            0            0            0   1.2821e+10            0            0⎥
+	// ⎢          0  -1.2821e+10  -1.2821e+10            0            0            0            0            0   1.2821e+10            0   1.2821e+10            0⎥
+	// ⎣-1.9231e+10  -1.9231e+10  -4.4872e+10   1.9231e+10            0            0            0   1.9231e+10            0            0            0   4.4872e+10⎦
 }
 
 func ExampleGeneralAssembler_AddIsoparametric_quad4PlaneStress() {
@@ -142,8 +142,8 @@ func ExampleGeneralAssembler_AddIsoparametric_axisymmetricThermal() {
 	fmt.Printf("K=\n%.3g\n", lap.Formatted(ga.Ksolid()))
 	//Output:
 	// K=
-	// ⎡  3.4e+03   1.84e+03  -1.89e+03  -3.36e+03⎤
-	// ⎢ 1.84e+03   4.18e+03   -4.1e+03  -1.89e+03⎥
-	// ⎢-1.89e+03   -4.1e+03   4.18e+03   1.84e+03⎥
-	// ⎣-3.36e+03  -1.89e+03   1.84e+03    3.4e+03⎦
+	// ⎡ 3.41e+03   1.84e+03  -1.89e+03  -3.36e+03⎤
+	// ⎢ 1.84e+03   4.16e+03  -4.11e+03  -1.89e+03⎥
+	// ⎢-1.89e+03  -4.11e+03   4.16e+03   1.84e+03⎥
+	// ⎣-3.36e+03  -1.89e+03   1.84e+03   3.41e+03⎦
 }
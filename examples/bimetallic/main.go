@@ -5,14 +5,107 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log"
+	"math"
 	"strings"
 
+	"github.com/soypat/go-fem"
+	"github.com/soypat/go-fem/constitution/solids"
+	"github.com/soypat/go-fem/elements"
+	"github.com/soypat/lap"
 	"gonum.org/v1/gonum/spatial/r3"
 )
 
+// Bimetallic strip clamped at one end which bends when heated due to the
+// mismatch of thermal expansion between its layers. Units are mm and MPa.
 func main() {
+	const (
+		deltaT = 100.0 // Temperature change in Kelvin.
+		// Layers interface height. Strip is 60mm thick and 180mm long.
+		yInterface = 40.0
+	)
+	steel := solids.Isotropic{E: 200e3, Poisson: 0.3, ThermalExpansion: 12e-6}
+	aluminium := solids.Isotropic{E: 70e3, Poisson: 0.33, ThermalExpansion: 23e-6}
 	nodes, q8 := feaModel()
 	fmt.Println("nodes:", len(nodes), "elements:", len(q8))
+
+	// Assign elements to layers by the height of their centroid.
+	const steelID, aluminiumID = 0, 1
+	materials := []fem.ThermalIsoConstituter{
+		steelID:     steel.PlaneStess().(fem.ThermalIsoConstituter),
+		aluminiumID: aluminium.PlaneStess().(fem.ThermalIsoConstituter),
+	}
+	elemMaterial := make([]int, len(q8))
+	for i, elem := range q8 {
+		var centroid r3.Vec
+		for _, n := range elem {
+			centroid = r3.Add(centroid, r3.Scale(1.0/8, nodes[n]))
+		}
+		if centroid.Y < yInterface {
+			elemMaterial[i] = steelID
+		} else {
+			elemMaterial[i] = aluminiumID
+		}
+	}
+	elemType := elements.Quad8{}
+	ga := fem.NewGeneralAssembler(nodes, elemType.Dofs())
+	getElement := func(i int) ([]int, int, r3.Vec, r3.Vec) { return q8[i][:], elemMaterial[i], r3.Vec{}, r3.Vec{} }
+	err := ga.AddIsoparametricMaterials(elemType, []fem.IsoConstituter{materials[steelID], materials[aluminiumID]}, len(q8), getElement)
+	if err != nil {
+		log.Fatal(err)
+	}
+	loads := lap.NewDenseVector(ga.TotalDofs(), nil)
+	uniformDeltaT := func(int, []int, []float64) float64 { return deltaT }
+	err = ga.AddIsoparametricThermalLoadMaterials(loads, elemType, materials, len(q8), getElement, uniformDeltaT)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Clamp the strip's left end.
+	xmin := math.Inf(1)
+	for _, node := range nodes {
+		xmin = math.Min(xmin, node.X)
+	}
+	fix := fem.NewFixity(elemType.Dofs(), len(nodes))
+	for i, node := range nodes {
+		if node.X == xmin {
+			fix.Fix(i, elemType.Dofs())
+		}
+	}
+	displacements, _, err := fem.LinearStatic(ga.Ksolid(), loads, fix)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Average vertical displacement of the strip's free end.
+	xmax := math.Inf(-1)
+	for _, node := range nodes {
+		xmax = math.Max(xmax, node.X)
+	}
+	var tipDeflection float64
+	var tipNodes int
+	for i, node := range nodes {
+		if node.X == xmax {
+			tipDeflection += displacements.AtVec(2*i + 1)
+			tipNodes++
+		}
+	}
+	tipDeflection /= float64(tipNodes)
+	fmt.Printf("tip deflection: %.4gmm\n", tipDeflection)
+	fmt.Printf("Timoshenko's bimetallic beam deflection: %.4gmm\n", timoshenkoDeflection(steel, aluminium, yInterface, 60-yInterface, deltaT, xmax-xmin))
+}
+
+// timoshenkoDeflection returns the upward tip deflection of a cantilevered bimetallic beam
+// with a bottom layer of material m1 and thickness t1 and a top layer of material m2 and
+// thickness t2 subject to a uniform temperature change. The beam bends towards the layer
+// of lesser thermal expansion.
+func timoshenkoDeflection(m1, m2 solids.Isotropic, t1, t2, deltaT, length float64) float64 {
+	m := t1 / t2
+	n := m1.E / m2.E
+	h := t1 + t2
+	curvature := 6 * (m2.ThermalExpansion - m1.ThermalExpansion) * deltaT * (1 + m) * (1 + m) /
+		(h * (3*(1+m)*(1+m) + (1+m*n)*(m*m+1/(m*n))))
+	return -curvature * length * length / 2
 }
 
 var (
@@ -0,0 +1,337 @@
+package fem
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/soypat/lap"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+// ExplicitModel is a model of isoparametric elements whose internal forces are calculated
+// element by element from the displacements, without assembling the stiffness matrix.
+// It is used by explicit time integration methods such as CentralDifference, which
+// require little more memory than the model's mesh and a few vectors.
+type ExplicitModel struct {
+	ga         *GeneralAssembler
+	elemT      Isoparametric
+	c          IsoConstituter
+	cmat       *mat.Dense
+	nelem      int
+	getElement func(i int) (elem []int, xC, yC r3.Vec)
+	mass       *lap.DenseV
+	dtCrit     float64
+	// Internal forces and dofs of each element, accumulated in element order.
+	forces   []float64
+	elemDofs []int
+}
+
+// NewExplicitModel returns the explicit model of the isoparametric elements of the assembler's mesh.
+// The arguments are those of AddIsoparametric with the addition of the density of the elements,
+// from which the model's nodal masses are lumped with LumpAuto. The critical time step and internal
+// forces are calculated concurrently with the workers set by the assembler's SetConcurrency.
+func NewExplicitModel(ga *GeneralAssembler, elemT Isoparametric, c IsoConstituter, density float64, Nelem int, getElement func(i int) (elem []int, xC, yC r3.Vec)) (*ExplicitModel, error) {
+	mass := lap.NewDenseVector(ga.TotalDofs(), nil)
+	err := ga.AddIsoparametricLumpedMass(mass, elemT, c, density, LumpAuto, Nelem, getElement)
+	if err != nil {
+		return nil, err
+	}
+	Cd, err := denseConstitutive(c)
+	if err != nil {
+		return nil, err
+	}
+	NdofperElem := elemT.LenNodes() * elemT.Dofs().Count()
+	em := &ExplicitModel{
+		ga:         ga,
+		elemT:      elemT,
+		c:          c,
+		cmat:       Cd,
+		nelem:      Nelem,
+		getElement: getElement,
+		mass:       mass,
+		forces:     make([]float64, Nelem*NdofperElem),
+		elemDofs:   make([]int, Nelem*NdofperElem),
+	}
+	em.dtCrit, err = em.criticalTimeStep(density)
+	if err != nil {
+		return nil, err
+	}
+	return em, nil
+}
+
+// Mass returns the lumped nodal masses of the model indexed by the model's global dofs.
+func (em *ExplicitModel) Mass() *lap.DenseV { return em.mass }
+
+// CriticalTimeStep returns the largest stable time step of explicit integration of the model, 2/ωmax where
+// ωmax is the highest natural frequency of the elements when unassembled with their lumped masses, which
+// bounds that of the model. It is about the time it takes a dilatational wave of speed √(Cmax/ρ), where Cmax is
+// the largest diagonal term of the constitutive matrix, to cross the element's smallest dimension. The element
+// frequency is used instead because the wave estimate exceeds the stable time step of fully integrated,
+// quadratic and distorted elements.
+func (em *ExplicitModel) CriticalTimeStep() float64 { return em.dtCrit }
+
+func (em *ExplicitModel) criticalTimeStep(density float64) (float64, error) {
+	it, err := newIsoIntegrator(em.elemT)
+	if err != nil {
+		return 0, err
+	}
+	itMass, err := newMassIntegrator(em.elemT)
+	if err != nil {
+		return 0, err
+	}
+	dimC, _ := em.cmat.Dims()
+	var (
+		NdofsPerNode = em.elemT.Dofs().Count()
+		NdofperElem  = it.NnodperElem * NdofsPerNode
+		// Smallest critical time step of the elements of each worker.
+		workerDt []*float64
+	)
+	err = forEachElementConcurrent(em.ga.workers, em.ga.dofs, em.ga.nodes, em.elemT, it.NdimsPerNode, em.nelem, func() (func(int) []int, elementDofCallback) {
+		it := it.clone()
+		itMass := itMass.clone()
+		var (
+			Ke     = mat.NewDense(NdofperElem, NdofperElem, nil)
+			B      = mat.NewDense(dimC, NdofperElem, nil)
+			aux1   = mat.NewDense(NdofperElem, dimC, nil)
+			aux2   = mat.NewDense(NdofperElem, NdofperElem, nil)
+			Mn     = mat.NewDense(it.NnodperElem, it.NnodperElem, nil)
+			lumped = make([]float64, it.NnodperElem)
+			A      = mat.NewSymDense(NdofperElem, nil)
+			eig    mat.EigenSym
+			x, y   r3.Vec
+		)
+		dtCrit := math.Inf(1)
+		workerDt = append(workerDt, &dtCrit)
+		subGetElement := func(i int) (elem []int) {
+			elem, x, y = em.getElement(i)
+			return elem
+		}
+		return subGetElement, func(iele int, elemNodBacking []float64, elemDofs []int) error {
+			Ce, err := orientedConstitutive(em.c, em.cmat, x, y)
+			if err != nil {
+				return err
+			}
+			elemNod := mat.NewDense(it.NnodperElem, it.NdimsPerNode, elemNodBacking)
+			err = it.stiffness(Ke, B, aux1, aux2, iele, elemNod, em.c, Ce)
+			if err != nil {
+				return err
+			}
+			err = itMass.scalarMass(Mn, B, iele, elemNod, em.c, density)
+			if err != nil {
+				return err
+			}
+			lumpMass(lumped, Mn, LumpAuto)
+			// Eigenvalues ω² of Ke·φ = ω²·Me·φ are those of Me^(-1/2)·Ke·Me^(-1/2).
+			for i := 0; i < NdofperElem; i++ {
+				mi := lumped[i/NdofsPerNode]
+				for j := i; j < NdofperElem; j++ {
+					mj := lumped[j/NdofsPerNode]
+					A.SetSym(i, j, Ke.At(i, j)/math.Sqrt(mi*mj))
+				}
+			}
+			if !eig.Factorize(A, false) {
+				return fmt.Errorf("eigenvalue decomposition of element #%d failed", iele)
+			}
+			values := eig.Values(nil)
+			omegaMax := math.Sqrt(values[len(values)-1])
+			dtCrit = math.Min(dtCrit, 2/omegaMax)
+			return nil
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+	dtCrit := math.Inf(1)
+	for _, dt := range workerDt {
+		dtCrit = math.Min(dtCrit, *dt)
+	}
+	return dtCrit, nil
+}
+
+// InternalForces stores the internal forces ∫Bᵀ·σ dV of the elements due to the
+// displacements u in dst, both indexed by the model's global dofs. The internal
+// forces equal K·u for the stiffness matrix assembled by AddIsoparametric.
+// It must not be called concurrently on the same model.
+func (em *ExplicitModel) InternalForces(dst *lap.DenseV, u lap.Vector) error {
+	n := em.ga.TotalDofs()
+	if dst.Len() != n || u.Len() != n {
+		return fmt.Errorf("vector lengths %d and %d do not match total number of dofs %d", dst.Len(), u.Len(), n)
+	}
+	it, err := newIsoIntegrator(em.elemT)
+	if err != nil {
+		return err
+	}
+	dimC, _ := em.cmat.Dims()
+	NdofperElem := it.NnodperElem * em.elemT.Dofs().Count()
+	err = forEachElementConcurrent(em.ga.workers, em.ga.dofs, em.ga.nodes, em.elemT, it.NdimsPerNode, em.nelem, func() (func(int) []int, elementDofCallback) {
+		it := it.clone()
+		var (
+			B      = mat.NewDense(dimC, NdofperElem, nil)
+			ue     = mat.NewVecDense(NdofperElem, nil)
+			strain = mat.NewVecDense(dimC, nil)
+			stress = mat.NewVecDense(dimC, nil)
+			fpg    = mat.NewVecDense(NdofperElem, nil)
+			x, y   r3.Vec
+		)
+		subGetElement := func(i int) (elem []int) {
+			elem, x, y = em.getElement(i)
+			return elem
+		}
+		return subGetElement, func(iele int, elemNodBacking []float64, elemDofs []int) error {
+			Ce, err := orientedConstitutive(em.c, em.cmat, x, y)
+			if err != nil {
+				return err
+			}
+			offset := iele * NdofperElem
+			copy(em.elemDofs[offset:], elemDofs)
+			fe := mat.NewVecDense(NdofperElem, em.forces[offset:offset+NdofperElem])
+			fe.Zero()
+			for i, dof := range elemDofs {
+				ue.SetVec(i, u.AtVec(dof))
+			}
+			elemNod := mat.NewDense(it.NnodperElem, it.NdimsPerNode, elemNodBacking)
+			for ipg := range it.upg {
+				dJac, err := it.jacobian(iele, ipg, elemNod)
+				if err != nil {
+					return err
+				}
+				scale, err := it.strainDisplacement(B, iele, ipg, elemNod, em.c)
+				if err != nil {
+					return err
+				}
+				// fe = fe + Bᵀ*C*B*ue * weight*det(J)
+				strain.MulVec(B, ue)
+				stress.MulVec(Ce, strain)
+				fpg.MulVec(B.T(), stress)
+				fe.AddScaledVec(fe, dJac*it.wpg[ipg]*scale, fpg)
+			}
+			return nil
+		}
+	})
+	if err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		dst.SetVec(i, 0)
+	}
+	for k, dof := range em.elemDofs {
+		dst.SetVec(dof, dst.AtVec(dof)+em.forces[k])
+	}
+	return nil
+}
+
+// CentralDifference integrates the equations of motion M·ü + K·u = F(t) of an ExplicitModel in
+// time with the explicit central difference method, where M is the model's lumped mass matrix.
+// The velocities are updated at half steps:
+//
+//	u̇ₙ₊₁⸝₂ = u̇ₙ₋₁⸝₂ + Δt·üₙ
+//	uₙ₊₁ = uₙ + Δt·u̇ₙ₊₁⸝₂
+//	üₙ₊₁ = M⁻¹·(Fₙ₊₁ - K·uₙ₊₁)
+//
+// No system of equations is solved, so each step is inexpensive, but the method is only stable for time steps
+// smaller than the time it takes a wave to cross the smallest element. It suits short duration events
+// such as impacts, which are resolved with small time steps anyway.
+type CentralDifference struct {
+	// TimeStep Δt of the integration. Defaults to 0.9 times the
+	// critical time step of the model. Must not exceed the critical time step.
+	TimeStep float64
+	// Loads stores the loads F of the model at time t in dst, which is indexed by the
+	// model's global dofs and zeroed before each call. The model has no loads if nil.
+	Loads func(dst *lap.DenseV, t float64)
+}
+
+// Dynamic integrates the displacements of the explicit model over the given number of steps
+// from the initial displacements u0 and velocities v0. The fixed dofs of fix are held at their
+// prescribed values with zero velocity, which overwrite u0 and v0. All free dofs must have mass.
+//
+// stepCallback is called with the displacements, velocities and accelerations of all dofs at
+// time t = step·Δt, starting with the initial conditions at step 0. The vectors are not modified after the call.
+func (cd *CentralDifference) Dynamic(model *ExplicitModel, fix Fixity, u0, v0 lap.Vector, steps int, stepCallback func(step int, t float64, u, v, a *lap.DenseV)) error {
+	dt := cd.TimeStep
+	if dt == 0 {
+		dt = 0.9 * model.dtCrit
+	}
+	switch {
+	case dt <= 0 || math.IsNaN(dt) || math.IsInf(dt, 0):
+		return fmt.Errorf("time step must be a positive finite number, got %g", dt)
+	case dt > model.dtCrit:
+		return fmt.Errorf("time step %g exceeds critical time step %g", dt, model.dtCrit)
+	case steps < 0:
+		return errors.New("number of steps must be non-negative")
+	}
+	n := model.ga.TotalDofs()
+	switch {
+	case fix.TotalDofs() != n:
+		return fmt.Errorf("fixity total dofs %d does not match total number of dofs %d", fix.TotalDofs(), n)
+	case u0.Len() != n || v0.Len() != n:
+		return fmt.Errorf("initial displacements and velocities lengths %d and %d do not match total number of dofs %d", u0.Len(), v0.Len(), n)
+	}
+	free := make([]bool, n)
+	for _, dof := range fix.FreeDofs() {
+		if model.mass.AtVec(dof) <= 0 {
+			return fmt.Errorf("free dof %d has no mass, check model fixity", dof)
+		}
+		free[dof] = true
+	}
+	up := fix.Prescribed()
+	u := lap.NewDenseVector(n, nil)
+	v := lap.NewDenseVector(n, nil)
+	for i := 0; i < n; i++ {
+		if free[i] {
+			u.SetVec(i, u0.AtVec(i))
+			v.SetVec(i, v0.AtVec(i))
+		} else {
+			u.SetVec(i, up.AtVec(i))
+		}
+	}
+	fint := lap.NewDenseVector(n, nil)
+	accelerations := func(t float64, u *lap.DenseV) (*lap.DenseV, error) {
+		a := lap.NewDenseVector(n, nil)
+		if cd.Loads != nil {
+			cd.Loads(a, t)
+		}
+		err := model.InternalForces(fint, u)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < n; i++ {
+			if free[i] {
+				a.SetVec(i, (a.AtVec(i)-fint.AtVec(i))/model.mass.AtVec(i))
+			} else {
+				a.SetVec(i, 0)
+			}
+		}
+		return a, nil
+	}
+	a, err := accelerations(0, u)
+	if err != nil {
+		return err
+	}
+	stepCallback(0, 0, u, v, a)
+	// Velocities at the half step.
+	vhalf := make([]float64, n)
+	for i := range vhalf {
+		vhalf[i] = v.AtVec(i) + dt/2*a.AtVec(i)
+	}
+	for step := 1; step <= steps; step++ {
+		t := float64(step) * dt
+		unext := lap.NewDenseVector(n, nil)
+		for i, vh := range vhalf {
+			unext.SetVec(i, u.AtVec(i)+dt*vh)
+		}
+		u = unext
+		a, err = accelerations(t, u)
+		if err != nil {
+			return err
+		}
+		v = lap.NewDenseVector(n, nil)
+		for i := range vhalf {
+			v.SetVec(i, vhalf[i]+dt/2*a.AtVec(i))
+			vhalf[i] += dt * a.AtVec(i)
+		}
+		stepCallback(step, t, u, v, a)
+	}
+	return nil
+}
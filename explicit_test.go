@@ -0,0 +1,156 @@
+package fem_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/soypat/go-fem"
+	"github.com/soypat/go-fem/constitution/solids"
+	"github.com/soypat/go-fem/elements"
+	"github.com/soypat/lap"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+func TestExplicitInternalForces(t *testing.T) {
+	const tol = 1e-10
+	material := solids.Isotropic{E: 1000, Poisson: 0.3}
+	nodes, hexas := boxMesh([]float64{0, 0.5, 1, 2}, []float64{0, 1}, []float64{0, 0.5, 1})
+	elemT := elements.Hexa8{}
+	getElement := func(i int) ([]int, r3.Vec, r3.Vec) { return hexas[i], r3.Vec{}, r3.Vec{} }
+	ga := fem.NewGeneralAssembler(nodes, elemT.Dofs())
+	err := ga.AddIsoparametric(elemT, material.Solid3D(), len(hexas), getElement)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := ga.TotalDofs()
+	u := lap.NewDenseVector(n, nil)
+	for i := 0; i < n; i++ {
+		u.SetVec(i, math.Sin(float64(i)))
+	}
+	want := lap.NewDenseVector(n, nil)
+	want.MulVec(ga.Ksolid(), u)
+	for _, workers := range []int{1, 3} {
+		ga.SetConcurrency(workers)
+		model, err := fem.NewExplicitModel(ga, elemT, material.Solid3D(), 7800, len(hexas), getElement)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := lap.NewDenseVector(n, nil)
+		err = model.InternalForces(got, u)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < n; i++ {
+			if math.Abs(got.AtVec(i)-want.AtVec(i)) > tol*math.Abs(want.AtVec(i))+tol {
+				t.Errorf("workers %d dof %d: want internal force %g, got %g", workers, i, want.AtVec(i), got.AtVec(i))
+			}
+		}
+	}
+}
+
+func TestExplicitCriticalTimeStep(t *testing.T) {
+	// The critical time step is bounded by that of the assembled model
+	// and close to it for meshes of regular elements.
+	material := solids.Isotropic{E: 1000, Poisson: 0.25}
+	boxNodes, hexas := boxMesh([]float64{0, 0.5, 1}, []float64{0, 0.25, 0.5}, []float64{0, 1, 2})
+	plateNodes, quads := rectangularMesh([]float64{0, 1, 3}, []float64{0, 0.5, 1}, true)
+	for _, test := range []struct {
+		name  string
+		elemT fem.Isoparametric
+		c     fem.IsoConstituter
+		nodes []r3.Vec
+		elems [][]int
+	}{
+		{name: "Hexa8", elemT: elements.Hexa8{}, c: material.Solid3D(), nodes: boxNodes, elems: hexas},
+		{name: "Quad8", elemT: elements.Quad8{}, c: material.PlaneStess(), nodes: plateNodes, elems: quads},
+	} {
+		getElement := func(i int) ([]int, r3.Vec, r3.Vec) { return test.elems[i], r3.Vec{}, r3.Vec{} }
+		ga := fem.NewGeneralAssembler(test.nodes, test.elemT.Dofs())
+		err := ga.AddIsoparametric(test.elemT, test.c, len(test.elems), getElement)
+		if err != nil {
+			t.Fatal(err)
+		}
+		model, err := fem.NewExplicitModel(ga, test.elemT, test.c, 2, len(test.elems), getElement)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := ga.TotalDofs()
+		M := lap.NewSparse(n, n)
+		all := make([]int, n)
+		for i := range all {
+			all[i] = i
+			M.Set(i, i, model.Mass().AtVec(i))
+		}
+		values := denseEigenvalues(t, ga.Ksolid(), M, all)
+		stable := 2 / math.Sqrt(values[len(values)-1])
+		got := model.CriticalTimeStep()
+		if got > stable*(1+1e-9) || got < 0.9*stable {
+			t.Errorf("%s: want critical time step close to and below %g, got %g", test.name, stable, got)
+		}
+		ga.SetConcurrency(3)
+		concurrent, err := fem.NewExplicitModel(ga, test.elemT, test.c, 2, len(test.elems), getElement)
+		if err != nil {
+			t.Fatal(err)
+		} else if concurrent.CriticalTimeStep() != got {
+			t.Errorf("%s: concurrent critical time step %g does not match sequential %g", test.name, concurrent.CriticalTimeStep(), got)
+		}
+	}
+}
+
+func TestCentralDifference(t *testing.T) {
+	// Cantilever released from its first mode shape vibrates as u = φ·cos(ω·t).
+	const tol = 1e-2
+	material := solids.Isotropic{E: 1000, Poisson: 0.3}
+	nodes, hexas := boxMesh([]float64{0, 0.5, 1, 1.5, 2}, []float64{0, 0.5}, []float64{0, 0.5})
+	elemT := elements.Hexa8{}
+	getElement := func(i int) ([]int, r3.Vec, r3.Vec) { return hexas[i], r3.Vec{}, r3.Vec{} }
+	ga := fem.NewGeneralAssembler(nodes, elemT.Dofs())
+	err := ga.AddIsoparametric(elemT, material.Solid3D(), len(hexas), getElement)
+	if err != nil {
+		t.Fatal(err)
+	}
+	model, err := fem.NewExplicitModel(ga, elemT, material.Solid3D(), 1, len(hexas), getElement)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fix := fem.NewFixity(elemT.Dofs(), len(nodes))
+	for i, node := range nodes {
+		if node.X == 0 {
+			fix.Fix(i, fem.DofPos)
+		}
+	}
+	n := ga.TotalDofs()
+	M := lap.NewSparse(n, n)
+	for i := 0; i < n; i++ {
+		M.Set(i, i, model.Mass().AtVec(i))
+	}
+	modes, err := fem.Modal(ga.Ksolid(), M, fix, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	omega := modes.AngularFrequency(0)
+	shape := modes.Shapes[0]
+	var scale float64
+	for i := 0; i < n; i++ {
+		scale = math.Max(scale, math.Abs(shape.AtVec(i)))
+	}
+	period := 2 * math.Pi / omega
+	var cd fem.CentralDifference
+	steps := int(period/(0.9*model.CriticalTimeStep())) + 1
+	err = cd.Dynamic(model, fix, shape, lap.NewDenseVector(n, nil), steps, func(step int, time float64, u, v, a *lap.DenseV) {
+		c := math.Cos(omega * time)
+		for i := 0; i < n; i++ {
+			if math.Abs(u.AtVec(i)-c*shape.AtVec(i)) > tol*scale {
+				t.Fatalf("step %d dof %d: want displacement %g, got %g", step, i, c*shape.AtVec(i), u.AtVec(i))
+			}
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cd.TimeStep = 1.1 * model.CriticalTimeStep()
+	err = cd.Dynamic(model, fix, shape, lap.NewDenseVector(n, nil), 1, func(int, float64, *lap.DenseV, *lap.DenseV, *lap.DenseV) {})
+	if err == nil {
+		t.Error("expected error for time step above critical")
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"math/bits"
 	"strconv"
+	"sync"
 
+	"github.com/soypat/lap"
 	"gonum.org/v1/gonum/mat"
 	"gonum.org/v1/gonum/spatial/r3"
 )
@@ -45,12 +47,54 @@ type Isoparametric interface {
 	BasisDiff(r3.Vec) []float64
 }
 
+// Faceted is an isoparametric element whose boundary is described by lower
+// dimension isoparametric elements. The boundary of 3D elements is composed of faces
+// and that of 2D elements of edges, both of which are referred to as faces.
+type Faceted interface {
+	Isoparametric
+	// LenFaces returns the number of faces of the element.
+	LenFaces() int
+	// Face returns the indices of the element's nodes that make up the ith face,
+	// in the node order of the returned face element. Face nodes are ordered
+	// so that the face normal points out of the element: faces of 3D elements are
+	// counter-clockwise when viewed from outside and edges of 2D elements follow
+	// the counter-clockwise node ordering of the element so the outward normal
+	// lies to the right of the edge.
+	Face(i int) (faceNodes []int, faceT Isoparametric)
+}
+
+// MassIsoparametric is an Isoparametric element with a quadrature for its consistent mass
+// matrix, whose integrand ρ·Nᵀ·N is of higher degree than that of the stiffness matrix.
+type MassIsoparametric interface {
+	Isoparametric
+	// MassQuadrature returns the quadrature integration positions and weights that
+	// integrate the products of two form functions of the element exactly.
+	MassQuadrature() (positions []r3.Vec, weights []float64)
+}
+
 type Element3 interface {
 	Element
 	CopyK(dst *mat.Dense, elementNodes []r3.Vec) error
 	SetConstitutive(c Constituter) error
 }
 
+// GeometricElement3 is an Element3 whose stiffness depends on its stress state,
+// which is taken into account in buckling analysis.
+type GeometricElement3 interface {
+	Element3
+	// CopyKGeometric stores the element's geometric stiffness matrix in local axes in dst
+	// given the element's displacements in local axes of a reference load case.
+	CopyKGeometric(dst *mat.Dense, elementNodes []r3.Vec, localDisplacements []float64) error
+}
+
+// AxialElement3 is an Element3 whose local X axis is defined
+// by the position of its nodes, such as a beam or truss.
+type AxialElement3 interface {
+	Element3
+	// LocalX returns the direction of the element's local X axis.
+	LocalX(elementNodes []r3.Vec) r3.Vec
+}
+
 // Constituter represents the homogenous properties of a medium
 // that can then be used to model solids or other continuous field problems.
 // For solids it returns the unmodified constitutive tensor (Generalized Hookes law).
@@ -64,6 +108,27 @@ type IsoConstituter interface {
 	SetStrainDisplacementMatrix(dstB, elemNodes, dN *mat.Dense, N *mat.VecDense) (scale float64)
 }
 
+// OrientableIsoConstituter is an IsoConstituter whose material axes
+// can be oriented arbitrarily with respect to the model's axes.
+type OrientableIsoConstituter interface {
+	IsoConstituter
+	// Oriented returns the constituter with the material's X axis pointing
+	// along x and its Y axis in the plane of x and y, perpendicular to x.
+	// Constituters of planar problems may only be rotated about the
+	// Z axis and use only x, which must then lie in the XY plane.
+	Oriented(x, y r3.Vec) (IsoConstituter, error)
+}
+
+// ThermalIsoConstituter is an IsoConstituter of a material that strains
+// when its temperature changes.
+type ThermalIsoConstituter interface {
+	IsoConstituter
+	// ThermalStrain returns the free strain of the material per unit of
+	// temperature change. It has the same layout as the rows of the strain
+	// displacement matrix.
+	ThermalStrain() []float64
+}
+
 // DofsFlag holds bitwise information of degrees of freedom.
 type DofsFlag uint16
 
@@ -117,6 +182,24 @@ func (d DofsFlag) String() (s string) {
 	return s
 }
 
+// dofComponents returns the spatial component corresponding to each of
+// the dofs set in d, in order. Position dofs X, Y and Z correspond to
+// components 0, 1 and 2 respectively. Rotational dofs are marked with -1.
+func dofComponents(d DofsFlag) []int {
+	components := make([]int, 0, d.Count())
+	for i := 0; i < maxDofsPerNode; i++ {
+		if !d.Has(1 << i) {
+			continue
+		}
+		if i < 3 {
+			components = append(components, i)
+		} else {
+			components = append(components, -1)
+		}
+	}
+	return components
+}
+
 func forEachElement(modelDofs DofsFlag, nodes []r3.Vec, elemT Element, spatialDimsPerNode, Nelem int, getElement func(i int) []int, elemCallback elementDofCallback) error {
 	if elemT == nil || getElement == nil || elemCallback == nil {
 		panic("nil argument to AddIsoparametric2") // This is very likely programmer error.
@@ -135,9 +218,62 @@ func forEachElement(modelDofs DofsFlag, nodes []r3.Vec, elemT Element, spatialDi
 		NmodelDofsPerNode = modelDofs.Count()
 	)
 
+	return forEachElementIn(0, Nelem, dofMapping, NmodelDofsPerNode, nodes, NnodperElem, spatialDimsPerNode, getElement, elemCallback)
+}
+
+// forEachElementConcurrent is like forEachElement but partitions the elements in contiguous
+// ranges that are iterated by up to workers goroutines concurrently. newWorker is called once
+// per goroutine to create its element getter and callback, which should use their own scratch space.
+// The error of the lowest element index is returned so results do not depend on the number of workers.
+func forEachElementConcurrent(workers int, modelDofs DofsFlag, nodes []r3.Vec, elemT Element, spatialDimsPerNode, Nelem int, newWorker func() (getElement func(i int) []int, elemCallback elementDofCallback)) error {
+	if elemT == nil || newWorker == nil {
+		panic("nil argument to forEachElementConcurrent") // This is very likely programmer error.
+	}
+	if workers > Nelem {
+		workers = Nelem
+	}
+	if workers <= 1 {
+		getElement, elemCallback := newWorker()
+		return forEachElement(modelDofs, nodes, elemT, spatialDimsPerNode, Nelem, getElement, elemCallback)
+	} else if spatialDimsPerNode < 1 || spatialDimsPerNode > 3 {
+		return errors.New("dimsPerNode must be 1, 2 or 3")
+	}
+	dofMapping, err := mapdofs(modelDofs, elemT.Dofs())
+	if err != nil {
+		return err
+	}
+	var (
+		NnodperElem       = elemT.LenNodes()
+		NmodelDofsPerNode = modelDofs.Count()
+		errs              = make([]error, workers)
+		wg                sync.WaitGroup
+	)
+	for w := 0; w < workers; w++ {
+		getElement, elemCallback := newWorker()
+		if getElement == nil || elemCallback == nil {
+			panic("nil worker function")
+		}
+		start, end := w*Nelem/workers, (w+1)*Nelem/workers
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			errs[w] = forEachElementIn(start, end, dofMapping, NmodelDofsPerNode, nodes, NnodperElem, spatialDimsPerNode, getElement, elemCallback)
+		}(w)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// forEachElementIn calls elemCallback for elements start to end-1.
+func forEachElementIn(start, end int, dofMapping []int, NmodelDofsPerNode int, nodes []r3.Vec, NnodperElem, spatialDimsPerNode int, getElement func(i int) []int, elemCallback elementDofCallback) error {
 	elemNodBacking := make([]float64, spatialDimsPerNode*NnodperElem)
-	elemDofs := make([]int, NmodelDofsPerNode*NnodperElem)
-	for iele := 0; iele < Nelem; iele++ {
+	elemDofs := make([]int, len(dofMapping)*NnodperElem)
+	for iele := start; iele < end; iele++ {
 		element := getElement(iele)
 		if len(element) != NnodperElem {
 			return fmt.Errorf("element #%d of %d nodes expected to be of %d nodes", iele, len(element), NnodperElem)
@@ -153,15 +289,15 @@ func forEachElement(modelDofs DofsFlag, nodes []r3.Vec, elemT Element, spatialDi
 }
 
 func mapdofs(modelDofs, elemDofs DofsFlag) (dofs []int, err error) {
-	numModelDofs := modelDofs.Count()
 	if !modelDofs.Has(elemDofs) {
 		return nil, fmt.Errorf("model dofs %s does not contain all element dofs %s", modelDofs.String(), elemDofs.String())
 	}
 	dofMapping := make([]int, elemDofs.Count())
 	idm := 0
-	for i := 0; i < numModelDofs; i++ {
-		if modelDofs.Has(1<<i) && elemDofs.Has(1<<i) {
-			dofMapping[idm] = i
+	for i := 0; i < maxDofsPerNode; i++ {
+		if elemDofs.Has(1 << i) {
+			// Position of dof among the model's dofs of a node.
+			dofMapping[idm] = bits.OnesCount16(uint16(modelDofs & (1<<i - 1)))
 			idm++
 		}
 	}
@@ -175,14 +311,17 @@ func mapdofs(modelDofs, elemDofs DofsFlag) (dofs []int, err error) {
 func NewFixity(modelDofs DofsFlag, numNodes int) Fixity {
 	return Fixity{
 		nodes:     make([]DofsFlag, numNodes),
+		values:    make([]float64, numNodes*modelDofs.Count()),
 		modelDofs: modelDofs,
 	}
 }
 
-// Fixity holds information for the fixed degrees of freedom per node.
+// Fixity holds information for the fixed degrees of freedom per node
+// and the value prescribed to them, which is zero unless set with Prescribe.
 // It assumes constant number of dofs per node.
 type Fixity struct {
 	nodes     []DofsFlag
+	values    []float64
 	modelDofs DofsFlag
 }
 
@@ -191,9 +330,40 @@ func (f Fixity) Fix(nodeIdx int, fixed DofsFlag) {
 	f.nodes[nodeIdx] |= (fixed & f.modelDofs)
 }
 
+// Prescribe fixes the dofs of the given node to value, i.e. a prescribed displacement
+// or temperature. It ignores dofs that are not in the model.
+func (f Fixity) Prescribe(nodeIdx int, dofs DofsFlag, value float64) {
+	f.Fix(nodeIdx, dofs)
+	f.setValues(nodeIdx, dofs, value)
+}
+
 // Free sets the free dofs for the given node. It ignores dofs that are not in the model.
 func (f Fixity) Free(nodeIdx int, freed DofsFlag) {
 	f.nodes[nodeIdx] &^= (freed & f.modelDofs)
+	f.setValues(nodeIdx, freed, 0)
+}
+
+// Prescribed returns a vector indexed by the model's global dofs holding the
+// prescribed values of the fixed dofs. Free dofs are zero.
+func (f Fixity) Prescribed() *lap.DenseV {
+	return lap.NewDenseVector(len(f.values), append([]float64{}, f.values...))
+}
+
+// TotalDofs returns the total number of dofs in the model.
+func (f Fixity) TotalDofs() int { return len(f.values) }
+
+func (f Fixity) setValues(nodeIdx int, dofs DofsFlag, value float64) {
+	dofsPerNode := f.modelDofs.Count()
+	j := 0
+	for i := 0; i < 16; i++ {
+		if f.modelDofs&(1<<i) == 0 {
+			continue
+		}
+		if dofs&(1<<i) != 0 {
+			f.values[nodeIdx*dofsPerNode+j] = value
+		}
+		j++
+	}
 }
 
 // FreeDofs returns the free dof indices.
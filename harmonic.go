@@ -0,0 +1,247 @@
+package fem
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/cmplx"
+
+	"github.com/soypat/lap"
+)
+
+// HarmonicResponse holds the steady-state response u(t) = Re(U·exp(i·ω·t)) of a model to harmonic loads
+// F·cos(ω·t) for a sweep of excitation frequencies ω, the solution of (K - ω²·M + i·ω·C)·U = F.
+type HarmonicResponse struct {
+	// AngularFrequencies ω of the excitation in radians per unit time.
+	AngularFrequencies []float64
+	// Displacements are the complex amplitudes U of the displacements at each frequency.
+	// They are indexed by the model's global dofs and are zero at fixed dofs.
+	Displacements [][]complex128
+	modelDofs     DofsFlag
+}
+
+// Len returns the number of frequencies of the sweep.
+func (h *HarmonicResponse) Len() int { return len(h.AngularFrequencies) }
+
+// Amplitudes returns the amplitudes |U| of the displacements at frequency i.
+func (h *HarmonicResponse) Amplitudes(i int) []float64 {
+	amp := make([]float64, len(h.Displacements[i]))
+	for dof, u := range h.Displacements[i] {
+		amp[dof] = cmplx.Abs(u)
+	}
+	return amp
+}
+
+// Phases returns the phase angles arg(U) in radians of the displacements at frequency i,
+// in (-π, π]. Negative angles are displacements that lag behind the loads.
+func (h *HarmonicResponse) Phases(i int) []float64 {
+	phase := make([]float64, len(h.Displacements[i]))
+	for dof, u := range h.Displacements[i] {
+		phase[dof] = cmplx.Phase(u)
+	}
+	return phase
+}
+
+// FRF returns the complex displacement amplitudes of a single dof of a node over the frequency sweep.
+// If the loads are a unit force at an input dof it is the frequency response function, or receptance,
+// between the input dof and the node's dof. It panics if dof is not a single dof of the model.
+func (h *HarmonicResponse) FRF(node int, dof DofsFlag) []complex128 {
+	if dof.Count() != 1 || !h.modelDofs.Has(dof) {
+		panic(fmt.Sprintf("dof %s is not a single dof of model dofs %s", dof, h.modelDofs))
+	}
+	global := node*h.modelDofs.Count() + (h.modelDofs & (dof - 1)).Count()
+	frf := make([]complex128, h.Len())
+	for i, U := range h.Displacements {
+		frf[i] = U[global]
+	}
+	return frf
+}
+
+// Harmonic returns the steady-state harmonic response of a model with stiffness matrix K, mass
+// matrix M and damping matrix C to loads of amplitude F for each of the angular frequencies ω in
+// omegas. K and M are usually those returned by GeneralAssembler.Ksolid and GeneralAssembler.Msolid
+// and C is usually built with RayleighDamping or returned by GeneralAssembler.Csolid. The model is
+// undamped if C is nil. The fixed dofs of fix are held at zero regardless of their prescribed values.
+//
+// The complex dynamic stiffness matrix is factorized for each frequency with an LDLᵀ factorization
+// that shares the symbolic analysis of SparseCholesky. Undamped models can't be solved at their
+// natural frequencies, where the response is infinite.
+func Harmonic(K, M, C lap.Matrix, fix Fixity, loads lap.Vector, omegas []float64) (*HarmonicResponse, error) {
+	n, _ := K.Dims()
+	if C == nil {
+		C = lap.NewSparse(n, n)
+	}
+	// Matrices are stored with the same sparsity pattern so their values can be combined entry by entry.
+	var parts [3]staticPartition
+	var free [3]*CSR
+	for k := range free {
+		coefs := []float64{0, 0, 0}
+		coefs[k] = 1
+		A, err := sumCSR(coefs, K, M, C)
+		if err != nil {
+			return nil, err
+		}
+		free[k], err = parts[k].reset(A, fix)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if loads.Len() != n {
+		return nil, fmt.Errorf("loads vector length %d does not match stiffness matrix dimension %d", loads.Len(), n)
+	}
+	Kff, Mff, Cff := free[0], free[1], free[2]
+	part := &parts[0]
+	var ldl complexLDL
+	err := ldl.analyze(Kff)
+	if err != nil {
+		return nil, err
+	}
+	h := &HarmonicResponse{modelDofs: fix.modelDofs}
+	vals := make([]complex128, len(Kff.val))
+	for _, omega := range omegas {
+		if omega < 0 || math.IsNaN(omega) || math.IsInf(omega, 0) {
+			return nil, fmt.Errorf("angular frequency must be a non-negative finite number, got %g", omega)
+		}
+		for p := range vals {
+			vals[p] = complex(Kff.val[p]-omega*omega*Mff.val[p], omega*Cff.val[p])
+		}
+		err = ldl.factorize(Kff, vals)
+		if err != nil {
+			return nil, fmt.Errorf("factorizing dynamic stiffness matrix at angular frequency %g: %w", omega, err)
+		}
+		uf := make([]complex128, len(part.free))
+		for i, dof := range part.free {
+			uf[i] = complex(loads.AtVec(dof), 0)
+		}
+		ldl.solve(uf)
+		U := make([]complex128, n)
+		for i, dof := range part.free {
+			U[dof] = uf[i]
+		}
+		h.AngularFrequencies = append(h.AngularFrequencies, omega)
+		h.Displacements = append(h.Displacements, U)
+	}
+	return h, nil
+}
+
+// complexLDL is the LDLᵀ factorization of a complex symmetric matrix with the
+// sparsity pattern of a CSR matrix, computed the same way as SparseCholesky.factorize.
+type complexLDL struct {
+	// sc holds the symbolic analysis.
+	sc SparseCholesky
+	li []int
+	lx []complex128
+	d  []complex128
+}
+
+// analyze computes the ordering and symbolic factorization of A's sparsity pattern.
+func (cl *complexLDL) analyze(A *CSR) error {
+	sc := &cl.sc
+	err := sc.analyze(A)
+	if err != nil {
+		return err
+	}
+	cl.li = make([]int, sc.lp[sc.n])
+	cl.lx = make([]complex128, sc.lp[sc.n])
+	cl.d = make([]complex128, sc.n)
+	return nil
+}
+
+// factorize computes the factorization of the matrix with the
+// sparsity pattern of A, as analyzed by analyze, and values vals.
+func (cl *complexLDL) factorize(A *CSR, vals []complex128) error {
+	sc := &cl.sc
+	n := sc.n
+	var (
+		y       = make([]complex128, n)
+		pattern = make([]int, n)
+		flag    = make([]int, n)
+		lnz     = make([]int, n)
+	)
+	for k := 0; k < n; k++ {
+		y[k] = 0
+		top := n
+		flag[k] = k
+		kk := sc.perm[k]
+		var akk complex128
+		for p := A.rowPtr[kk]; p < A.rowPtr[kk+1]; p++ {
+			i := sc.pinv[A.col[p]]
+			if i > k {
+				continue
+			}
+			if i == k {
+				akk = vals[p]
+			}
+			y[i] += vals[p]
+			length := 0
+			for flag[i] != k {
+				pattern[length] = i
+				length++
+				flag[i] = k
+				i = sc.parent[i]
+				if i < 0 || i > k {
+					return errPatternMismatch
+				}
+			}
+			for length > 0 {
+				top--
+				length--
+				pattern[top] = pattern[length]
+			}
+		}
+		cl.d[k] = y[k]
+		y[k] = 0
+		for ; top < n; top++ {
+			i := pattern[top]
+			yi := y[i]
+			y[i] = 0
+			p2 := sc.lp[i] + lnz[i]
+			if p2 >= sc.lp[i+1] {
+				return errPatternMismatch
+			}
+			for p := sc.lp[i]; p < p2; p++ {
+				y[cl.li[p]] -= cl.lx[p] * yi
+			}
+			lki := yi / cl.d[i]
+			cl.d[k] -= lki * yi
+			cl.li[p2] = k
+			cl.lx[p2] = lki
+			lnz[i]++
+		}
+		if cl.d[k] == 0 || cmplx.Abs(cl.d[k]) <= 1e-14*cmplx.Abs(akk) || cmplx.IsNaN(cl.d[k]) {
+			return errors.New("zero pivot, matrix is singular")
+		}
+	}
+	return nil
+}
+
+// solve solves A·x = b in place, overwriting b with x.
+func (cl *complexLDL) solve(b []complex128) {
+	sc := &cl.sc
+	n := sc.n
+	x := make([]complex128, n)
+	for k, p := range sc.perm {
+		x[k] = b[p]
+	}
+	// L·y = b
+	for j := 0; j < n; j++ {
+		xj := x[j]
+		for p := sc.lp[j]; p < sc.lp[j+1]; p++ {
+			x[cl.li[p]] -= cl.lx[p] * xj
+		}
+	}
+	for j := range x {
+		x[j] /= cl.d[j]
+	}
+	// Lᵀ·x = z
+	for j := n - 1; j >= 0; j-- {
+		xj := x[j]
+		for p := sc.lp[j]; p < sc.lp[j+1]; p++ {
+			xj -= cl.lx[p] * x[cl.li[p]]
+		}
+		x[j] = xj
+	}
+	for k, p := range sc.perm {
+		b[p] = x[k]
+	}
+}
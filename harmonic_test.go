@@ -0,0 +1,142 @@
+package fem_test
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+
+	"github.com/soypat/go-fem"
+	"github.com/soypat/lap"
+)
+
+func TestHarmonicOscillator(t *testing.T) {
+	// Single degree of freedom oscillator: U = F/(k - ω²·m + i·ω·c).
+	const (
+		tol  = 1e-12
+		k    = 400.
+		m    = 4.
+		zeta = 0.05
+		F    = 3.
+	)
+	omegaN := math.Sqrt(k / m)
+	c := 2 * zeta * omegaN * m
+	K := lap.NewSparse(1, 1)
+	K.Set(0, 0, k)
+	M := lap.NewSparse(1, 1)
+	M.Set(0, 0, m)
+	C := lap.NewSparse(1, 1)
+	C.Set(0, 0, c)
+	fix := fem.NewFixity(fem.DofPosX, 1)
+	omegas := []float64{0, 0.5 * omegaN, omegaN, 2 * omegaN, 10 * omegaN}
+	h, err := fem.Harmonic(K, M, C, fix, lap.NewDenseVector(1, []float64{F}), omegas)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Len() != len(omegas) {
+		t.Fatalf("expected %d frequencies, got %d", len(omegas), h.Len())
+	}
+	frf := h.FRF(0, fem.DofPosX)
+	for i, w := range omegas {
+		want := complex(F, 0) / complex(k-w*w*m, w*c)
+		if cmplx.Abs(frf[i]-want) > tol*cmplx.Abs(want) {
+			t.Errorf("ω=%g: want displacement %g, got %g", w, want, frf[i])
+		}
+		wantAmp := F / math.Hypot(k-w*w*m, w*c)
+		if got := h.Amplitudes(i)[0]; math.Abs(got-wantAmp) > tol*wantAmp {
+			t.Errorf("ω=%g: want amplitude %g, got %g", w, wantAmp, got)
+		}
+		// Displacement lags behind the load by arctan(c·ω/(k - ω²·m)).
+		wantPhase := -math.Atan2(w*c, k-w*w*m)
+		if got := h.Phases(i)[0]; math.Abs(got-wantPhase) > 1e-12 {
+			t.Errorf("ω=%g: want phase %g, got %g", w, wantPhase, got)
+		}
+	}
+	if phase := h.Phases(2)[0]; math.Abs(phase+math.Pi/2) > 1e-12 {
+		t.Errorf("want phase -π/2 at resonance, got %g", phase)
+	}
+	// Undamped oscillator can't be solved at resonance.
+	_, err = fem.Harmonic(K, M, nil, fix, lap.NewDenseVector(1, []float64{F}), []float64{omegaN})
+	if err == nil {
+		t.Error("expected error at resonance of undamped oscillator")
+	}
+}
+
+func TestHarmonicPlate(t *testing.T) {
+	const tol = 1e-8
+	ga, fix := vibratingPlate(t, []float64{0, 0.25, 0.5, 0.75, 1}, []float64{0, 0.1})
+	K, M := ga.Ksolid(), ga.Msolid()
+	n := ga.TotalDofs()
+	modes, err := fem.Modal(K, M, fix, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w1, w2 := modes.AngularFrequency(0), modes.AngularFrequency(1)
+	alpha, beta, err := fem.RayleighCoefficients(w1, 0.02, w2, 0.02)
+	if err != nil {
+		t.Fatal(err)
+	}
+	C, err := fem.RayleighDamping(M, K, alpha, beta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Unit transverse load at the tip of the plate.
+	const tip = 9
+	loads := lap.NewDenseVector(n, nil)
+	loads.SetVec(2*tip+1, 1)
+	omegas := []float64{0, 0.5 * w1, w1, (w1 + w2) / 2, w2}
+	h, err := fem.Harmonic(K, M, C, fix, loads, omegas)
+	if err != nil {
+		t.Fatal(err)
+	}
+	static, _, err := fem.LinearStatic(K, loads, fix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scale := math.Abs(static.AtVec(2*tip + 1))
+	for i := 0; i < n; i++ {
+		if got := h.Displacements[0][i]; cmplx.Abs(got-complex(static.AtVec(i), 0)) > tol*scale {
+			t.Errorf("dof %d: want static displacement %g at ω=0, got %g", i, static.AtVec(i), got)
+		}
+	}
+	for _, dof := range fix.FixedDofs() {
+		for i := range omegas {
+			if h.Displacements[i][dof] != 0 {
+				t.Errorf("ω=%g: fixed dof %d has displacement %g", omegas[i], dof, h.Displacements[i][dof])
+			}
+		}
+	}
+	// Residual of (K - ω²·M + i·ω·C)·U = F split in real and imaginary parts.
+	Ur, Ui := lap.NewDenseVector(n, nil), lap.NewDenseVector(n, nil)
+	KUr, KUi := lap.NewDenseVector(n, nil), lap.NewDenseVector(n, nil)
+	MUr, MUi := lap.NewDenseVector(n, nil), lap.NewDenseVector(n, nil)
+	CUr, CUi := lap.NewDenseVector(n, nil), lap.NewDenseVector(n, nil)
+	for i, w := range omegas {
+		for dof, u := range h.Displacements[i] {
+			Ur.SetVec(dof, real(u))
+			Ui.SetVec(dof, imag(u))
+		}
+		KUr.MulVec(K, Ur)
+		KUi.MulVec(K, Ui)
+		MUr.MulVec(M, Ur)
+		MUi.MulVec(M, Ui)
+		CUr.MulVec(C, Ur)
+		CUi.MulVec(C, Ui)
+		for _, dof := range fix.FreeDofs() {
+			re := KUr.AtVec(dof) - w*w*MUr.AtVec(dof) - w*CUi.AtVec(dof) - loads.AtVec(dof)
+			im := KUi.AtVec(dof) - w*w*MUi.AtVec(dof) + w*CUr.AtVec(dof)
+			if math.Hypot(re, im) > tol {
+				t.Errorf("ω=%g dof %d: residual %g", w, dof, complex(re, im))
+			}
+		}
+	}
+	// Tip response peaks at the first natural frequency with a phase lag close to π/2.
+	frf := h.FRF(tip, fem.DofPosY)
+	for i := range frf {
+		if i != 2 && cmplx.Abs(frf[i]) >= cmplx.Abs(frf[2]) {
+			t.Errorf("ω=%g: response %g exceeds response at first natural frequency %g", omegas[i], cmplx.Abs(frf[i]), cmplx.Abs(frf[2]))
+		}
+	}
+	if phase := cmplx.Phase(frf[2]); math.Abs(phase+math.Pi/2) > 0.1 {
+		t.Errorf("want phase close to -π/2 at first natural frequency, got %g", phase)
+	}
+}
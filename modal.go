@@ -0,0 +1,393 @@
+package fem
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"math/rand"
+	"sort"
+
+	"github.com/soypat/lap"
+	"gonum.org/v1/gonum/mat"
+)
+
+// Modes holds the natural modes of vibration of a model, the solution of the
+// generalized eigenvalue problem K·φ = ω²·M·φ, in ascending order of frequency.
+type Modes struct {
+	// Eigenvalues ω² of the modes, where ω is the angular frequency in radians per unit time.
+	Eigenvalues []float64
+	// Shapes are the mode shapes φ normalised to the mass matrix so that φᵀ·M·φ = 1.
+	// They are indexed by the model's global dofs and are zero at fixed dofs.
+	Shapes []*lap.DenseV
+	// Directions are the model's translational dofs, i.e. DofPosX, in the
+	// order of the participation factors and effective masses of each mode.
+	Directions []DofsFlag
+	// ParticipationFactors Γ = φᵀ·M·r of each mode and direction, where r is the
+	// unit rigid body translation of the free dofs in the direction.
+	ParticipationFactors [][]float64
+	// EffectiveMasses Γ² of each mode and direction. Their sum over all modes
+	// is the mass of the model's free dofs moving in the direction.
+	EffectiveMasses [][]float64
+}
+
+// Len returns the number of modes.
+func (m *Modes) Len() int { return len(m.Eigenvalues) }
+
+// AngularFrequency returns the angular frequency ω of mode i in radians per unit time.
+func (m *Modes) AngularFrequency(i int) float64 { return math.Sqrt(math.Max(m.Eigenvalues[i], 0)) }
+
+// Frequency returns the natural frequency f = ω/2π of mode i in cycles per unit time, i.e. Hz.
+func (m *Modes) Frequency(i int) float64 { return m.AngularFrequency(i) / (2 * math.Pi) }
+
+// Modal returns the nModes lowest natural modes of vibration of a model with stiffness
+// matrix K and mass matrix M, usually those returned by GeneralAssembler.Ksolid and
+// GeneralAssembler.Msolid. The fixed dofs of fix are held at zero regardless of their
+// prescribed values. It uses SubspaceIteration with its default values.
+func Modal(K, M lap.Matrix, fix Fixity, nModes int) (*Modes, error) {
+	var si SubspaceIteration
+	return si.Modal(K, M, fix, nModes)
+}
+
+// SubspaceIteration solves generalized symmetric eigenvalue problems A·φ = λ·B·φ for the
+// eigenvalues closest to Shift, with B positive semi-definite. Each iteration solves the
+// shifted problem (A-σ·B)·X = B·X for a subspace of vectors with a single SparseCholesky
+// factorization and projects the problem onto it.
+type SubspaceIteration struct {
+	// Shift σ of the eigenvalues. The lowest eigenvalues are found when
+	// the shift is below them. A negative shift is required to find the rigid
+	// body modes of unrestrained models, which have zero eigenvalues. It should
+	// be of the order of the eigenvalues sought for the iteration to converge quickly.
+	Shift float64
+	// Tolerance is the relative change of the eigenvalues between iterations
+	// at which the iteration is stopped. Defaults to 1e-10.
+	Tolerance float64
+	// MaxIterations is the maximum number of iterations. Defaults to 100.
+	MaxIterations int
+	// SubspaceSize is the number of vectors iterated. Defaults to
+	// the largest of 2·nModes and nModes+8, at most the problem's size.
+	SubspaceSize int
+}
+
+// Modal returns the nModes natural modes of vibration closest to the shift.
+// See the Modal function for details. A NotConvergedError is returned if the tolerance is not reached.
+func (si *SubspaceIteration) Modal(K, M lap.Matrix, fix Fixity, nModes int) (*Modes, error) {
+	var part staticPartition
+	_, err := part.reset(K, fix)
+	if err != nil {
+		return nil, err
+	}
+	r, c := M.Dims()
+	if r != part.n || c != part.n {
+		return nil, fmt.Errorf("mass matrix dimensions %dx%d do not match stiffness matrix dimension %d", r, c, part.n)
+	}
+	values, vectors, err := si.solve(K, M, true, part.freeIdx, len(part.free), nModes)
+	if err != nil {
+		return nil, err
+	}
+	idx := sortedIndices(values, func(a, b float64) bool { return a < b })
+	modes := &Modes{}
+	for _, k := range idx {
+		modes.Eigenvalues = append(modes.Eigenvalues, values[k])
+		modes.Shapes = append(modes.Shapes, expandFree(vectors[k], part.free, part.n))
+	}
+	modes.participation(M, fix.modelDofs, part.freeIdx)
+	return modes, nil
+}
+
+// participation calculates the participation factors and effective masses
+// of the modes for the translational dofs of the model.
+func (m *Modes) participation(M lap.Matrix, modelDofs DofsFlag, freeIdx []int) {
+	n, _ := M.Dims()
+	dofsPerNode := modelDofs.Count()
+	var influences [][]float64
+	for i := 0; i < 3; i++ {
+		dir := DofsFlag(1 << i)
+		if !modelDofs.Has(dir) {
+			continue
+		}
+		m.Directions = append(m.Directions, dir)
+		// M·r is the sum of the columns of M of the direction's free dofs.
+		pos := bits.OnesCount16(uint16(modelDofs & (dir - 1)))
+		Mr := make([]float64, n)
+		doNonZero(M, func(i, j int, v float64) {
+			if j%dofsPerNode == pos && freeIdx[j] >= 0 {
+				Mr[i] += v
+			}
+		})
+		influences = append(influences, Mr)
+	}
+	for _, shape := range m.Shapes {
+		factors := make([]float64, len(influences))
+		masses := make([]float64, len(influences))
+		for d, Mr := range influences {
+			for i, v := range Mr {
+				factors[d] += shape.AtVec(i) * v
+			}
+			masses[d] = factors[d] * factors[d]
+		}
+		m.ParticipationFactors = append(m.ParticipationFactors, factors)
+		m.EffectiveMasses = append(m.EffectiveMasses, masses)
+	}
+}
+
+// solve returns the p eigenpairs closest to the shift of A·φ = λ·B·φ restricted to the
+// n free dofs mapped by freeIdx, where fixed dofs are -1, in order of distance to the shift.
+// Eigenvectors are indexed by free dofs. If definiteB is true B must be positive semi-definite
+// and the eigenvectors are B-orthonormal. Otherwise A-σ·B must be positive definite and the
+// eigenvectors are orthonormal with respect to it.
+func (si *SubspaceIteration) solve(A, B lap.Matrix, definiteB bool, freeIdx []int, n, p int) (values []float64, vectors [][]float64, err error) {
+	switch {
+	case p < 1:
+		return nil, nil, errors.New("number of modes must be positive")
+	case p > n:
+		return nil, nil, fmt.Errorf("number of modes %d exceeds number of free dofs %d", p, n)
+	}
+	tol := si.Tolerance
+	if tol == 0 {
+		tol = 1e-10
+	}
+	maxIter := si.MaxIterations
+	if maxIter == 0 {
+		maxIter = 100
+	}
+	shifted := newCSRBuilder(n)
+	Bf := newCSRBuilder(n)
+	doNonZero(A, func(i, j int, v float64) {
+		if fi, fj := freeIdx[i], freeIdx[j]; fi >= 0 && fj >= 0 {
+			shifted.add(fi, fj, v)
+		}
+	})
+	doNonZero(B, func(i, j int, v float64) {
+		if fi, fj := freeIdx[i], freeIdx[j]; fi >= 0 && fj >= 0 {
+			shifted.add(fi, fj, -si.Shift*v)
+			Bf.add(fi, fj, v)
+		}
+	})
+	shifted.compress()
+	Bf.compress()
+	var chol SparseCholesky
+	err = chol.analyze(shifted)
+	if err == nil {
+		err = chol.factorize(shifted)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("factorizing shifted matrix, check model fixity or use a shift below the lowest eigenvalue: %w", err)
+	}
+	// Dofs with non-zero diagonal, such as those with mass, which bound the rank of B.
+	diagB := Bf.diagonal()
+	diagA := shifted.diagonal()
+	var active []int
+	for i, v := range diagB {
+		diagB[i] = math.Abs(v)
+		if v != 0 {
+			active = append(active, i)
+		}
+	}
+	q := si.SubspaceSize
+	if q == 0 {
+		q = 2 * p
+		if p+8 > q {
+			q = p + 8
+		}
+	}
+	if q > len(active) {
+		q = len(active)
+	}
+	if q < p {
+		return nil, nil, fmt.Errorf("subspace size %d is less than number of modes %d or number of dofs with mass", q, p)
+	}
+	X := subspaceStart(diagA, diagB, active, q)
+	// Y = B·X and Z = B·Xbar.
+	Y := make([][]float64, q)
+	Z := make([][]float64, q)
+	for j := range X {
+		Y[j] = make([]float64, n)
+		Z[j] = make([]float64, n)
+		Bf.MulVecTo(Y[j], X[j])
+	}
+	var (
+		Ar   = mat.NewSymDense(q, nil)
+		Br   = mat.NewSymDense(q, nil)
+		mu   []float64
+		prev []float64
+	)
+	for iter := 1; ; iter++ {
+		// Xbar = (A-σ·B)⁻¹·B·X
+		for j := range X {
+			copy(X[j], Y[j])
+			chol.solve(X[j])
+			// Scaling the vectors improves the conditioning of the projected problem.
+			scale := 1 / norm(X[j])
+			for i := range X[j] {
+				X[j][i] *= scale
+				Y[j][i] *= scale
+			}
+			Bf.MulVecTo(Z[j], X[j])
+		}
+		// Projected matrices Ar = Xbarᵀ·(A-σ·B)·Xbar = Xbarᵀ·Y and Br = Xbarᵀ·B·Xbar.
+		for i := 0; i < q; i++ {
+			for j := i; j < q; j++ {
+				Ar.SetSym(i, j, dot(X[i], Y[j]))
+				Br.SetSym(i, j, dot(X[i], Z[j]))
+			}
+		}
+		var Q *mat.Dense
+		mu, Q, err = projectedEigen(Ar, Br, definiteB)
+		if err != nil {
+			return nil, nil, err
+		}
+		// X = Xbar·Q is B-orthonormal and B·X = Z·Q.
+		X = combine(X, Q)
+		Y = combine(Z, Q)
+		var change float64
+		for i := 0; i < p && prev != nil; i++ {
+			// Shifted eigenvalues vanish when the shift is at an eigenvalue, so the
+			// change is measured relative to the shift or the largest eigenvalue sought.
+			scale := math.Max(math.Abs(mu[i]), math.Max(math.Abs(si.Shift), math.Abs(mu[p-1])))
+			if scale == 0 {
+				scale = 1
+			}
+			change = math.Max(change, math.Abs(mu[i]-prev[i])/scale)
+		}
+		if prev != nil && change <= tol {
+			break
+		} else if iter >= maxIter {
+			return nil, nil, &NotConvergedError{Iterations: iter, Residual: change}
+		}
+		prev = append(prev[:0], mu...)
+	}
+	values = make([]float64, p)
+	for i := range values {
+		values[i] = mu[i] + si.Shift
+	}
+	return values, X[:p], nil
+}
+
+// subspaceStart returns the q starting vectors of the subspace iteration. The first
+// is the diagonal of B, followed by unit vectors at the dofs with the largest B/A diagonal
+// ratios and a random vector, as suggested by Bathe. active are the dofs with non-zero B diagonal.
+func subspaceStart(diagA, diagB []float64, active []int, q int) [][]float64 {
+	n := len(diagB)
+	X := make([][]float64, q)
+	for j := range X {
+		X[j] = make([]float64, n)
+	}
+	copy(X[0], diagB)
+	ratio := func(i int) float64 { return diagB[i] / math.Abs(diagA[i]) }
+	candidates := append([]int{}, active...)
+	sort.SliceStable(candidates, func(i, j int) bool { return ratio(candidates[i]) > ratio(candidates[j]) })
+	units := q - 1
+	if q > 2 {
+		units = q - 2
+		rng := rand.New(rand.NewSource(1))
+		for _, i := range active {
+			X[q-1][i] = rng.Float64()
+		}
+	}
+	for j := 0; j < units; j++ {
+		X[j+1][candidates[j]] = 1
+	}
+	return X
+}
+
+// projectedEigen solves the projected eigenvalue problem Ar·Q = Br·Q·μ and returns the
+// eigenvalues μ sorted by increasing magnitude and the eigenvectors Q, which are Br-orthonormal
+// if definiteB is true. Otherwise Ar must be positive definite and Q is Ar-orthonormal.
+func projectedEigen(Ar, Br *mat.SymDense, definiteB bool) (mu []float64, Q *mat.Dense, err error) {
+	q := Ar.SymmetricDim()
+	P, S := Br, Ar
+	if !definiteB {
+		// Solve Br·Q = Ar·Q·ν with ν = 1/μ.
+		P, S = Ar, Br
+	}
+	var chol mat.Cholesky
+	if !chol.Factorize(P) {
+		if !definiteB {
+			return nil, nil, errors.New("projected shifted matrix is not positive definite, check the shift and model fixity")
+		}
+		return nil, nil, errors.New("subspace vectors are linearly dependent")
+	}
+	// C = L⁻¹·S·L⁻ᵀ where P = L·Lᵀ.
+	var L mat.TriDense
+	chol.LTo(&L)
+	var LinvS, C mat.Dense
+	err = LinvS.Solve(&L, S)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = C.Solve(&L, LinvS.T())
+	if err != nil {
+		return nil, nil, err
+	}
+	Cs := mat.NewSymDense(q, nil)
+	for i := 0; i < q; i++ {
+		for j := i; j < q; j++ {
+			Cs.SetSym(i, j, (C.At(i, j)+C.At(j, i))/2)
+		}
+	}
+	var eig mat.EigenSym
+	if !eig.Factorize(Cs, true) {
+		return nil, nil, errors.New("projected eigenvalue decomposition failed")
+	}
+	values := eig.Values(nil)
+	if !definiteB {
+		for i, nu := range values {
+			values[i] = 1 / nu
+		}
+	}
+	var V, W mat.Dense
+	eig.VectorsTo(&V)
+	// Q = L⁻ᵀ·V
+	err = W.Solve(L.T(), &V)
+	if err != nil {
+		return nil, nil, err
+	}
+	idx := sortedIndices(values, func(a, b float64) bool { return math.Abs(a) < math.Abs(b) })
+	mu = make([]float64, q)
+	Q = mat.NewDense(q, q, nil)
+	for j, k := range idx {
+		mu[j] = values[k]
+		for i := 0; i < q; i++ {
+			Q.Set(i, j, W.At(i, k))
+		}
+	}
+	return mu, Q, nil
+}
+
+// sortedIndices returns the indices of values sorted by less.
+func sortedIndices(values []float64, less func(a, b float64) bool) []int {
+	idx := make([]int, len(values))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool { return less(values[idx[i]], values[idx[j]]) })
+	return idx
+}
+
+// combine returns the linear combinations of vectors X given by the columns of Q.
+func combine(X [][]float64, Q *mat.Dense) [][]float64 {
+	_, c := Q.Dims()
+	out := make([][]float64, c)
+	for j := range out {
+		v := make([]float64, len(X[0]))
+		for k, x := range X {
+			if f := Q.At(k, j); f != 0 {
+				for i, xi := range x {
+					v[i] += f * xi
+				}
+			}
+		}
+		out[j] = v
+	}
+	return out
+}
+
+// expandFree returns the vector of all n dofs given the values of the free dofs.
+func expandFree(vf []float64, free []int, n int) *lap.DenseV {
+	v := lap.NewDenseVector(n, nil)
+	for i, dof := range free {
+		v.SetVec(dof, vf[i])
+	}
+	return v
+}
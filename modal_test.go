@@ -0,0 +1,294 @@
+package fem_test
+
+import (
+	"math"
+	"sort"
+	"testing"
+
+	"github.com/soypat/go-fem"
+	"github.com/soypat/go-fem/constitution/solids"
+	"github.com/soypat/go-fem/elements"
+	"github.com/soypat/lap"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+func TestModal(t *testing.T) {
+	const (
+		tol    = 1e-8
+		nModes = 5
+	)
+	ga, fix := vibratingPlate(t, []float64{0, 0.5, 1, 1.5, 2, 2.5, 3}, []float64{0, 0.25, 0.5})
+	K, M := ga.Ksolid(), ga.Msolid()
+	modes, err := fem.Modal(K, M, fix, nModes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := denseEigenvalues(t, K, M, fix.FreeDofs())
+	if modes.Len() != nModes {
+		t.Fatalf("expected %d modes, got %d", nModes, modes.Len())
+	}
+	n := ga.TotalDofs()
+	Kphi := lap.NewDenseVector(n, nil)
+	Mphi := lap.NewDenseVector(n, nil)
+	for i, got := range modes.Eigenvalues {
+		if math.Abs(got-want[i]) > tol*want[i] {
+			t.Errorf("mode %d: want eigenvalue %g, got %g", i, want[i], got)
+		}
+		if f := modes.Frequency(i); math.Abs(f-math.Sqrt(want[i])/(2*math.Pi)) > tol*f {
+			t.Errorf("mode %d: bad frequency %g", i, f)
+		}
+		phi := modes.Shapes[i]
+		Kphi.MulVec(K, phi)
+		Mphi.MulVec(M, phi)
+		var residual, scale float64
+		for _, dof := range fix.FreeDofs() {
+			r := Kphi.AtVec(dof) - got*Mphi.AtVec(dof)
+			residual += r * r
+			scale += Kphi.AtVec(dof) * Kphi.AtVec(dof)
+		}
+		if math.Sqrt(residual) > 1e-6*math.Sqrt(scale) {
+			t.Errorf("mode %d: residual ‖K·φ-ω²·M·φ‖=%g", i, math.Sqrt(residual))
+		}
+		for j := 0; j <= i; j++ {
+			wantDot := 0.0
+			if i == j {
+				wantDot = 1
+			}
+			if got := lap.Dot(modes.Shapes[j], Mphi); math.Abs(got-wantDot) > 1e-8 {
+				t.Errorf("modes %d,%d: want φᵀ·M·φ=%g, got %g", i, j, wantDot, got)
+			}
+		}
+	}
+}
+
+func TestModalRigidBody(t *testing.T) {
+	const tol = 1e-8
+	ga, _ := vibratingPlate(t, []float64{0, 0.5, 1, 1.5, 2}, []float64{0, 0.5})
+	free := fem.NewFixity(fem.DofPosX|fem.DofPosY, ga.TotalDofs()/2)
+	_, err := fem.Modal(ga.Ksolid(), ga.Msolid(), free, 4)
+	if err == nil {
+		t.Error("expected error factorizing unrestrained model without shift")
+	}
+	want := denseEigenvalues(t, ga.Ksolid(), ga.Msolid(), free.FreeDofs())
+	si := fem.SubspaceIteration{Shift: -want[3] / 10}
+	modes, err := si.Modal(ga.Ksolid(), ga.Msolid(), free, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Plane models have 3 rigid body modes.
+	for i := 0; i < 3; i++ {
+		if math.Abs(modes.Eigenvalues[i]) > tol*want[3] {
+			t.Errorf("mode %d: expected zero eigenvalue of rigid body mode, got %g", i, modes.Eigenvalues[i])
+		}
+	}
+	if math.Abs(modes.Eigenvalues[3]-want[3]) > tol*want[3] {
+		t.Errorf("want first elastic eigenvalue %g, got %g", want[3], modes.Eigenvalues[3])
+	}
+}
+
+func TestModalShiftAtEigenvalue(t *testing.T) {
+	const tol = 1e-12
+	ga, fix := vibratingPlate(t, []float64{0, 0.5, 1, 1.5, 2}, []float64{0, 0.5})
+	want := denseEigenvalues(t, ga.Ksolid(), ga.Msolid(), fix.FreeDofs())
+	// The eigenvalue closest to the shift is close to zero after shifting.
+	si := fem.SubspaceIteration{Shift: want[0] * (1 + 1e-4), Tolerance: tol}
+	modes, err := si.Modal(ga.Ksolid(), ga.Msolid(), fix, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := modes.Eigenvalues[0]; math.Abs(got-want[0]) > 1e-8*want[0] {
+		t.Errorf("want eigenvalue %g at shift, got %g", want[0], got)
+	}
+}
+
+func TestModalParticipation(t *testing.T) {
+	const tol = 1e-8
+	ga, fix := vibratingPlate(t, []float64{0, 0.5, 1}, []float64{0, 0.5, 1})
+	free := fix.FreeDofs()
+	modes, err := fem.Modal(ga.Ksolid(), ga.Msolid(), fix, len(free))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(modes.Directions) != 2 || modes.Directions[0] != fem.DofPosX || modes.Directions[1] != fem.DofPosY {
+		t.Fatalf("expected X and Y directions, got %v", modes.Directions)
+	}
+	// Sum of effective masses of all modes is the mass of the free dofs.
+	for d := range modes.Directions {
+		var wantMass, sum float64
+		for _, i := range free {
+			for _, j := range free {
+				if i%2 == d && j%2 == d {
+					wantMass += ga.Msolid().At(i, j)
+				}
+			}
+		}
+		for i := range modes.EffectiveMasses {
+			sum += modes.EffectiveMasses[i][d]
+			if m := modes.ParticipationFactors[i][d]; math.Abs(m*m-modes.EffectiveMasses[i][d]) > tol*wantMass {
+				t.Errorf("mode %d: effective mass is not squared participation factor", i)
+			}
+		}
+		if math.Abs(sum-wantMass) > tol*wantMass {
+			t.Errorf("direction %d: want total effective mass %g, got %g", d, wantMass, sum)
+		}
+	}
+}
+
+func TestModalTetra(t *testing.T) {
+	// Axial vibration of a bar of tetrahedra fixed at x=0 with lateral motion restrained,
+	// whose angular frequencies are (2k-1)·π/(2L)·√(E/ρ).
+	const (
+		L       = 1.0
+		E       = 200e9
+		density = 7800.
+	)
+	c := solids.Isotropic{E: E, Poisson: 0}.Solid3D()
+	for _, test := range []struct {
+		elemT fem.Isoparametric
+		nx    int
+		tol   float64
+	}{
+		{elemT: elements.Tetra4{}, nx: 20, tol: 3e-3},
+		{elemT: elements.Tetra10{}, nx: 4, tol: 1.2e-3},
+	} {
+		xs := make([]float64, test.nx+1)
+		for i := range xs {
+			xs[i] = L * float64(i) / float64(test.nx)
+		}
+		nodes, tetras := tetraMesh(xs, []float64{0, 0.1}, []float64{0, 0.1}, test.elemT.LenNodes() == 10)
+		ga := fem.NewGeneralAssembler(nodes, test.elemT.Dofs())
+		getElement := func(i int) ([]int, r3.Vec, r3.Vec) { return tetras[i], r3.Vec{}, r3.Vec{} }
+		err := ga.AddIsoparametric(test.elemT, c, len(tetras), getElement)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ga.AddIsoparametricMass(test.elemT, c, density, len(tetras), getElement)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fix := fem.NewFixity(test.elemT.Dofs(), len(nodes))
+		for i, node := range nodes {
+			fix.Fix(i, fem.DofPosY|fem.DofPosZ)
+			if node.X == 0 {
+				fix.Fix(i, fem.DofPosX)
+			}
+		}
+		modes, err := fem.Modal(ga.Ksolid(), ga.Msolid(), fix, 2)
+		if err != nil {
+			t.Fatalf("%s: %s", test.elemT, err)
+		}
+		for k := 1; k <= 2; k++ {
+			want := float64(2*k-1) * math.Pi / (2 * L) * math.Sqrt(E/density)
+			got := modes.AngularFrequency(k - 1)
+			if math.Abs(got-want) > test.tol*want {
+				t.Errorf("%s mode %d: want angular frequency %g, got %g", test.elemT, k, want, got)
+			}
+		}
+	}
+}
+
+// tetraMesh returns a mesh of a box with nodes at the coordinates xs, ys and zs
+// where each hexahedron of boxMesh is split into 6 tetrahedra. The tetrahedra
+// are of 10 nodes if quadratic is true, and of 4 nodes otherwise.
+func tetraMesh(xs, ys, zs []float64, quadratic bool) (nodes []r3.Vec, tetras [][]int) {
+	nodes, hexas := boxMesh(xs, ys, zs)
+	// Edge nodes of Tetra10 in node order.
+	edges := [6][2]int{{0, 1}, {0, 2}, {0, 3}, {1, 2}, {2, 3}, {1, 3}}
+	midside := make(map[[2]int]int)
+	for _, h := range hexas {
+		// Split around the hexahedron's 0-6 diagonal.
+		for _, tet := range [6][4]int{{0, 1, 2, 6}, {0, 2, 3, 6}, {0, 3, 7, 6}, {0, 7, 4, 6}, {0, 4, 5, 6}, {0, 5, 1, 6}} {
+			elem := []int{h[tet[0]], h[tet[1]], h[tet[2]], h[tet[3]]}
+			a, b, c := r3.Sub(nodes[elem[1]], nodes[elem[0]]), r3.Sub(nodes[elem[2]], nodes[elem[0]]), r3.Sub(nodes[elem[3]], nodes[elem[0]])
+			if r3.Dot(r3.Cross(a, b), c) < 0 {
+				elem[1], elem[2] = elem[2], elem[1]
+			}
+			for _, edge := range edges {
+				if !quadratic {
+					break
+				}
+				n0, n1 := elem[edge[0]], elem[edge[1]]
+				key := [2]int{n0, n1}
+				if n1 < n0 {
+					key = [2]int{n1, n0}
+				}
+				mid, ok := midside[key]
+				if !ok {
+					mid = len(nodes)
+					midside[key] = mid
+					nodes = append(nodes, r3.Scale(0.5, r3.Add(nodes[n0], nodes[n1])))
+				}
+				elem = append(elem, mid)
+			}
+			tetras = append(tetras, elem)
+		}
+	}
+	return nodes, tetras
+}
+
+// vibratingPlate returns the assembled stiffness and mass of a steel plane
+// stress plate with Quad4 elements clamped at x=0.
+func vibratingPlate(t *testing.T, xs, ys []float64) (*fem.GeneralAssembler, fem.Fixity) {
+	t.Helper()
+	nodes, elems := rectangularMesh(xs, ys, false)
+	elemT := elements.Quad4{}
+	c := solids.Isotropic{E: 200e9, Poisson: 0.3}.PlaneStess()
+	ga := fem.NewGeneralAssembler(nodes, elemT.Dofs())
+	getElement := func(i int) ([]int, r3.Vec, r3.Vec) { return elems[i], r3.Vec{}, r3.Vec{} }
+	err := ga.AddIsoparametric(elemT, c, len(elems), getElement)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ga.AddIsoparametricMass(elemT, c, 7800, len(elems), getElement)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fix := fem.NewFixity(elemT.Dofs(), len(nodes))
+	for i, node := range nodes {
+		if node.X == 0 {
+			fix.Fix(i, elemT.Dofs())
+		}
+	}
+	return ga, fix
+}
+
+// denseEigenvalues returns the sorted eigenvalues of K·φ = λ·M·φ restricted to the free dofs.
+func denseEigenvalues(t *testing.T, K, M lap.Matrix, free []int) []float64 {
+	t.Helper()
+	n := len(free)
+	Kff := mat.NewSymDense(n, nil)
+	Mff := mat.NewSymDense(n, nil)
+	for i, fi := range free {
+		for j := i; j < n; j++ {
+			Kff.SetSym(i, j, K.At(fi, free[j]))
+			Mff.SetSym(i, j, M.At(fi, free[j]))
+		}
+	}
+	var chol mat.Cholesky
+	if !chol.Factorize(Mff) {
+		t.Fatal("mass matrix not positive definite")
+	}
+	var L mat.TriDense
+	chol.LTo(&L)
+	var LinvK, C mat.Dense
+	if err := LinvK.Solve(&L, Kff); err != nil {
+		t.Fatal(err)
+	}
+	if err := C.Solve(&L, LinvK.T()); err != nil {
+		t.Fatal(err)
+	}
+	Cs := mat.NewSymDense(n, nil)
+	for i := 0; i < n; i++ {
+		for j := i; j < n; j++ {
+			Cs.SetSym(i, j, (C.At(i, j)+C.At(j, i))/2)
+		}
+	}
+	var eig mat.EigenSym
+	if !eig.Factorize(Cs, false) {
+		t.Fatal("eigen decomposition failed")
+	}
+	values := eig.Values(nil)
+	sort.Float64s(values)
+	return values
+}
@@ -0,0 +1,412 @@
+package fem
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/soypat/lap"
+)
+
+// Operator is a linear operator of a square matrix, i.e. a sparse matrix
+// or a matrix-free implementation of its product with a vector.
+type Operator interface {
+	// Dims returns the dimensions of the matrix.
+	Dims() (r, c int)
+	// MulVecTo stores A·x in dst.
+	MulVecTo(dst, x []float64)
+}
+
+// Preconditioner is an approximation M of a matrix A which
+// is inexpensive to invert and speeds up iterative solvers.
+type Preconditioner interface {
+	// Reset computes the preconditioner of matrix A.
+	Reset(A Operator) error
+	// Precondition stores M⁻¹·r in dst.
+	Precondition(dst, r []float64)
+}
+
+// ErrNotPositiveDefinite is returned by PCG and IncompleteCholesky when the
+// matrix is found not to be positive definite. Use errors.Is to check for it.
+var ErrNotPositiveDefinite = errors.New("matrix is not positive definite")
+
+// NotConvergedError is returned by iterative solvers that
+// do not reach the desired tolerance within the iteration limit.
+type NotConvergedError struct {
+	// Iterations performed.
+	Iterations int
+	// Residual is the relative residual norm ‖b-A·x‖/‖b‖ of the last iteration.
+	Residual float64
+}
+
+func (e *NotConvergedError) Error() string {
+	return fmt.Sprintf("not converged after %d iterations, relative residual %g", e.Iterations, e.Residual)
+}
+
+// PCG is the preconditioned conjugate gradient iterative solver of linear systems
+// with a symmetric positive definite matrix. It requires much less memory than
+// a direct solver such as SparseCholesky for large 3D models.
+type PCG struct {
+	// Tolerance is the relative residual norm ‖b-A·x‖/‖b‖ at which
+	// the iteration is stopped. Defaults to 1e-8.
+	Tolerance float64
+	// MaxIterations is the maximum number of iterations. Defaults to
+	// ten times the dimension of the matrix.
+	MaxIterations int
+	// Preconditioner used for the iteration, no preconditioning is done if nil.
+	// Jacobi is a good starting point for stiffness matrices.
+	Preconditioner Preconditioner
+	// Residuals is the relative residual norm of each iteration of
+	// the last solve, starting with that of the initial guess.
+	Residuals []float64
+}
+
+// LinearStatic solves the linear static problem K·u = F + R with the preconditioned conjugate gradient
+// method. See the LinearStatic function for details. A NotConvergedError is returned if the tolerance is not reached
+// and ErrNotPositiveDefinite if K is found not to be positive definite.
+func (pcg *PCG) LinearStatic(K lap.Matrix, loads lap.Vector, fix Fixity) (displacements, reactions *lap.DenseV, err error) {
+	var part staticPartition
+	Kff, err := part.reset(K, fix)
+	if err != nil {
+		return nil, nil, err
+	}
+	rhs, err := part.rhs(loads)
+	if err != nil {
+		return nil, nil, err
+	}
+	uf := make([]float64, len(rhs))
+	if len(uf) > 0 {
+		err = pcg.solve(uf, Kff, rhs)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	displacements, reactions = part.results(loads, uf)
+	return displacements, reactions, nil
+}
+
+// SolveVec solves A·x = b and stores the result in dst. The values of dst
+// are used as the initial guess of the iteration. If A is not an Operator it is
+// converted to CSR. A NotConvergedError is returned if the tolerance is not reached
+// and ErrNotPositiveDefinite if A is found not to be positive definite.
+func (pcg *PCG) SolveVec(dst *lap.DenseV, A lap.Matrix, b lap.Vector) error {
+	op, ok := A.(Operator)
+	if !ok {
+		var err error
+		op, err = NewCSR(A)
+		if err != nil {
+			return err
+		}
+	}
+	n, _ := op.Dims()
+	if b.Len() != n || dst.Len() != n {
+		return fmt.Errorf("vector lengths %d and %d do not match matrix dimension %d", dst.Len(), b.Len(), n)
+	}
+	x := make([]float64, n)
+	rhs := make([]float64, n)
+	for i := range x {
+		x[i] = dst.AtVec(i)
+		rhs[i] = b.AtVec(i)
+	}
+	err := pcg.solve(x, op, rhs)
+	for i, v := range x {
+		dst.SetVec(i, v)
+	}
+	return err
+}
+
+// solve solves A·x = b with x as initial guess.
+func (pcg *PCG) solve(x []float64, A Operator, b []float64) error {
+	n, c := A.Dims()
+	if n != c {
+		return fmt.Errorf("expected square matrix, got %dx%d", n, c)
+	}
+	tol := pcg.Tolerance
+	if tol == 0 {
+		tol = 1e-8
+	}
+	maxIter := pcg.MaxIterations
+	if maxIter == 0 {
+		maxIter = 10 * n
+	}
+	if pcg.Preconditioner != nil {
+		err := pcg.Preconditioner.Reset(A)
+		if err != nil {
+			return err
+		}
+	}
+	pcg.Residuals = pcg.Residuals[:0]
+	bnorm := norm(b)
+	if bnorm == 0 {
+		for i := range x {
+			x[i] = 0
+		}
+		pcg.Residuals = append(pcg.Residuals, 0)
+		return nil
+	}
+	var (
+		r  = make([]float64, n)
+		z  = make([]float64, n)
+		p  = make([]float64, n)
+		Ap = make([]float64, n)
+	)
+	// r = b - A·x
+	A.MulVecTo(r, x)
+	for i := range r {
+		r[i] = b[i] - r[i]
+	}
+	res := norm(r) / bnorm
+	pcg.Residuals = append(pcg.Residuals, res)
+	if res <= tol {
+		return nil
+	}
+	pcg.precondition(z, r)
+	copy(p, z)
+	rz := dot(r, z)
+	for iter := 1; iter <= maxIter; iter++ {
+		A.MulVecTo(Ap, p)
+		pAp := dot(p, Ap)
+		if pAp <= 0 {
+			return ErrNotPositiveDefinite
+		}
+		alpha := rz / pAp
+		for i := range x {
+			x[i] += alpha * p[i]
+			r[i] -= alpha * Ap[i]
+		}
+		res = norm(r) / bnorm
+		pcg.Residuals = append(pcg.Residuals, res)
+		if res <= tol {
+			return nil
+		} else if math.IsNaN(res) {
+			break
+		}
+		pcg.precondition(z, r)
+		rzNew := dot(r, z)
+		beta := rzNew / rz
+		rz = rzNew
+		for i := range p {
+			p[i] = z[i] + beta*p[i]
+		}
+	}
+	return &NotConvergedError{Iterations: len(pcg.Residuals) - 1, Residual: res}
+}
+
+func (pcg *PCG) precondition(dst, r []float64) {
+	if pcg.Preconditioner == nil {
+		copy(dst, r)
+		return
+	}
+	pcg.Preconditioner.Precondition(dst, r)
+}
+
+// Jacobi is the diagonal preconditioner M = diag(A).
+type Jacobi struct {
+	invDiag []float64
+}
+
+// Reset computes the preconditioner of A. A must be a lap.Matrix.
+func (jac *Jacobi) Reset(A Operator) error {
+	m, err := preconditionerCSR(A)
+	if err != nil {
+		return err
+	}
+	jac.invDiag = make([]float64, m.n)
+	for i, d := range m.diagonal() {
+		if d == 0 {
+			return fmt.Errorf("zero diagonal entry at row %d", i)
+		}
+		jac.invDiag[i] = 1 / d
+	}
+	return nil
+}
+
+// Precondition stores M⁻¹·r in dst.
+func (jac *Jacobi) Precondition(dst, r []float64) {
+	for i, v := range r {
+		dst[i] = v * jac.invDiag[i]
+	}
+}
+
+// SSOR is the symmetric successive over-relaxation preconditioner
+//
+//	M = ω/(2-ω) · (D/ω + L) · (D/ω)⁻¹ · (D/ω + Lᵀ)
+//
+// where D is the diagonal and L the strictly lower triangle of A.
+type SSOR struct {
+	// Omega is the relaxation factor in the range (0, 2). Defaults to 1,
+	// which corresponds to the symmetric Gauss-Seidel preconditioner.
+	Omega float64
+	m     *CSR
+	diag  []float64
+}
+
+// Reset computes the preconditioner of A. A must be a lap.Matrix.
+func (ssor *SSOR) Reset(A Operator) (err error) {
+	if ssor.Omega < 0 || ssor.Omega >= 2 {
+		return fmt.Errorf("SSOR relaxation factor %g out of range (0, 2)", ssor.Omega)
+	}
+	ssor.m, err = preconditionerCSR(A)
+	if err != nil {
+		return err
+	}
+	ssor.diag = ssor.m.diagonal()
+	for i, d := range ssor.diag {
+		if d == 0 {
+			return fmt.Errorf("zero diagonal entry at row %d", i)
+		}
+	}
+	return nil
+}
+
+// Precondition stores M⁻¹·r in dst.
+func (ssor *SSOR) Precondition(dst, r []float64) {
+	m := ssor.m
+	omega := ssor.Omega
+	if omega == 0 {
+		omega = 1
+	}
+	// Forward substitution (D/ω + L)·y = r.
+	for i := 0; i < m.n; i++ {
+		sum := r[i]
+		for p := m.rowPtr[i]; p < m.rowPtr[i+1] && m.col[p] < i; p++ {
+			sum -= m.val[p] * dst[m.col[p]]
+		}
+		dst[i] = sum * omega / ssor.diag[i]
+	}
+	// Backward substitution (D/ω + Lᵀ)·x = (D/ω)·y.
+	for i := m.n - 1; i >= 0; i-- {
+		sum := dst[i] * ssor.diag[i] / omega
+		for p := m.rowPtr[i+1] - 1; p >= m.rowPtr[i] && m.col[p] > i; p-- {
+			sum -= m.val[p] * dst[m.col[p]]
+		}
+		dst[i] = sum * omega / ssor.diag[i]
+	}
+	scale := (2 - omega) / omega
+	for i := range dst[:m.n] {
+		dst[i] *= scale
+	}
+}
+
+// IncompleteCholesky is the zero fill-in incomplete Cholesky preconditioner M = L·Lᵀ,
+// where L has the sparsity pattern of the lower triangle of A. If the factorization
+// breaks down due to a non-positive pivot, the diagonal of A is increasingly shifted
+// until it succeeds. Reset returns an error wrapping ErrNotPositiveDefinite if it does not.
+type IncompleteCholesky struct {
+	// L is stored by rows with column indices sorted in increasing order,
+	// the last entry of each row being the diagonal.
+	l *CSR
+}
+
+// Reset computes the preconditioner of A. A must be a lap.Matrix.
+func (ic *IncompleteCholesky) Reset(A Operator) error {
+	m, err := preconditionerCSR(A)
+	if err != nil {
+		return err
+	}
+	// Lower triangle of A.
+	l := &CSR{n: m.n, rowPtr: make([]int, m.n+1)}
+	for i := 0; i < m.n; i++ {
+		for p := m.rowPtr[i]; p < m.rowPtr[i+1] && m.col[p] <= i; p++ {
+			l.col = append(l.col, m.col[p])
+			l.val = append(l.val, m.val[p])
+		}
+		if len(l.col) == l.rowPtr[i] || l.col[len(l.col)-1] != i {
+			return fmt.Errorf("zero diagonal entry at row %d", i)
+		}
+		l.rowPtr[i+1] = len(l.col)
+	}
+	diag := m.diagonal()
+	values := append([]float64{}, l.val...)
+	for shift := 0.0; shift < 1; shift = math.Max(2*shift, 1e-3) {
+		copy(l.val, values)
+		if icFactorize(l, diag, shift) {
+			ic.l = l
+			return nil
+		}
+	}
+	return fmt.Errorf("incomplete Cholesky factorization failed: %w", ErrNotPositiveDefinite)
+}
+
+// icFactorize computes the incomplete Cholesky factorization of A+shift·diag(A) in place.
+// It returns false if the factorization breaks down.
+func icFactorize(l *CSR, diag []float64, shift float64) bool {
+	for i := 0; i < l.n; i++ {
+		start, end := l.rowPtr[i], l.rowPtr[i+1]-1
+		for p := start; p <= end; p++ {
+			k := l.col[p]
+			// Dot product of rows i and k of L over columns less than k.
+			sum := l.val[p]
+			if k == i {
+				sum += shift * diag[i]
+			}
+			pi, pk := start, l.rowPtr[k]
+			for pi < p && l.col[pk] < k {
+				switch ci, ck := l.col[pi], l.col[pk]; {
+				case ci == ck:
+					sum -= l.val[pi] * l.val[pk]
+					pi++
+					pk++
+				case ci < ck:
+					pi++
+				default:
+					pk++
+				}
+			}
+			if k < i {
+				l.val[p] = sum / l.val[l.rowPtr[k+1]-1]
+			} else if sum <= 0 || math.IsNaN(sum) {
+				return false
+			} else {
+				l.val[p] = math.Sqrt(sum)
+			}
+		}
+	}
+	return true
+}
+
+// Precondition stores M⁻¹·r in dst.
+func (ic *IncompleteCholesky) Precondition(dst, r []float64) {
+	l := ic.l
+	// L·y = r
+	for i := 0; i < l.n; i++ {
+		diag := l.rowPtr[i+1] - 1
+		sum := r[i]
+		for p := l.rowPtr[i]; p < diag; p++ {
+			sum -= l.val[p] * dst[l.col[p]]
+		}
+		dst[i] = sum / l.val[diag]
+	}
+	// Lᵀ·x = y
+	for i := l.n - 1; i >= 0; i-- {
+		diag := l.rowPtr[i+1] - 1
+		dst[i] /= l.val[diag]
+		xi := dst[i]
+		for p := l.rowPtr[i]; p < diag; p++ {
+			dst[l.col[p]] -= l.val[p] * xi
+		}
+	}
+}
+
+// preconditionerCSR returns the CSR representation of A
+// for preconditioners that require the entries of the matrix.
+func preconditionerCSR(A Operator) (*CSR, error) {
+	switch m := A.(type) {
+	case *CSR:
+		return m, nil
+	case lap.Matrix:
+		return NewCSR(m)
+	}
+	return nil, errors.New("preconditioner requires matrix entries, operator must implement lap.Matrix")
+}
+
+func dot(a, b []float64) (sum float64) {
+	for i, v := range a {
+		sum += v * b[i]
+	}
+	return sum
+}
+
+func norm(a []float64) float64 {
+	return math.Sqrt(dot(a, a))
+}
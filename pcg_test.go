@@ -0,0 +1,127 @@
+package fem_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/soypat/go-fem"
+	"github.com/soypat/go-fem/constitution/solids"
+	"github.com/soypat/go-fem/elements"
+	"github.com/soypat/lap"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+func TestPCGLinearStatic(t *testing.T) {
+	const tol = 1e-6
+	K, loads, fix := cantileverPlate(t)
+	want, _, err := fem.LinearStatic(K, loads, fix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var maxDisp float64
+	for i := 0; i < want.Len(); i++ {
+		maxDisp = math.Max(maxDisp, math.Abs(want.AtVec(i)))
+	}
+	iterations := make(map[string]int)
+	for name, pc := range map[string]fem.Preconditioner{
+		"none":   nil,
+		"jacobi": &fem.Jacobi{},
+		"ssor":   &fem.SSOR{Omega: 1.2},
+		"ic":     &fem.IncompleteCholesky{},
+	} {
+		pcg := fem.PCG{Tolerance: 1e-10, Preconditioner: pc}
+		u, R, err := pcg.LinearStatic(K, loads, fix)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		for i := 0; i < u.Len(); i++ {
+			if math.Abs(u.AtVec(i)-want.AtVec(i)) > tol*maxDisp {
+				t.Fatalf("%s: dof %d want displacement %g, got %g", name, i, want.AtVec(i), u.AtVec(i))
+			}
+		}
+		var sumX float64
+		for i := 0; i < R.Len(); i += 2 {
+			sumX += R.AtVec(i)
+		}
+		if math.Abs(sumX+1) > 1e-6 {
+			t.Errorf("%s: reactions %g do not balance unit load", name, sumX)
+		}
+		last := len(pcg.Residuals) - 1
+		if pcg.Residuals[0] != 1 || pcg.Residuals[last] > 1e-10 {
+			t.Errorf("%s: unexpected residual history start %g end %g", name, pcg.Residuals[0], pcg.Residuals[last])
+		}
+		iterations[name] = last
+	}
+	if iterations["ic"] >= iterations["none"] || iterations["jacobi"] >= iterations["none"] {
+		t.Errorf("preconditioning did not reduce iterations: %v", iterations)
+	}
+}
+
+func TestPCGNotConverged(t *testing.T) {
+	K, loads, fix := cantileverPlate(t)
+	pcg := fem.PCG{MaxIterations: 3, Preconditioner: &fem.Jacobi{}}
+	_, _, err := pcg.LinearStatic(K, loads, fix)
+	var nc *fem.NotConvergedError
+	if !errors.As(err, &nc) {
+		t.Fatalf("expected NotConvergedError, got %v", err)
+	}
+	if nc.Iterations != 3 || len(pcg.Residuals) != 4 {
+		t.Errorf("expected 3 iterations, got %d with %d residuals", nc.Iterations, len(pcg.Residuals))
+	}
+}
+
+func TestPCGNotPositiveDefinite(t *testing.T) {
+	const n = 4
+	A := lap.NewSparse(n, n)
+	for i := 0; i < n; i++ {
+		A.Set(i, i, -4)
+		if i > 0 {
+			A.Set(i, i-1, 1)
+			A.Set(i-1, i, 1)
+		}
+	}
+	b := lap.NewDenseVector(n, []float64{1, 1, 1, 1})
+	var pcg fem.PCG
+	err := pcg.SolveVec(lap.NewDenseVector(n, nil), A, b)
+	if !errors.Is(err, fem.ErrNotPositiveDefinite) {
+		t.Errorf("expected ErrNotPositiveDefinite from PCG, got %v", err)
+	}
+	csr, err := fem.NewCSR(A)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ic fem.IncompleteCholesky
+	err = ic.Reset(csr)
+	if !errors.Is(err, fem.ErrNotPositiveDefinite) {
+		t.Errorf("expected ErrNotPositiveDefinite from IncompleteCholesky, got %v", err)
+	}
+}
+
+// cantileverPlate returns the stiffness matrix, loads and fixity of a plate
+// clamped on its left edge with a unit load in X distributed on its right edge.
+func cantileverPlate(t *testing.T) (lap.Matrix, lap.Vector, fem.Fixity) {
+	t.Helper()
+	xs := []float64{0, 1, 2, 3, 4, 5, 6, 7, 8}
+	ys := []float64{0, 0.5, 1, 1.5, 2}
+	nodes, elems := rectangularMesh(xs, ys, false)
+	elemT := elements.Quad4{}
+	ga := fem.NewGeneralAssembler(nodes, elemT.Dofs())
+	err := ga.AddIsoparametric(elemT, solids.Isotropic{E: 1000, Poisson: 0.3}.PlaneStess(), len(elems), func(i int) ([]int, r3.Vec, r3.Vec) {
+		return elems[i], r3.Vec{}, r3.Vec{}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fix := fem.NewFixity(elemT.Dofs(), len(nodes))
+	loads := lap.NewDenseVector(ga.TotalDofs(), nil)
+	for i, node := range nodes {
+		switch node.X {
+		case xs[0]:
+			fix.Fix(i, elemT.Dofs())
+		case xs[len(xs)-1]:
+			loads.SetVec(2*i, 1/float64(len(ys)))
+		}
+	}
+	return ga.Ksolid(), loads, fix
+}
@@ -0,0 +1,475 @@
+package fem
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/soypat/lap"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+// ExtrapolationMatrix returns the Nnodes×Npg matrix that maps values at the
+// integration points of an isoparametric element to its nodes.
+//
+// When the element has at least as many integration points as nodes the values are
+// fit with the element's form functions in a least squares sense, which is the inverse of the
+// form functions evaluated at the integration points for elements such as Quad4 or Hexa8 with full integration.
+// Otherwise the values are fit with the lowest degree polynomial that interpolates the integration points,
+// i.e. a bilinear field for a Quad8 element with 2x2 quadrature.
+func ExtrapolationMatrix(elemT Isoparametric) (*mat.Dense, error) {
+	upg, _ := elemT.Quadrature()
+	isoNodes := elemT.IsoparametricNodes()
+	Npg, Nnod := len(upg), len(isoNodes)
+	if Npg == 0 || Nnod == 0 {
+		return nil, errors.New("isoparametric element must have nodes and integration points")
+	}
+	identity := mat.NewDense(Npg, Npg, nil)
+	for i := 0; i < Npg; i++ {
+		identity.Set(i, i, 1)
+	}
+	E := mat.NewDense(Nnod, Npg, nil)
+	if Npg >= Nnod {
+		A := mat.NewDense(Npg, Nnod, nil)
+		for ipg, pg := range upg {
+			A.SetRow(ipg, elemT.Basis(pg))
+		}
+		if matrixRank(A) == Nnod {
+			// Least squares solution of A*E = I.
+			err := E.Solve(A, identity)
+			if err != nil {
+				return nil, fmt.Errorf("extrapolation matrix: %w", err)
+			}
+			return E, nil
+		}
+	}
+	// Polynomial fit of integration point values evaluated at the nodes.
+	dims := len(elemT.BasisDiff(r3.Vec{})) / Nnod
+	P, Q := extrapolationPolynomial(dims, upg, isoNodes)
+	var coef mat.Dense
+	err := coef.Solve(P, identity)
+	if err != nil {
+		return nil, fmt.Errorf("extrapolation matrix: %w", err)
+	}
+	E.Mul(Q, &coef)
+	return E, nil
+}
+
+// extrapolationPolynomial returns the monomials of lowest degree with
+// linearly independent values at the points upg, evaluated at upg (P) and at nodes (Q).
+func extrapolationPolynomial(dims int, upg, nodes []r3.Vec) (P, Q *mat.Dense) {
+	const maxDegree = 6
+	var pcols, qcols [][]float64
+	eval := func(pts []r3.Vec, exp [3]int) []float64 {
+		col := make([]float64, len(pts))
+		for i, p := range pts {
+			col[i] = monomial(exp, p)
+		}
+		return col
+	}
+	toDense := func(cols [][]float64, rows int) *mat.Dense {
+		m := mat.NewDense(rows, len(cols), nil)
+		for j, col := range cols {
+			m.SetCol(j, col)
+		}
+		return m
+	}
+	for degree := 0; degree <= maxDegree && len(pcols) < len(upg); degree++ {
+		for a := degree; a >= 0 && len(pcols) < len(upg); a-- {
+			for b := degree - a; b >= 0 && len(pcols) < len(upg); b-- {
+				exp := [3]int{a, b, degree - a - b}
+				if (dims < 2 && exp[1] != 0) || (dims < 3 && exp[2] != 0) {
+					continue
+				}
+				pcols = append(pcols, eval(upg, exp))
+				if matrixRank(toDense(pcols, len(upg))) < len(pcols) {
+					// Monomial is linearly dependent on previous ones at integration points.
+					pcols = pcols[:len(pcols)-1]
+					continue
+				}
+				qcols = append(qcols, eval(nodes, exp))
+			}
+		}
+	}
+	return toDense(pcols, len(upg)), toDense(qcols, len(nodes))
+}
+
+// matrixRank returns the numerical rank of a.
+func matrixRank(a *mat.Dense) int {
+	var svd mat.SVD
+	if !svd.Factorize(a, mat.SVDNone) {
+		return 0
+	}
+	values := svd.Values(nil)
+	rank := 0
+	for _, v := range values {
+		if v > 1e-10*values[0] {
+			rank++
+		}
+	}
+	return rank
+}
+
+// NodalAverager extrapolates values at the integration points of isoparametric
+// elements to their nodes and averages the nodal values of all elements that share a node.
+// It is useful to obtain continuous strain, stress or heat flux fields for visualization.
+type NodalAverager struct {
+	extrap  *mat.Dense
+	ncomp   int
+	sum     []float64
+	weights []float64
+	nodal   *mat.Dense
+}
+
+// NewNodalAverager returns a NodalAverager of a model of Nnodes nodes with elements of type elemT.
+// Ncomponents is the number of values per integration point, i.e. 6 for 3D stresses.
+func NewNodalAverager(elemT Isoparametric, Nnodes, Ncomponents int) (*NodalAverager, error) {
+	if Nnodes <= 0 || Ncomponents <= 0 {
+		return nil, errors.New("number of nodes and components must be positive")
+	}
+	E, err := ExtrapolationMatrix(elemT)
+	if err != nil {
+		return nil, err
+	}
+	Nnod, _ := E.Dims()
+	return &NodalAverager{
+		extrap:  E,
+		ncomp:   Ncomponents,
+		sum:     make([]float64, Nnodes*Ncomponents),
+		weights: make([]float64, Nnodes),
+		nodal:   mat.NewDense(Nnod, Ncomponents, nil),
+	}, nil
+}
+
+// Add extrapolates the integration point values of an element with nodes elem to its nodes
+// and adds them to the average with the given weight. pgValues holds the Ncomponents values of each
+// integration point one after the other, as returned by IsoparametricStresses. The weight is usually 1
+// or the volume of the element, see IsoparametricVolumes.
+func (na *NodalAverager) Add(elem []int, pgValues []float64, weight float64) error {
+	Nnod, Npg := na.extrap.Dims()
+	if len(elem) != Nnod {
+		return fmt.Errorf("expected element of %d nodes, got %d", Nnod, len(elem))
+	} else if len(pgValues) != Npg*na.ncomp {
+		return fmt.Errorf("expected %d integration point values, got %d", Npg*na.ncomp, len(pgValues))
+	}
+	na.nodal.Mul(na.extrap, mat.NewDense(Npg, na.ncomp, pgValues))
+	for a, node := range elem {
+		row := na.nodal.RawRowView(a)
+		for k, v := range row {
+			na.sum[node*na.ncomp+k] += weight * v
+		}
+		na.weights[node] += weight
+	}
+	return nil
+}
+
+// Average stores the averaged nodal values in dst and returns it. If dst is nil a new slice is allocated.
+// The Ncomponents values of each node are stored one after the other. Nodes with no
+// contributions are set to zero.
+func (na *NodalAverager) Average(dst []float64) []float64 {
+	if dst == nil {
+		dst = make([]float64, len(na.sum))
+	} else if len(dst) != len(na.sum) {
+		panic("bad destination length")
+	}
+	for node, w := range na.weights {
+		for k := 0; k < na.ncomp; k++ {
+			i := node*na.ncomp + k
+			if w == 0 {
+				dst[i] = 0
+			} else {
+				dst[i] = na.sum[i] / w
+			}
+		}
+	}
+	return dst
+}
+
+// Reset discards all added values so the NodalAverager can be reused.
+func (na *NodalAverager) Reset() {
+	for i := range na.sum {
+		na.sum[i] = 0
+	}
+	for i := range na.weights {
+		na.weights[i] = 0
+	}
+}
+
+// IsoparametricPatchRecovery returns the nodal stresses of isoparametric elements recovered with
+// Zienkiewicz and Zhu's superconvergent patch recovery (SPR). The stresses are stored one node after
+// the other, each with the layout of the stresses of IsoparametricStresses.
+//
+// A patch is built around each interior vertex node of the model from the elements that share it.
+// The stresses at the integration points of the patch are fit in a least squares sense with a polynomial
+// of the monomials the element's form functions reproduce, i.e. bilinear for Quad4 and complete quadratic for Tetra10.
+// The value of an interior vertex node is obtained by evaluating its patch's polynomial. Midside nodes and
+// nodes on the boundary of the model are obtained by averaging the polynomials of the interior patches
+// they belong to. The boundary is found from the faces of Faceted elements, otherwise vertex nodes that belong to
+// a single element are on the boundary. As a last resort, i.e. models with no interior vertex nodes,
+// nodal values are averaged from extrapolated element values.
+func (ga *GeneralAssembler) IsoparametricPatchRecovery(displacements lap.Vector, elemT Isoparametric, c IsoConstituter, Nelem int, getElement func(i int) (elem []int, xC, yC r3.Vec)) (nodalStresses []float64, err error) {
+	Cd, err := denseConstitutive(c)
+	if err != nil {
+		return nil, err
+	}
+	dimC, _ := Cd.Dims()
+	it, err := newIsoIntegrator(elemT)
+	if err != nil {
+		return nil, err
+	}
+	Npg := len(it.upg)
+	Nnodes := len(ga.nodes)
+	// Store elements and integration point positions.
+	elems := make([][]int, Nelem)
+	orient := make([][2]r3.Vec, Nelem)
+	positions := make([]r3.Vec, Nelem*Npg)
+	err = ga.ForEachElement(elemT, it.NdimsPerNode, Nelem, func(i int) []int {
+		elem, xC, yC := getElement(i)
+		elems[i] = append([]int{}, elem...)
+		orient[i] = [2]r3.Vec{xC, yC}
+		return elem
+	}, func(iele int, elemNodBacking []float64, _ []int) error {
+		elemNod := mat.NewDense(it.NnodperElem, it.NdimsPerNode, elemNodBacking)
+		for ipg := range it.upg {
+			positions[iele*Npg+ipg] = it.position(ipg, elemNod)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	storedElement := func(i int) ([]int, r3.Vec, r3.Vec) { return elems[i], orient[i][0], orient[i][1] }
+	stresses := make([]float64, Nelem*Npg*dimC)
+	err = ga.IsoparametricStresses(displacements, elemT, c, Nelem, storedElement, func(iele int, s []float64) {
+		copy(stresses[iele*Npg*dimC:], s)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Elements that share each node.
+	nodeElems := make([][]int, Nnodes)
+	for iele, elem := range elems {
+		for _, node := range elem {
+			nodeElems[node] = append(nodeElems[node], iele)
+		}
+	}
+	vertex := vertexNodes(elemT.IsoparametricNodes())
+	boundary := boundaryNodes(elemT, elems, nodeElems, vertex)
+	exps := isoparametricMonomials(elemT, it.NdimsPerNode)
+	var (
+		own      = make([]float64, Nnodes*dimC)
+		ownDone  = make([]bool, Nnodes)
+		sum      = make([]float64, Nnodes*dimC)
+		count    = make([]int, Nnodes)
+		patchVal = make([]float64, dimC)
+		visited  = make(map[int]bool)
+		pvals    = mat.NewVecDense(len(exps), nil)
+		coef     mat.Dense
+	)
+	for node, patch := range nodeElems {
+		npts := len(patch) * Npg
+		if boundary[node] || npts < len(exps) || !isVertexOf(node, elems[patch[0]], vertex) {
+			continue
+		}
+		// Local coordinates centered at node and scaled by patch size for conditioning.
+		origin := ga.nodes[node]
+		h := 0.0
+		for _, iele := range patch {
+			for ipg := 0; ipg < Npg; ipg++ {
+				h = math.Max(h, r3.Norm(r3.Sub(positions[iele*Npg+ipg], origin)))
+			}
+		}
+		if h == 0 {
+			continue
+		}
+		P := mat.NewDense(npts, len(exps), nil)
+		S := mat.NewDense(npts, dimC, nil)
+		for k, iele := range patch {
+			for ipg := 0; ipg < Npg; ipg++ {
+				row := k*Npg + ipg
+				local := r3.Scale(1/h, r3.Sub(positions[iele*Npg+ipg], origin))
+				evalMonomials(P.RawRowView(row), exps, local)
+				S.SetRow(row, stresses[(iele*Npg+ipg)*dimC:(iele*Npg+ipg+1)*dimC])
+			}
+		}
+		if matrixRank(P) < len(exps) {
+			continue
+		}
+		coef.Reset()
+		err = coef.Solve(P, S)
+		if err != nil {
+			continue
+		}
+		eval := func(dst []float64, pos r3.Vec) {
+			evalMonomials(pvals.RawVector().Data, exps, r3.Scale(1/h, r3.Sub(pos, origin)))
+			for j := range dst {
+				dst[j] = mat.Dot(pvals, coef.ColView(j))
+			}
+		}
+		eval(own[node*dimC:(node+1)*dimC], origin)
+		ownDone[node] = true
+		for k := range visited {
+			delete(visited, k)
+		}
+		for _, iele := range patch {
+			for _, n := range elems[iele] {
+				if visited[n] {
+					continue
+				}
+				visited[n] = true
+				eval(patchVal, ga.nodes[n])
+				for j, v := range patchVal {
+					sum[n*dimC+j] += v
+				}
+				count[n]++
+			}
+		}
+	}
+
+	nodalStresses = make([]float64, Nnodes*dimC)
+	var fallback []float64
+	for node := range nodeElems {
+		dst := nodalStresses[node*dimC : (node+1)*dimC]
+		switch {
+		case ownDone[node]:
+			copy(dst, own[node*dimC:])
+		case count[node] > 0:
+			for j := range dst {
+				dst[j] = sum[node*dimC+j] / float64(count[node])
+			}
+		case len(nodeElems[node]) > 0:
+			if fallback == nil {
+				avg, err := NewNodalAverager(elemT, Nnodes, dimC)
+				if err != nil {
+					return nil, err
+				}
+				for iele, elem := range elems {
+					err = avg.Add(elem, stresses[iele*Npg*dimC:(iele+1)*Npg*dimC], 1)
+					if err != nil {
+						return nil, err
+					}
+				}
+				fallback = avg.Average(nil)
+			}
+			copy(dst, fallback[node*dimC:])
+		}
+	}
+	return nodalStresses, nil
+}
+
+// vertexNodes returns whether each of the isoparametric nodes is a vertex of the element,
+// that is, whether it is not the midpoint of two other nodes.
+func vertexNodes(isoNodes []r3.Vec) []bool {
+	vertex := make([]bool, len(isoNodes))
+	for k, node := range isoNodes {
+		vertex[k] = true
+		for a := range isoNodes {
+			for b := a + 1; b < len(isoNodes) && vertex[k]; b++ {
+				mid := r3.Scale(0.5, r3.Add(isoNodes[a], isoNodes[b]))
+				if a != k && b != k && r3.Norm(r3.Sub(mid, node)) < 1e-10 {
+					vertex[k] = false
+				}
+			}
+		}
+	}
+	return vertex
+}
+
+// isVertexOf returns whether node is a vertex of element elem.
+func isVertexOf(node int, elem []int, vertex []bool) bool {
+	for k, n := range elem {
+		if n == node {
+			return vertex[k]
+		}
+	}
+	return false
+}
+
+// boundaryNodes returns whether each node of the model lies on its boundary. Nodes of Faceted elements
+// are on the boundary if they belong to a face not shared by two elements. Otherwise vertex nodes that belong
+// to a single element are on the boundary.
+func boundaryNodes(elemT Isoparametric, elems [][]int, nodeElems [][]int, vertex []bool) []bool {
+	boundary := make([]bool, len(nodeElems))
+	faceted, ok := elemT.(Faceted)
+	if !ok {
+		for _, elem := range elems {
+			for k, node := range elem {
+				if vertex[k] && len(nodeElems[node]) == 1 {
+					boundary[node] = true
+				}
+			}
+		}
+		return boundary
+	}
+	// Faces are identified by their sorted global node indices.
+	faceCount := make(map[string]int)
+	faceNodes := make(map[string][]int)
+	for _, elem := range elems {
+		for iface := 0; iface < faceted.LenFaces(); iface++ {
+			local, _ := faceted.Face(iface)
+			global := make([]int, len(local))
+			for k, a := range local {
+				global[k] = elem[a]
+			}
+			sorted := append([]int{}, global...)
+			sort.Ints(sorted)
+			key := fmt.Sprint(sorted)
+			faceCount[key]++
+			faceNodes[key] = global
+		}
+	}
+	for key, count := range faceCount {
+		if count == 1 {
+			for _, node := range faceNodes[key] {
+				boundary[node] = true
+			}
+		}
+	}
+	return boundary
+}
+
+// isoparametricMonomials returns the exponents of the monomials of degree up to 3 in each
+// coordinate that the form functions of the element reproduce exactly.
+func isoparametricMonomials(elemT Isoparametric, dims int) (exps [][3]int) {
+	const maxDegree = 3
+	isoNodes := elemT.IsoparametricNodes()
+	// Arbitrary points inside all elements.
+	pts := []r3.Vec{{X: 0.21, Y: 0.17, Z: 0.13}, {X: 0.11, Y: 0.29, Z: 0.23}}
+	// Monomials are sorted by degree.
+	for d := 0; d <= dims*maxDegree; d++ {
+		for a := maxDegree; a >= 0; a-- {
+			for b := maxDegree; b >= 0; b-- {
+				exp := [3]int{a, b, d - a - b}
+				if exp[2] < 0 || exp[2] > maxDegree || (dims < 2 && exp[1] != 0) || (dims < 3 && exp[2] != 0) {
+					continue
+				}
+				reproduced := true
+				for _, pt := range pts {
+					N := elemT.Basis(pt)
+					var interp float64
+					for k, node := range isoNodes {
+						interp += N[k] * monomial(exp, node)
+					}
+					reproduced = reproduced && math.Abs(interp-monomial(exp, pt)) < 1e-10
+				}
+				if reproduced {
+					exps = append(exps, exp)
+				}
+			}
+		}
+	}
+	return exps
+}
+
+func evalMonomials(dst []float64, exps [][3]int, p r3.Vec) {
+	for i, exp := range exps {
+		dst[i] = monomial(exp, p)
+	}
+}
+
+func monomial(exp [3]int, p r3.Vec) float64 {
+	return math.Pow(p.X, float64(exp[0])) * math.Pow(p.Y, float64(exp[1])) * math.Pow(p.Z, float64(exp[2]))
+}
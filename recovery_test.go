@@ -0,0 +1,298 @@
+package fem_test
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/soypat/go-fem"
+	"github.com/soypat/go-fem/constitution/solids"
+	"github.com/soypat/go-fem/elements"
+	"github.com/soypat/lap"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+func TestExtrapolationMatrix(t *testing.T) {
+	const tol = 1e-10
+	linear := func(p r3.Vec) float64 { return 1 + 2*p.X - 3*p.Y + 0.5*p.Z }
+	for _, elemT := range []fem.Isoparametric{
+		elements.Quad4{},
+		elements.Quad4{QuadratureOrder: 3},
+		elements.Quad8{},
+		elements.Quad8{QuadratureOrder: 3},
+		elements.Triangle3{},
+		elements.Triangle6{},
+		elements.Hexa8{},
+		elements.Hexa20{},
+		elements.Hexa20{QuadratureOrder: 2},
+		elements.Tetra4{},
+		elements.Tetra10{},
+	} {
+		t.Run(fmt.Sprintf("%v", elemT), func(t *testing.T) {
+			E, err := fem.ExtrapolationMatrix(elemT)
+			if err != nil {
+				t.Fatal(err)
+			}
+			upg, _ := elemT.Quadrature()
+			isoNodes := elemT.IsoparametricNodes()
+			dims := len(elemT.BasisDiff(r3.Vec{})) / elemT.LenNodes()
+			// Elements with less integration points than needed to fit a linear field
+			// extrapolate a constant field.
+			field := linear
+			if len(upg) < dims+1 {
+				field = func(r3.Vec) float64 { return 3 }
+			}
+			for a, node := range isoNodes {
+				got := 0.0
+				for ipg, pg := range upg {
+					got += E.At(a, ipg) * field(pg)
+				}
+				if want := field(node); math.Abs(got-want) > tol {
+					t.Errorf("node %d: want extrapolated value %g, got %g", a, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestNodalAverager(t *testing.T) {
+	const tol = 1e-12
+	// Two Quad4 elements of areas 1 and 2 sharing the edge at X=1.
+	nodes := []r3.Vec{
+		{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 3, Y: 0},
+		{X: 0, Y: 1}, {X: 1, Y: 1}, {X: 3, Y: 1},
+	}
+	elems := [][4]int{{0, 1, 4, 3}, {1, 2, 5, 4}}
+	getElement := func(i int) ([]int, r3.Vec, r3.Vec) { return elems[i][:], r3.Vec{}, r3.Vec{} }
+	elemT := elements.Quad4{}
+	material := solids.Isotropic{E: 1, Poisson: 0.3}
+	ga := fem.NewGeneralAssembler(nodes, elemT.Dofs())
+	volumes := make([]float64, len(elems))
+	err := ga.IsoparametricVolumes(volumes, elemT, material.PlaneStess(), len(elems), getElement)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(volumes[0]-1) > tol || math.Abs(volumes[1]-2) > tol {
+		t.Fatalf("expected element volumes [1 2], got %v", volumes)
+	}
+	// Constant two component fields on each element.
+	pgValues := [][]float64{
+		{1, -1, 1, -1, 1, -1, 1, -1},
+		{4, -4, 4, -4, 4, -4, 4, -4},
+	}
+	avg, err := fem.NewNodalAverager(elemT, len(nodes), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name     string
+		weighted bool
+		shared   float64
+	}{
+		{name: "unweighted", shared: 2.5},
+		{name: "volume weighted", weighted: true, shared: 3},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			avg.Reset()
+			for i, elem := range elems {
+				weight := 1.0
+				if test.weighted {
+					weight = volumes[i]
+				}
+				err := avg.Add(elem[:], pgValues[i], weight)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			got := avg.Average(nil)
+			for node := range nodes {
+				var w float64
+				switch node {
+				case 0, 3:
+					w = 1
+				case 1, 4:
+					w = test.shared
+				case 2, 5:
+					w = 4
+				}
+				if math.Abs(got[2*node]-w) > tol || math.Abs(got[2*node+1]+w) > tol {
+					t.Errorf("node %d: want [%g %g], got %v", node, w, -w, got[2*node:2*node+2])
+				}
+			}
+		})
+	}
+}
+
+func TestIsoparametricPatchRecovery(t *testing.T) {
+	const (
+		tol = 1e-9
+		k   = 1e-3
+	)
+	material := solids.Isotropic{E: 1000, Poisson: 0.25}
+	c := material.PlaneStess()
+	C, _ := c.Constitutive()
+	// Non uniform rectangular grid.
+	xs := []float64{0, 1, 2.5, 3, 4.5}
+	ys := []float64{0, 0.7, 2, 2.4}
+	for _, test := range []struct {
+		elemT fem.Isoparametric
+		// Displacement field whose strains are represented exactly by the element.
+		u func(p r3.Vec) r3.Vec
+		// Corresponding strain field [XX, YY, XY].
+		strain func(p r3.Vec) [3]float64
+	}{
+		{elemT: elements.Quad4{},
+			u:      func(p r3.Vec) r3.Vec { return r3.Vec{X: k * p.X * p.Y, Y: k * p.Y} },
+			strain: func(p r3.Vec) [3]float64 { return [3]float64{k * p.Y, k, k * p.X} }},
+		{elemT: elements.Quad8{},
+			u:      func(p r3.Vec) r3.Vec { return r3.Vec{X: k * p.X * p.X * p.Y, Y: k * p.Y * p.Y} },
+			strain: func(p r3.Vec) [3]float64 { return [3]float64{2 * k * p.X * p.Y, 2 * k * p.Y, k * p.X * p.X} }},
+	} {
+		t.Run(fmt.Sprintf("%v", test.elemT), func(t *testing.T) {
+			nodes, elems := rectangularMesh(xs, ys, test.elemT.LenNodes() == 8)
+			getElement := func(i int) ([]int, r3.Vec, r3.Vec) { return elems[i], r3.Vec{}, r3.Vec{} }
+			ga := fem.NewGeneralAssembler(nodes, test.elemT.Dofs())
+			u := lap.NewDenseVector(ga.TotalDofs(), nil)
+			for i, node := range nodes {
+				ui := test.u(node)
+				u.SetVec(2*i, ui.X)
+				u.SetVec(2*i+1, ui.Y)
+			}
+			got, err := ga.IsoparametricPatchRecovery(u, test.elemT, c, len(elems), getElement)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != 3*len(nodes) {
+				t.Fatalf("expected %d nodal stresses, got %d", 3*len(nodes), len(got))
+			}
+			for i, node := range nodes {
+				eps := test.strain(node)
+				for j := 0; j < 3; j++ {
+					want := C.At(j, 0)*eps[0] + C.At(j, 1)*eps[1] + C.At(j, 2)*eps[2]
+					if math.Abs(got[3*i+j]-want) > tol {
+						t.Errorf("node %d stress %d: want %g, got %g", i, j, want, got[3*i+j])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestIsoparametricPatchRecoveryBoundary(t *testing.T) {
+	const k = 1e-3
+	material := solids.Isotropic{E: 1000, Poisson: 0.25}
+	c := material.PlaneStess()
+	C, _ := c.Constitutive()
+	u := func(p r3.Vec) r3.Vec {
+		return r3.Vec{X: k * math.Sin(p.X) * math.Exp(p.Y), Y: k * math.Cos(p.X+p.Y)}
+	}
+	strain := func(p r3.Vec) [3]float64 {
+		return [3]float64{
+			k * math.Cos(p.X) * math.Exp(p.Y),
+			-k * math.Sin(p.X+p.Y),
+			k*math.Sin(p.X)*math.Exp(p.Y) - k*math.Sin(p.X+p.Y),
+		}
+	}
+	// boundaryErrors returns the maximum stress error at the boundary nodes of the
+	// unit square meshed with n×n elements of patch recovery and nodal averaging.
+	boundaryErrors := func(elemT fem.Isoparametric, n int) (spr, averaged float64) {
+		var xs []float64
+		for i := 0; i <= n; i++ {
+			xs = append(xs, float64(i)/float64(n))
+		}
+		nodes, elems := rectangularMesh(xs, xs, elemT.LenNodes() == 8)
+		getElement := func(i int) ([]int, r3.Vec, r3.Vec) { return elems[i], r3.Vec{}, r3.Vec{} }
+		ga := fem.NewGeneralAssembler(nodes, elemT.Dofs())
+		displacements := lap.NewDenseVector(ga.TotalDofs(), nil)
+		for i, node := range nodes {
+			ui := u(node)
+			displacements.SetVec(2*i, ui.X)
+			displacements.SetVec(2*i+1, ui.Y)
+		}
+		recovered, err := ga.IsoparametricPatchRecovery(displacements, elemT, c, len(elems), getElement)
+		if err != nil {
+			t.Fatal(err)
+		}
+		avg, err := fem.NewNodalAverager(elemT, len(nodes), 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ga.IsoparametricStresses(displacements, elemT, c, len(elems), getElement, func(iele int, s []float64) {
+			err := avg.Add(elems[iele], s, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		averages := avg.Average(nil)
+		for i, node := range nodes {
+			if node.X != 0 && node.X != 1 && node.Y != 0 && node.Y != 1 {
+				continue
+			}
+			eps := strain(node)
+			for j := 0; j < 3; j++ {
+				want := C.At(j, 0)*eps[0] + C.At(j, 1)*eps[1] + C.At(j, 2)*eps[2]
+				spr = math.Max(spr, math.Abs(recovered[3*i+j]-want))
+				averaged = math.Max(averaged, math.Abs(averages[3*i+j]-want))
+			}
+		}
+		return spr, averaged
+	}
+	for _, test := range []struct {
+		elemT fem.Isoparametric
+		// Convergence rate of the boundary stresses. Those of Quad4 are
+		// extrapolated from points that are not superconvergent in its 2x2 quadrature.
+		rate float64
+	}{
+		{elemT: elements.Quad4{}, rate: 1},
+		{elemT: elements.Quad8{}, rate: 2},
+	} {
+		t.Run(fmt.Sprintf("%v", test.elemT), func(t *testing.T) {
+			coarse, coarseAvg := boundaryErrors(test.elemT, 4)
+			fine, fineAvg := boundaryErrors(test.elemT, 8)
+			if coarse >= coarseAvg || fine >= fineAvg {
+				t.Errorf("boundary errors %g and %g not smaller than those of nodal averaging %g and %g", coarse, fine, coarseAvg, fineAvg)
+			}
+			if got := math.Log2(coarse / fine); got < 0.9*test.rate {
+				t.Errorf("want boundary convergence rate %g, got %g", test.rate, got)
+			}
+		})
+	}
+}
+
+// rectangularMesh returns a mesh of Quad4 elements, or Quad8 elements if quadratic is true,
+// on the grid defined by coordinates xs and ys.
+func rectangularMesh(xs, ys []float64, quadratic bool) (nodes []r3.Vec, elems [][]int) {
+	nx, ny := len(xs), len(ys)
+	for _, y := range ys {
+		for _, x := range xs {
+			nodes = append(nodes, r3.Vec{X: x, Y: y})
+		}
+	}
+	corner := func(i, j int) int { return j*nx + i }
+	// Midside nodes are created on demand.
+	mids := make(map[[2]int]int)
+	mid := func(a, b int) int {
+		if a > b {
+			a, b = b, a
+		}
+		if n, ok := mids[[2]int{a, b}]; ok {
+			return n
+		}
+		nodes = append(nodes, r3.Scale(0.5, r3.Add(nodes[a], nodes[b])))
+		mids[[2]int{a, b}] = len(nodes) - 1
+		return len(nodes) - 1
+	}
+	for j := 0; j < ny-1; j++ {
+		for i := 0; i < nx-1; i++ {
+			elem := []int{corner(i, j), corner(i+1, j), corner(i+1, j+1), corner(i, j+1)}
+			if quadratic {
+				elem = append(elem, mid(elem[0], elem[1]), mid(elem[1], elem[2]), mid(elem[2], elem[3]), mid(elem[3], elem[0]))
+			}
+			elems = append(elems, elem)
+		}
+	}
+	return nodes, elems
+}
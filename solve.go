@@ -0,0 +1,179 @@
+package fem
+
+import (
+	"fmt"
+
+	"github.com/soypat/lap"
+)
+
+// LinearStatic solves the linear static problem K·u = F + R for the displacements u
+// of the model's free dofs given the loads F, where R are the reaction forces at the fixed dofs.
+// The fixed dofs of fix are set to their prescribed values. K is usually the stiffness
+// matrix returned by GeneralAssembler.Ksolid.
+//
+// LinearStatic returns the full displacement vector, including fixed dofs, and the reaction
+// forces R = K·u - F which are zero at free dofs. Both are indexed by the model's global dofs.
+// Use LinearStaticSolver to solve multiple load cases with a single factorization of K.
+func LinearStatic(K lap.Matrix, loads lap.Vector, fix Fixity) (displacements, reactions *lap.DenseV, err error) {
+	solver, err := NewLinearStaticSolver(K, fix)
+	if err != nil {
+		return nil, nil, err
+	}
+	return solver.Solve(loads)
+}
+
+// LinearStaticSolver solves the linear static problem for multiple load cases
+// of a model with the same stiffness matrix and fixity. The stiffness matrix
+// restricted to the free dofs is factorized once with SparseCholesky.
+type LinearStaticSolver struct {
+	part staticPartition
+	chol SparseCholesky
+}
+
+// NewLinearStaticSolver factorizes the stiffness matrix K of the model's free dofs
+// as given by fix. The prescribed values of fix are stored at the time of the call.
+func NewLinearStaticSolver(K lap.Matrix, fix Fixity) (*LinearStaticSolver, error) {
+	s := &LinearStaticSolver{}
+	Kff, err := s.part.reset(K, fix)
+	if err != nil {
+		return nil, err
+	}
+	if Kff.n > 0 {
+		err = s.chol.analyze(Kff)
+		if err == nil {
+			err = s.chol.factorize(Kff)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("factorizing stiffness matrix, check model fixity: %w", err)
+		}
+	}
+	return s, nil
+}
+
+// Solve returns the displacements and reactions of the model for the given loads.
+// See LinearStatic for the details.
+func (s *LinearStaticSolver) Solve(loads lap.Vector) (displacements, reactions *lap.DenseV, err error) {
+	rhs, err := s.part.rhs(loads)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(rhs) > 0 {
+		s.chol.solve(rhs)
+	}
+	displacements, reactions = s.part.results(loads, rhs)
+	return displacements, reactions, nil
+}
+
+// staticPartition partitions the linear static problem K·u = F + R into
+// the free dofs, which are solved for, and the fixed dofs with prescribed values.
+type staticPartition struct {
+	n       int
+	free    []int
+	freeIdx []int
+	// Prescribed displacements.
+	up *lap.DenseV
+	// Coupling of free dofs with fixed dofs and rows of fixed dofs in triplet form.
+	kfp, kp triplets
+}
+
+type triplets struct {
+	i, j []int
+	v    []float64
+}
+
+func (t *triplets) add(i, j int, v float64) {
+	t.i = append(t.i, i)
+	t.j = append(t.j, j)
+	t.v = append(t.v, v)
+}
+
+// reset partitions K as given by fix and returns the stiffness matrix of the free dofs.
+func (sp *staticPartition) reset(K lap.Matrix, fix Fixity) (Kff *CSR, err error) {
+	n, c := K.Dims()
+	switch {
+	case n != c:
+		return nil, fmt.Errorf("expected square stiffness matrix, got %dx%d", n, c)
+	case fix.TotalDofs() != n:
+		return nil, fmt.Errorf("fixity total dofs %d does not match stiffness matrix dimension %d", fix.TotalDofs(), n)
+	}
+	*sp = staticPartition{
+		n:       n,
+		free:    fix.FreeDofs(),
+		freeIdx: make([]int, n),
+		up:      fix.Prescribed(),
+	}
+	// Map global dofs to free dofs. Fixed dofs are set to -1.
+	for i := range sp.freeIdx {
+		sp.freeIdx[i] = -1
+	}
+	for i, dof := range sp.free {
+		sp.freeIdx[dof] = i
+	}
+	Kff = newCSRBuilder(len(sp.free))
+	doNonZero(K, func(i, j int, v float64) {
+		fi, fj := sp.freeIdx[i], sp.freeIdx[j]
+		switch {
+		case fi >= 0 && fj >= 0:
+			Kff.add(fi, fj, v)
+		case fi >= 0:
+			sp.kfp.add(fi, j, v)
+		default:
+			sp.kp.add(i, j, v)
+		}
+	})
+	return Kff.compress(), nil
+}
+
+// rhs returns the right hand side of the free dofs problem Kff·uf = Ff - Kfp·up.
+func (sp *staticPartition) rhs(loads lap.Vector) ([]float64, error) {
+	if loads.Len() != sp.n {
+		return nil, fmt.Errorf("loads vector length %d does not match stiffness matrix dimension %d", loads.Len(), sp.n)
+	}
+	rhs := make([]float64, len(sp.free))
+	for i, dof := range sp.free {
+		rhs[i] = loads.AtVec(dof)
+	}
+	for k, fi := range sp.kfp.i {
+		rhs[fi] -= sp.kfp.v[k] * sp.up.AtVec(sp.kfp.j[k])
+	}
+	return rhs, nil
+}
+
+// results returns the displacements of all dofs given those of the free dofs uf
+// and the reactions R = K·u - F at the fixed dofs.
+func (sp *staticPartition) results(loads lap.Vector, uf []float64) (displacements, reactions *lap.DenseV) {
+	displacements = lap.NewDenseVector(sp.n, nil)
+	displacements.CopyVec(sp.up)
+	for i, dof := range sp.free {
+		displacements.SetVec(dof, uf[i])
+	}
+	reactions = lap.NewDenseVector(sp.n, nil)
+	for k, i := range sp.kp.i {
+		reactions.SetVec(i, reactions.AtVec(i)+sp.kp.v[k]*displacements.AtVec(sp.kp.j[k]))
+	}
+	for i := 0; i < sp.n; i++ {
+		if sp.freeIdx[i] < 0 {
+			reactions.SetVec(i, reactions.AtVec(i)-loads.AtVec(i))
+		}
+	}
+	return displacements, reactions
+}
+
+// doNonZero calls fn for the non-zero entries of m. Sparse matrices
+// such as lap.Sparse are iterated over efficiently.
+func doNonZero(m lap.Matrix, fn func(i, j int, v float64)) {
+	if nz, ok := m.(interface {
+		DoNonZero(func(i, j int, v float64))
+	}); ok {
+		nz.DoNonZero(fn)
+		return
+	}
+	r, c := m.Dims()
+	for i := 0; i < r; i++ {
+		for j := 0; j < c; j++ {
+			if v := m.At(i, j); v != 0 {
+				fn(i, j, v)
+			}
+		}
+	}
+}
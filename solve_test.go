@@ -0,0 +1,155 @@
+package fem_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/soypat/go-fem"
+	"github.com/soypat/go-fem/constitution/solids"
+	"github.com/soypat/go-fem/elements"
+	"github.com/soypat/lap"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+func TestLinearStatic(t *testing.T) {
+	const (
+		tol    = 1e-9
+		nNodes = 40
+	)
+	rng := rand.New(rand.NewSource(1))
+	dofs := fem.DofPosX | fem.DofPosY
+	n := nNodes * dofs.Count()
+	// Random sparse symmetric positive definite matrix.
+	K := lap.NewSparse(n, n)
+	for i := 0; i < n; i++ {
+		K.Set(i, i, 10+rng.Float64())
+		for k := 0; k < 3; k++ {
+			j := rng.Intn(n)
+			if j == i {
+				continue
+			}
+			v := rng.Float64() - 0.5
+			K.Set(i, j, K.At(i, j)+v)
+			K.Set(j, i, K.At(j, i)+v)
+		}
+	}
+	fix := fem.NewFixity(dofs, nNodes)
+	loads := lap.NewDenseVector(n, nil)
+	for i := 0; i < nNodes; i++ {
+		switch rng.Intn(4) {
+		case 0:
+			fix.Fix(i, fem.DofPosX)
+		case 1:
+			fix.Prescribe(i, fem.DofPosY, rng.Float64())
+		case 2:
+			fix.Prescribe(i, dofs, rng.Float64())
+		}
+	}
+	for i := 0; i < n; i++ {
+		loads.SetVec(i, rng.Float64()-0.5)
+	}
+	u, R, err := fem.LinearStatic(K, loads, fix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Compare with dense solution of the free dofs.
+	free, fixed := fix.FreeDofs(), fix.FixedDofs()
+	prescribed := fix.Prescribed()
+	Kff := mat.NewDense(len(free), len(free), nil)
+	rhs := mat.NewVecDense(len(free), nil)
+	for i, fi := range free {
+		rhs.SetVec(i, loads.AtVec(fi))
+		for j, fj := range free {
+			Kff.Set(i, j, K.At(fi, fj))
+		}
+		for _, fj := range fixed {
+			rhs.SetVec(i, rhs.AtVec(i)-K.At(fi, fj)*prescribed.AtVec(fj))
+		}
+	}
+	var want mat.VecDense
+	err = want.SolveVec(Kff, rhs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, fi := range free {
+		if math.Abs(u.AtVec(fi)-want.AtVec(i)) > tol {
+			t.Errorf("free dof %d: want displacement %g, got %g", fi, want.AtVec(i), u.AtVec(fi))
+		}
+		if R.AtVec(fi) != 0 {
+			t.Errorf("free dof %d: expected no reaction, got %g", fi, R.AtVec(fi))
+		}
+	}
+	for _, fi := range fixed {
+		if u.AtVec(fi) != prescribed.AtVec(fi) {
+			t.Errorf("fixed dof %d: want prescribed displacement %g, got %g", fi, prescribed.AtVec(fi), u.AtVec(fi))
+		}
+	}
+	// K·u = F + R
+	Ku := lap.NewDenseVector(n, nil)
+	Ku.MulVec(K, u)
+	for i := 0; i < n; i++ {
+		if math.Abs(Ku.AtVec(i)-loads.AtVec(i)-R.AtVec(i)) > tol {
+			t.Errorf("dof %d: equilibrium not satisfied K·u=%g, F+R=%g", i, Ku.AtVec(i), loads.AtVec(i)+R.AtVec(i))
+		}
+	}
+}
+
+func TestLinearStaticReactions(t *testing.T) {
+	const (
+		tol   = 1e-9
+		force = 1000.
+	)
+	// Plate of 2x1 in tension, clamped on its left edge.
+	nodes, elems := rectangularMesh([]float64{0, 0.5, 1, 2}, []float64{0, 0.4, 1}, false)
+	elemT := elements.Quad4{}
+	material := solids.Isotropic{E: 200e3, Poisson: 0.3}
+	ga := fem.NewGeneralAssembler(nodes, elemT.Dofs())
+	err := ga.AddIsoparametric(elemT, material.PlaneStess(), len(elems), func(i int) ([]int, r3.Vec, r3.Vec) {
+		return elems[i], r3.Vec{}, r3.Vec{}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fix := fem.NewFixity(elemT.Dofs(), len(nodes))
+	loads := lap.NewDenseVector(ga.TotalDofs(), nil)
+	for i, node := range nodes {
+		switch node.X {
+		case 0:
+			fix.Fix(i, elemT.Dofs())
+		case 2:
+			loads.SetVec(2*i, force)
+		}
+	}
+	_, R, err := fem.LinearStatic(ga.Ksolid(), loads, fix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sumX, sumY float64
+	for i := range nodes {
+		sumX += R.AtVec(2 * i)
+		sumY += R.AtVec(2*i + 1)
+	}
+	appliedX := 3 * force
+	if math.Abs(sumX+appliedX) > tol*appliedX || math.Abs(sumY) > tol*appliedX {
+		t.Errorf("reactions (%g,%g) do not balance applied load (%g,0)", sumX, sumY, appliedX)
+	}
+}
+
+func TestLinearStaticSingular(t *testing.T) {
+	// Unrestrained model.
+	nodes, elems := rectangularMesh([]float64{0, 1}, []float64{0, 1}, false)
+	elemT := elements.Quad4{}
+	ga := fem.NewGeneralAssembler(nodes, elemT.Dofs())
+	err := ga.AddIsoparametric(elemT, solids.Isotropic{E: 1, Poisson: 0.3}.PlaneStess(), len(elems), func(i int) ([]int, r3.Vec, r3.Vec) {
+		return elems[i], r3.Vec{}, r3.Vec{}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = fem.LinearStatic(ga.Ksolid(), lap.NewDenseVector(ga.TotalDofs(), nil), fem.NewFixity(elemT.Dofs(), len(nodes)))
+	if err == nil {
+		t.Error("expected error solving unrestrained model")
+	}
+}
@@ -0,0 +1,148 @@
+package fem
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/soypat/lap"
+)
+
+// CSR is a square sparse matrix in compressed sparse row format with column
+// indices sorted in increasing order within each row. It is efficient for
+// matrix-vector products and implements Operator and lap.Matrix.
+type CSR struct {
+	n int
+	// Row pointers into col and val. Entries of row i are in [rowPtr[i], rowPtr[i+1]).
+	rowPtr []int
+	col    []int
+	val    []float64
+	// triplets added before compression.
+	ti, tj []int
+	tv     []float64
+}
+
+// NewCSR returns square matrix A in compressed sparse row format. Sparse
+// matrices that implement DoNonZero such as lap.Sparse are read efficiently.
+func NewCSR(A lap.Matrix) (*CSR, error) {
+	r, c := A.Dims()
+	if r != c {
+		return nil, fmt.Errorf("expected square matrix, got %dx%d", r, c)
+	}
+	m := newCSRBuilder(r)
+	doNonZero(A, m.add)
+	return m.compress(), nil
+}
+
+// newCSRBuilder returns an empty matrix of dimension n. Entries are
+// first added to the matrix in triplet form and then compressed.
+func newCSRBuilder(n int) *CSR {
+	return &CSR{n: n}
+}
+
+// add adds v to entry (i,j) of the matrix. Must be called before compress.
+func (m *CSR) add(i, j int, v float64) {
+	m.ti = append(m.ti, i)
+	m.tj = append(m.tj, j)
+	m.tv = append(m.tv, v)
+}
+
+// compress sums duplicate triplets and builds the row compressed storage
+// with column indices sorted in increasing order within each row.
+func (m *CSR) compress() *CSR {
+	// Bucket triplets by row.
+	start := make([]int, m.n+1)
+	for _, i := range m.ti {
+		start[i+1]++
+	}
+	for i := 0; i < m.n; i++ {
+		start[i+1] += start[i]
+	}
+	next := append([]int{}, start[:m.n]...)
+	byRow := make([]int, len(m.ti))
+	for k, i := range m.ti {
+		byRow[next[i]] = k
+		next[i]++
+	}
+	m.rowPtr = make([]int, m.n+1)
+	m.col = make([]int, 0, len(m.ti))
+	m.val = make([]float64, 0, len(m.ti))
+	for i := 0; i < m.n; i++ {
+		row := byRow[start[i]:start[i+1]]
+		sort.Slice(row, func(a, b int) bool { return m.tj[row[a]] < m.tj[row[b]] })
+		for _, k := range row {
+			j := m.tj[k]
+			if last := len(m.col) - 1; last >= m.rowPtr[i] && m.col[last] == j {
+				m.val[last] += m.tv[k]
+				continue
+			}
+			m.col = append(m.col, j)
+			m.val = append(m.val, m.tv[k])
+		}
+		m.rowPtr[i+1] = len(m.col)
+	}
+	m.ti, m.tj, m.tv = nil, nil, nil
+	return m
+}
+
+// Dims returns the dimensions of the matrix.
+func (m *CSR) Dims() (r, c int) { return m.n, m.n }
+
+// At returns the value of entry (i,j) of the matrix.
+func (m *CSR) At(i, j int) float64 {
+	if uint(i) >= uint(m.n) {
+		panic(lap.ErrRowAccess)
+	} else if uint(j) >= uint(m.n) {
+		panic(lap.ErrColAccess)
+	}
+	row := m.col[m.rowPtr[i]:m.rowPtr[i+1]]
+	k := sort.SearchInts(row, j)
+	if k < len(row) && row[k] == j {
+		return m.val[m.rowPtr[i]+k]
+	}
+	return 0
+}
+
+// DoNonZero calls fn for each of the stored entries of the matrix in row major order.
+func (m *CSR) DoNonZero(fn func(i, j int, v float64)) {
+	for i := 0; i < m.n; i++ {
+		for p := m.rowPtr[i]; p < m.rowPtr[i+1]; p++ {
+			fn(i, m.col[p], m.val[p])
+		}
+	}
+}
+
+// MulVecTo stores A·x in dst.
+func (m *CSR) MulVecTo(dst, x []float64) {
+	for i := 0; i < m.n; i++ {
+		sum := 0.0
+		for p := m.rowPtr[i]; p < m.rowPtr[i+1]; p++ {
+			sum += m.val[p] * x[m.col[p]]
+		}
+		dst[i] = sum
+	}
+}
+
+// diagonal returns the diagonal entries of the matrix.
+func (m *CSR) diagonal() []float64 {
+	d := make([]float64, m.n)
+	for i := range d {
+		d[i] = m.At(i, i)
+	}
+	return d
+}
+
+// sumCSR returns the linear combination Σ coefs[k]·mats[k] of square
+// matrices of equal dimensions in compressed sparse row format.
+func sumCSR(coefs []float64, mats ...lap.Matrix) (*CSR, error) {
+	n, _ := mats[0].Dims()
+	m := newCSRBuilder(n)
+	for k, A := range mats {
+		r, c := A.Dims()
+		if r != n || c != n {
+			return nil, fmt.Errorf("matrix %d dimensions %dx%d do not match dimension %d", k, r, c, n)
+		}
+		coef := coefs[k]
+		doNonZero(A, func(i, j int, v float64) { m.add(i, j, coef*v) })
+	}
+	return m.compress(), nil
+}
@@ -0,0 +1,139 @@
+package fem
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/soypat/lap"
+)
+
+// ThetaMethod integrates the transient heat conduction problem C·Ṫ + K·T = Q(t) in time with
+// the generalised trapezoidal rule, where C is the heat capacity matrix, K the conductivity
+// matrix, T the temperatures and Q the heat loads. Each step solves
+//
+//	(C/Δt + θ·K)·Tₙ₊₁ = (C/Δt - (1-θ)·K)·Tₙ + θ·Qₙ₊₁ + (1-θ)·Qₙ
+//
+// for the free dofs with a single SparseCholesky factorization of the left hand side matrix.
+type ThetaMethod struct {
+	// Theta θ in [0, 1] weights the end of each step. Forward Euler is θ=0,
+	// Crank-Nicolson θ=1/2 and backward Euler θ=1. The method is unconditionally
+	// stable for θ ≥ 1/2, though Crank-Nicolson may oscillate for large time steps
+	// and sudden changes of temperature. Backward Euler damps the oscillations.
+	Theta float64
+	// TimeStep Δt of the integration.
+	TimeStep float64
+	// Loads stores the heat loads Q of the model at time t in dst, which is
+	// indexed by the model's global dofs and zeroed before each call. The
+	// model has no heat loads if nil.
+	Loads func(dst *lap.DenseV, t float64)
+	// Prescribed stores the temperatures of the fixed dofs at time t in dst, which is
+	// indexed by the model's global dofs and holds the temperatures of the previous step.
+	// Values of free dofs are ignored. The prescribed values of the fixity are used if nil.
+	Prescribed func(dst *lap.DenseV, t float64)
+}
+
+// HeatTransient integrates the temperatures of a model with conductivity matrix K and heat capacity
+// matrix C, usually those returned by GeneralAssembler.Ksolid and GeneralAssembler.Msolid, over
+// the given number of steps from the initial temperatures T0. The temperatures of the fixed dofs of fix
+// are those given by the Prescribed field, which overwrite their initial temperatures.
+//
+// stepCallback is called with the temperatures of all dofs at time t = step·Δt, starting with
+// the initial temperatures at step 0. The temperatures vector is not modified after the call.
+func (tm *ThetaMethod) HeatTransient(K, C lap.Matrix, fix Fixity, T0 lap.Vector, steps int, stepCallback func(step int, t float64, temperatures *lap.DenseV)) error {
+	theta, dt := tm.Theta, tm.TimeStep
+	switch {
+	case theta < 0 || theta > 1 || math.IsNaN(theta):
+		return fmt.Errorf("theta must be in [0, 1], got %g", theta)
+	case dt <= 0 || math.IsNaN(dt) || math.IsInf(dt, 0):
+		return fmt.Errorf("time step must be a positive finite number, got %g", dt)
+	case steps < 0:
+		return errors.New("number of steps must be non-negative")
+	}
+	var part staticPartition
+	A, err := sumCSR([]float64{1 / dt, theta}, C, K)
+	if err != nil {
+		return err
+	}
+	Aff, err := part.reset(A, fix)
+	if err != nil {
+		return err
+	} else if T0.Len() != part.n {
+		return fmt.Errorf("initial temperatures length %d does not match conductivity matrix dimension %d", T0.Len(), part.n)
+	}
+	var chol SparseCholesky
+	if Aff.n > 0 {
+		err = chol.analyze(Aff)
+		if err == nil {
+			err = chol.factorize(Aff)
+		}
+		if err != nil {
+			return fmt.Errorf("factorizing time step matrix, check capacity matrix and fixity: %w", err)
+		}
+	}
+	Kc, err := NewCSR(K)
+	if err != nil {
+		return err
+	}
+	Cc, err := NewCSR(C)
+	if err != nil {
+		return err
+	}
+	n := part.n
+	loads := func(t float64) *lap.DenseV {
+		q := lap.NewDenseVector(n, nil)
+		if tm.Loads != nil {
+			tm.Loads(q, t)
+		}
+		return q
+	}
+	prescribed := part.up
+	if tm.Prescribed != nil {
+		tm.Prescribed(prescribed, 0)
+	}
+	T := lap.NewDenseVector(n, nil)
+	T.CopyVec(T0)
+	for i := 0; i < n; i++ {
+		if part.freeIdx[i] < 0 {
+			T.SetVec(i, prescribed.AtVec(i))
+		}
+	}
+	stepCallback(0, 0, T)
+	q0 := loads(0)
+	var (
+		Tn  = make([]float64, n)
+		KT  = make([]float64, n)
+		CT  = make([]float64, n)
+		rhs = lap.NewDenseVector(n, nil)
+	)
+	for step := 1; step <= steps; step++ {
+		t := float64(step) * dt
+		q1 := loads(t)
+		if tm.Prescribed != nil {
+			next := lap.NewDenseVector(n, nil)
+			next.CopyVec(prescribed)
+			tm.Prescribed(next, t)
+			prescribed = next
+		}
+		for i := range Tn {
+			Tn[i] = T.AtVec(i)
+		}
+		Kc.MulVecTo(KT, Tn)
+		Cc.MulVecTo(CT, Tn)
+		for i := range Tn {
+			rhs.SetVec(i, CT[i]/dt-(1-theta)*KT[i]+theta*q1.AtVec(i)+(1-theta)*q0.AtVec(i))
+		}
+		part.up = prescribed
+		Tf, err := part.rhs(rhs)
+		if err != nil {
+			return err
+		}
+		if len(Tf) > 0 {
+			chol.solve(Tf)
+		}
+		T, _ = part.results(rhs, Tf)
+		stepCallback(step, t, T)
+		q0 = q1
+	}
+	return nil
+}
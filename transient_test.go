@@ -0,0 +1,172 @@
+package fem_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/soypat/go-fem"
+	"github.com/soypat/go-fem/constitution/solids"
+	"github.com/soypat/go-fem/elements"
+	"github.com/soypat/lap"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+func TestHeatTransientSlab(t *testing.T) {
+	// Slab of unit length and diffusivity with ends held at zero temperature.
+	// A sinusoidal temperature decays as T = sin(π·x)·exp(-π²·t).
+	const tol = 5e-3
+	xs := make([]float64, 41)
+	for i := range xs {
+		xs[i] = float64(i) / float64(len(xs)-1)
+	}
+	nodes, elems := rectangularMesh(xs, []float64{0, 0.05}, false)
+	ga, fix := thermalModel(t, nodes, elems, solids.IsotropicConductivity{K: 1}.Plane())
+	T0 := lap.NewDenseVector(ga.TotalDofs(), nil)
+	for i, node := range nodes {
+		if node.X == 0 || node.X == 1 {
+			fix.Fix(i, fem.DofPosX)
+		}
+		T0.SetVec(i, math.Sin(math.Pi*node.X))
+	}
+	tm := fem.ThetaMethod{Theta: 0.5, TimeStep: 1e-3}
+	calls := 0
+	err := tm.HeatTransient(ga.Ksolid(), ga.Msolid(), fix, T0, 100, func(step int, time float64, T *lap.DenseV) {
+		if step != calls {
+			t.Errorf("expected step %d, got %d", calls, step)
+		}
+		calls++
+		decay := math.Exp(-math.Pi * math.Pi * time)
+		for i, node := range nodes {
+			want := math.Sin(math.Pi*node.X) * decay
+			if math.Abs(T.AtVec(i)-want) > tol {
+				t.Fatalf("step %d node %d: want temperature %g, got %g", step, i, want, T.AtVec(i))
+			}
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 101 {
+		t.Errorf("expected 101 step callbacks, got %d", calls)
+	}
+}
+
+func TestHeatTransientAxisymmetric(t *testing.T) {
+	// Thick cylinder heated from zero at its inner surface with the outer surface
+	// held at zero reaches the steady state T = Ti·ln(ro/r)/ln(ro/ri).
+	const (
+		tol    = 1e-6
+		ri, ro = 1.0, 2.0
+		Ti     = 100.0
+		tramp  = 0.1
+	)
+	xs := make([]float64, 21)
+	for i := range xs {
+		xs[i] = ri + (ro-ri)*float64(i)/float64(len(xs)-1)
+	}
+	nodes, elems := rectangularMesh(xs, []float64{0, 0.05}, false)
+	ga, fix := thermalModel(t, nodes, elems, solids.IsotropicConductivity{K: 1}.Axisymmetric())
+	for i, node := range nodes {
+		if node.X == ri || node.X == ro {
+			fix.Fix(i, fem.DofPosX)
+		}
+	}
+	tm := fem.ThetaMethod{
+		Theta:    1,
+		TimeStep: 0.01,
+		Prescribed: func(dst *lap.DenseV, time float64) {
+			for i, node := range nodes {
+				if node.X == ri {
+					dst.SetVec(i, Ti*math.Min(time/tramp, 1))
+				}
+			}
+		},
+	}
+	var last *lap.DenseV
+	err := tm.HeatTransient(ga.Ksolid(), ga.Msolid(), fix, lap.NewDenseVector(ga.TotalDofs(), nil), 1000, func(step int, time float64, T *lap.DenseV) {
+		for i, node := range nodes {
+			if node.X == ri && T.AtVec(i) != Ti*math.Min(time/tramp, 1) {
+				t.Fatalf("step %d: inner surface temperature %g not prescribed", step, T.AtVec(i))
+			}
+			if T.AtVec(i) < -tol || T.AtVec(i) > Ti+tol {
+				t.Fatalf("step %d node %d: temperature %g out of bounds", step, i, T.AtVec(i))
+			}
+		}
+		last = T
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Compare with the steady state of the same mesh.
+	for i, node := range nodes {
+		if node.X == ri {
+			fix.Prescribe(i, fem.DofPosX, Ti)
+		}
+	}
+	steady, _, err := fem.LinearStatic(ga.Ksolid(), lap.NewDenseVector(ga.TotalDofs(), nil), fix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, node := range nodes {
+		if math.Abs(last.AtVec(i)-steady.AtVec(i)) > tol {
+			t.Errorf("node %d: want steady temperature %g, got %g", i, steady.AtVec(i), last.AtVec(i))
+		}
+		want := Ti * math.Log(ro/node.X) / math.Log(ro/ri)
+		if math.Abs(last.AtVec(i)-want) > 1e-3*Ti {
+			t.Errorf("node %d: want analytical temperature %g, got %g", i, want, last.AtVec(i))
+		}
+	}
+}
+
+func TestHeatTransientLoads(t *testing.T) {
+	// An insulated body stores all heat supplied. The trapezoidal
+	// rule integrates a linearly increasing heat load exactly.
+	const tol = 1e-9
+	nodes, elems := rectangularMesh([]float64{0, 0.5, 1}, []float64{0, 0.5, 1}, true)
+	ga, fix := thermalModel(t, nodes, elems, solids.IsotropicConductivity{K: 2}.Plane())
+	C := ga.Msolid()
+	heated := len(nodes) - 1
+	tm := fem.ThetaMethod{
+		Theta:    0.5,
+		TimeStep: 0.1,
+		Loads: func(dst *lap.DenseV, time float64) {
+			dst.SetVec(heated, time)
+		},
+	}
+	err := tm.HeatTransient(ga.Ksolid(), C, fix, lap.NewDenseVector(ga.TotalDofs(), nil), 20, func(step int, time float64, T *lap.DenseV) {
+		CT := lap.NewDenseVector(T.Len(), nil)
+		CT.MulVec(C, T)
+		var heat float64
+		for i := 0; i < CT.Len(); i++ {
+			heat += CT.AtVec(i)
+		}
+		if want := time * time / 2; math.Abs(heat-want) > tol {
+			t.Errorf("step %d: want stored heat %g, got %g", step, want, heat)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// thermalModel assembles the conductivity and heat capacity matrices of a unit
+// density and specific heat mesh of Quad4 or Quad8 elements with a single
+// temperature dof per node and returns them with an empty fixity.
+func thermalModel(t *testing.T, nodes []r3.Vec, elems [][]int, c fem.IsoConstituter) (*fem.GeneralAssembler, fem.Fixity) {
+	t.Helper()
+	var elemT fem.Isoparametric = elements.Quad4{NodeDofs: fem.DofPosX}
+	if len(elems[0]) == 8 {
+		elemT = elements.Quad8{NodeDofs: fem.DofPosX}
+	}
+	ga := fem.NewGeneralAssembler(nodes, elemT.Dofs())
+	getElement := func(i int) ([]int, r3.Vec, r3.Vec) { return elems[i], r3.Vec{}, r3.Vec{} }
+	err := ga.AddIsoparametric(elemT, c, len(elems), getElement)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ga.AddIsoparametricCapacity(elemT, c, 1, 1, len(elems), getElement)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ga, fem.NewFixity(elemT.Dofs(), len(nodes))
+}